@@ -1,8 +1,15 @@
 package main
 
 import (
+	"callcenter/internal/config"
 	"callcenter/internal/database"
+	"callcenter/internal/events"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/payment"
 	"callcenter/internal/routes"
+	"callcenter/internal/services"
+	"context"
 	"log"
 	"os"
 
@@ -17,12 +24,58 @@ func main() {
 		log.Printf("Warning: .env file not found")
 	}
 
+	// Load application configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	// Initialize database
 	db, err := database.InitDB()
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Log cancellations so they can be followed up outside the API
+	events.Subscribe(events.BookingCancelled, func(ctx context.Context, event events.Event) {
+		log.Printf("booking %s cancelled by %v: refund %v %v", event.AggregateID, event.Data["actor"], event.Data["refund_amount"], event.Data["currency"])
+	})
+
+	// Log SLA alerts until they are delivered to agents and supervisors
+	events.Subscribe(events.TicketSLAWarning, func(ctx context.Context, event events.Event) {
+		log.Printf("ticket %v: %v target due at %v", event.Data["number"], event.Data["target"], event.Data["due_at"])
+	})
+	events.Subscribe(events.TicketSLABreach, func(ctx context.Context, event events.Event) {
+		log.Printf("ticket %v: %v target missed, was due at %v", event.Data["number"], event.Data["target"], event.Data["due_at"])
+	})
+	events.Subscribe(events.TicketEscalated, func(ctx context.Context, event events.Event) {
+		log.Printf("ticket %v escalated to supervisors %v", event.Data["number"], event.Data["supervisors"])
+	})
+
+	// Log comments and mentions until they are delivered by email and to agents
+	events.Subscribe(events.TicketCommented, func(ctx context.Context, event events.Event) {
+		if event.Data["visibility"] == models.CommentPublic && event.Data["channel"] == "" {
+			log.Printf("ticket %v: reply to customer %v has no chat session to be sent to", event.Data["number"], event.Data["customer_id"])
+		}
+	})
+	events.Subscribe(events.TicketMentioned, func(ctx context.Context, event events.Event) {
+		log.Printf("ticket %v: agents %v mentioned by %v", event.Data["number"], event.Data["mentions"], event.Data["author_id"])
+	})
+
+	// Flag upcoming and missed SLA targets
+	go services.NewSLAService(db).Run(context.Background(), cfg.SLACheckInterval)
+
+	// Run bulk ticket jobs queued by supervisors
+	bulkJobs := services.NewBulkService(db, services.NewTicketService(db, cfg.TicketNumberFormat))
+	go bulkJobs.Run(context.Background(), cfg.BulkJobPollInterval)
+
+	// Pay approved refunds when a payment gateway is configured
+	if cfg.PaymentGatewayAPIURL != "" {
+		gateway := payment.NewClient(cfg.PaymentGatewayAPIKey, cfg.PaymentGatewayAPIURL)
+		payouts := services.NewRefundPayoutService(db, gateway, cfg.PaymentCallbackURL)
+		go payouts.Run(context.Background(), cfg.PaymentPollInterval)
+	}
+
 	// Create Gin router
 	r := gin.Default()
 
@@ -40,10 +93,21 @@ func main() {
 		c.Next()
 	})
 
+	// Resolve the response language from Accept-Language
+	r.Use(middleware.Language(cfg.DefaultLanguage))
+
 	// Setup routes
 	routes.SetupAuthRoutes(r, db)
-	routes.SetupChatRoutes(r, db)
-	routes.SetupTicketRoutes(r, db)
+	routes.SetupChatRoutes(r, db, cfg)
+	routes.SetupTicketRoutes(r, db, cfg)
+	routes.SetupQueueRoutes(r, db)
+	routes.SetupSLARoutes(r, db)
+	routes.SetupCalendarRoutes(r, db)
+	routes.SetupBookingRoutes(r, db)
+	routes.SetupRefundPolicyRoutes(r, db)
+	routes.SetupPaymentRoutes(r, db, cfg)
+	routes.SetupLabelingRoutes(r, db, cfg)
+	routes.SetupKnowledgeRoutes(r, db, cfg)
 
 	// Start server
 	port := os.Getenv("PORT")
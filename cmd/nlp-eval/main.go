@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"callcenter/internal/config"
+	"callcenter/internal/fuzzy"
+	"callcenter/internal/nlpeval"
+	"callcenter/internal/services"
+	"callcenter/internal/ticketnumber"
+
+	"github.com/joho/godotenv"
+)
+
+// engines lists the NLPService implementations that can be evaluated.
+// The fuzzy options only apply to engines that match keywords with typo tolerance.
+var engines = map[string]func(opts fuzzy.Options, numbers ticketnumber.Format) services.NLPService{
+	"keyword": func(opts fuzzy.Options, numbers ticketnumber.Format) services.NLPService {
+		return services.NewNLPServiceWithOptions(opts, numbers)
+	},
+	"keyword-exact": func(_ fuzzy.Options, numbers ticketnumber.Format) services.NLPService {
+		return services.NewNLPServiceWithOptions(fuzzy.Options{}, numbers)
+	},
+}
+
+func main() {
+	datasetPath := flag.String("dataset", "data/nlp/intents.jsonl", "labelled JSONL dataset")
+	baselinePath := flag.String("baseline", "data/nlp/baseline.json", "baseline scores to compare against")
+	engine := flag.String("engine", "keyword", "NLP engine to evaluate ("+strings.Join(engineNames(), ", ")+")")
+	tolerance := flag.Float64("tolerance", 0.005, "allowed drop below the baseline before failing")
+	updateBaseline := flag.Bool("update-baseline", false, "write the current scores as the new baseline")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	editRatio := flag.Float64("fuzzy-ratio", fuzzy.DefaultOptions.MaxEditRatio, "share of a keyword's characters that may be mistyped")
+	minLength := flag.Int("fuzzy-min-length", fuzzy.DefaultOptions.MinLength, "shortest keyword matched fuzzily")
+	phonetic := flag.Bool("phonetic", fuzzy.DefaultOptions.Phonetic, "match keywords that sound alike")
+	benchRounds := flag.Int("bench", 0, "time intent detection over the dataset this many times instead of scoring it")
+	flag.Parse()
+
+	newService, ok := engines[*engine]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown engine %q\n", *engine)
+		os.Exit(2)
+	}
+
+	examples, err := nlpeval.LoadDataset(*datasetPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading dataset: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Ticket numbers are extracted in the format the API is configured with
+	_ = godotenv.Load()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	nlp := newService(fuzzy.Options{MaxEditRatio: *editRatio, MinLength: *minLength, Phonetic: *phonetic}, cfg.TicketNumberFormat)
+
+	if *benchRounds > 0 {
+		result, err := nlpeval.Benchmark(context.Background(), nlp, examples, *benchRounds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error benchmarking: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Println(result)
+		return
+	}
+
+	report, err := nlpeval.Evaluate(context.Background(), nlp, examples)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error evaluating dataset: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.Print(os.Stdout)
+	}
+
+	if *updateBaseline {
+		if err := nlpeval.BaselineFromReport(report).Save(*baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving baseline: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "Baseline written to %s\n", *baselinePath)
+		return
+	}
+
+	if *baselinePath == "" {
+		return
+	}
+	baseline, err := nlpeval.LoadBaseline(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+		os.Exit(2)
+	}
+
+	regressions := baseline.Compare(report, *tolerance)
+	if len(regressions) > 0 {
+		fmt.Fprintln(os.Stderr, "Scores dropped below the baseline:")
+		for _, r := range regressions {
+			fmt.Fprintf(os.Stderr, "  %s\n", r)
+		}
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "All scores are at or above the baseline")
+}
+
+func engineNames() []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,226 @@
+// Package calendar computes business time: the working hours of a week in a
+// time zone, less holidays, and durations and due times that only count those
+// hours, such as the SLA targets of support tickets.
+package calendar
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // time zones must load where the host has no zoneinfo
+)
+
+// maxDays bounds how far ahead a due time is searched for
+const maxDays = 3660
+
+// Span is a stretch of working hours within a day, in minutes after midnight
+type Span struct {
+	Start int
+	End   int
+}
+
+// Calendar is a working week in a time zone with its holidays. Days without
+// spans are days off.
+type Calendar struct {
+	Location *time.Location
+	Week     [7][]Span // by time.Weekday
+	Holidays []Holiday
+}
+
+// tehran is the time zone of the call center
+var tehran = mustLoadLocation("Asia/Tehran")
+
+// Default is the call center's working week: Saturday to Wednesday from 08:00
+// to 16:00 and Thursday morning until 12:00, Tehran time, off on the Iranian
+// holidays of a fixed date. It is used until calendars are set up.
+var Default = &Calendar{
+	Location: tehran,
+	Week: [7][]Span{
+		time.Saturday:  {{Start: 8 * 60, End: 16 * 60}},
+		time.Sunday:    {{Start: 8 * 60, End: 16 * 60}},
+		time.Monday:    {{Start: 8 * 60, End: 16 * 60}},
+		time.Tuesday:   {{Start: 8 * 60, End: 16 * 60}},
+		time.Wednesday: {{Start: 8 * 60, End: 16 * 60}},
+		time.Thursday:  {{Start: 8 * 60, End: 12 * 60}},
+	},
+	Holidays: IranianHolidays,
+}
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("calendar: %v", err))
+	}
+	return location
+}
+
+// Validate checks that the spans of every day are within the day, in order
+// and do not overlap, and that the holidays are valid dates
+func (c *Calendar) Validate() error {
+	for day, spans := range c.Week {
+		end := 0
+		for _, span := range spans {
+			if span.Start < end || span.End <= span.Start || span.End > 24*60 {
+				return fmt.Errorf("invalid working hours on %s", time.Weekday(day))
+			}
+			end = span.End
+		}
+	}
+	for _, holiday := range c.Holidays {
+		if err := holiday.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Holiday returns the holiday on the date of t, if any
+func (c *Calendar) Holiday(t time.Time) *Holiday {
+	t = t.In(c.location())
+	for i := range c.Holidays {
+		if c.Holidays[i].On(t) {
+			return &c.Holidays[i]
+		}
+	}
+	return nil
+}
+
+// Open reports whether t falls within working hours
+func (c *Calendar) Open(t time.Time) bool {
+	for _, interval := range c.intervals(c.midnight(t)) {
+		if !t.Before(interval.start) && t.Before(interval.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t if it falls within working hours, or else when working
+// hours start next. Without any working hours it returns t.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	if !c.hasHours() {
+		return t
+	}
+	day := c.midnight(t)
+	for i := 0; i < maxDays; i++ {
+		for _, interval := range c.intervals(day) {
+			if interval.end.After(t) {
+				if interval.start.After(t) {
+					return interval.start
+				}
+				return t
+			}
+		}
+		day = nextDay(day)
+	}
+	return t
+}
+
+// Add returns the time d of working hours after from. Without any working
+// hours the calendar counts wall-clock time.
+func (c *Calendar) Add(from time.Time, d time.Duration) time.Time {
+	if d <= 0 || !c.hasHours() {
+		return from.Add(d)
+	}
+
+	day := c.midnight(from)
+	for i := 0; i < maxDays; i++ {
+		for _, interval := range c.intervals(day) {
+			if !interval.end.After(from) {
+				continue
+			}
+			start := interval.start
+			if from.After(start) {
+				start = from
+			}
+			left := interval.end.Sub(start)
+			if d <= left {
+				return start.Add(d)
+			}
+			d -= left
+		}
+		day = nextDay(day)
+	}
+	return from.Add(d)
+}
+
+// Between returns the working hours from one time to another, zero if to is
+// not after from. Without any working hours the calendar counts wall-clock time.
+func (c *Calendar) Between(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if !c.hasHours() {
+		return to.Sub(from)
+	}
+
+	var total time.Duration
+	for day := c.midnight(from); day.Before(to); day = nextDay(day) {
+		for _, interval := range c.intervals(day) {
+			start, end := interval.start, interval.end
+			if from.After(start) {
+				start = from
+			}
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+	}
+	return total
+}
+
+// interval is a span of working hours on a date
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// intervals returns the working hours of the day starting at midnight; none
+// on holidays
+func (c *Calendar) intervals(midnight time.Time) []interval {
+	if c.Holiday(midnight) != nil {
+		return nil
+	}
+	spans := c.Week[midnight.Weekday()]
+	intervals := make([]interval, len(spans))
+	for i, span := range spans {
+		intervals[i] = interval{
+			start: clock(midnight, span.Start),
+			end:   clock(midnight, span.End),
+		}
+	}
+	return intervals
+}
+
+func (c *Calendar) hasHours() bool {
+	for _, spans := range c.Week {
+		if len(spans) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// midnight returns the start of the local day of t
+func (c *Calendar) midnight(t time.Time) time.Time {
+	t = t.In(c.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func nextDay(midnight time.Time) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day()+1, 0, 0, 0, 0, midnight.Location())
+}
+
+// clock returns the time minutes after midnight on the date of midnight
+func clock(midnight time.Time, minutes int) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), minutes/60, minutes%60, 0, 0, midnight.Location())
+}
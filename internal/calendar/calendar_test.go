@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time in Tehran, for cases against the default calendar
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, tehran)
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		from time.Time
+		d    time.Duration
+		want time.Time
+	}{
+		{"within the day", at(2026, 10, 24, 9, 0), 2 * time.Hour, at(2026, 10, 24, 11, 0)},
+		{"before opening", at(2026, 10, 24, 6, 0), time.Hour, at(2026, 10, 24, 9, 0)},
+		{"after closing", at(2026, 10, 24, 17, 0), time.Hour, at(2026, 10, 25, 9, 0)},
+		{"up to closing", at(2026, 10, 24, 15, 0), time.Hour, at(2026, 10, 24, 16, 0)},
+		{"into the Thursday morning", at(2026, 10, 21, 15, 0), 3 * time.Hour, at(2026, 10, 22, 10, 0)},
+		{"over the Thursday 12:00 close", at(2026, 10, 22, 11, 0), 2 * time.Hour, at(2026, 10, 24, 9, 0)},
+		{"from Thursday afternoon", at(2026, 10, 22, 13, 0), time.Hour, at(2026, 10, 24, 9, 0)},
+		{"from Friday", at(2026, 10, 23, 10, 0), 30 * time.Minute, at(2026, 10, 24, 8, 30)},
+		{"over a holiday", at(2026, 2, 10, 15, 0), 2 * time.Hour, at(2026, 2, 12, 9, 0)},
+		{"over a holiday on Thursday", at(2026, 6, 3, 15, 30), time.Hour, at(2026, 6, 6, 8, 30)},
+		{"over Nowruz", at(2026, 3, 19, 11, 0), 2 * time.Hour, at(2026, 3, 25, 9, 0)},
+		{"a working week", at(2026, 10, 24, 8, 0), 44 * time.Hour, at(2026, 10, 29, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Default.Add(tt.from, tt.d)
+			if !got.Equal(tt.want) {
+				t.Fatalf("Add(%s, %s) = %s, want %s", tt.from, tt.d, got, tt.want)
+			}
+			if between := Default.Between(tt.from, got); between != tt.d {
+				t.Errorf("Between(%s, %s) = %s, want %s", tt.from, got, between, tt.d)
+			}
+		})
+	}
+}
+
+func TestAddWithoutHours(t *testing.T) {
+	from := time.Date(2026, 10, 23, 10, 0, 0, 0, time.UTC)
+	closed := &Calendar{}
+	if got := closed.Add(from, 3*time.Hour); !got.Equal(from.Add(3 * time.Hour)) {
+		t.Errorf("Add() = %s, want wall-clock time", got)
+	}
+	if got := Default.Add(from, 0); !got.Equal(from) {
+		t.Errorf("Add() of nothing = %s, want %s", got, from)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"within the day", at(2026, 10, 24, 9, 0), at(2026, 10, 24, 11, 30), 150 * time.Minute},
+		{"to before from", at(2026, 10, 24, 11, 0), at(2026, 10, 24, 9, 0), 0},
+		{"outside working hours", at(2026, 10, 24, 17, 0), at(2026, 10, 24, 23, 0), 0},
+		{"over the Thursday 12:00 close", at(2026, 10, 21, 15, 0), at(2026, 10, 24, 9, 0), 6 * time.Hour},
+		{"the weekend", at(2026, 10, 22, 12, 0), at(2026, 10, 24, 8, 0), 0},
+		{"over a holiday", at(2026, 2, 10, 15, 0), at(2026, 2, 12, 9, 0), 2 * time.Hour},
+		{"over Nowruz", at(2026, 3, 19, 8, 0), at(2026, 3, 26, 8, 0), 12 * time.Hour},
+		{"a working week", at(2026, 10, 24, 0, 0), at(2026, 10, 31, 0, 0), 44 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Default.Between(tt.from, tt.to); got != tt.want {
+				t.Errorf("Between(%s, %s) = %s, want %s", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"Saturday morning", at(2026, 10, 24, 8, 0), true},
+		{"Saturday closing", at(2026, 10, 24, 16, 0), false},
+		{"Thursday morning", at(2026, 10, 22, 11, 59), true},
+		{"Thursday noon", at(2026, 10, 22, 12, 0), false},
+		{"Friday", at(2026, 10, 23, 10, 0), false},
+		{"holiday", at(2026, 2, 11, 10, 0), false},
+		{"in UTC", time.Date(2026, 10, 24, 5, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Default.Open(tt.t); got != tt.want {
+				t.Errorf("Open(%s) = %t, want %t", tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// Calendar systems holidays are dated in
+const (
+	Gregorian = "gregorian"
+	Jalali    = "jalali"
+)
+
+// Holiday is a day off. Without a year it recurs every year on the same date
+// of its calendar system, such as Nowruz on 1 Farvardin.
+type Holiday struct {
+	Name   string
+	System string // Gregorian or Jalali
+	Year   int    // 0 for every year
+	Month  int
+	Day    int
+}
+
+// IranianHolidays are the public holidays of Iran that fall on a fixed Jalali
+// date. Holidays of the lunar Hijri calendar, such as Tasua and Ashura, move
+// every year and are added with their date.
+var IranianHolidays = []Holiday{
+	{Name: "Nowruz", System: Jalali, Month: 1, Day: 1},
+	{Name: "Nowruz", System: Jalali, Month: 1, Day: 2},
+	{Name: "Nowruz", System: Jalali, Month: 1, Day: 3},
+	{Name: "Nowruz", System: Jalali, Month: 1, Day: 4},
+	{Name: "Islamic Republic Day", System: Jalali, Month: 1, Day: 12},
+	{Name: "Nature Day", System: Jalali, Month: 1, Day: 13},
+	{Name: "Death of Imam Khomeini", System: Jalali, Month: 3, Day: 14},
+	{Name: "15 Khordad Uprising", System: Jalali, Month: 3, Day: 15},
+	{Name: "Revolution Day", System: Jalali, Month: 11, Day: 22},
+	{Name: "Oil Nationalization Day", System: Jalali, Month: 12, Day: 29},
+}
+
+// Validate checks that the holiday is a date of its calendar system
+func (h Holiday) Validate() error {
+	if h.Month < 1 || h.Month > 12 {
+		return fmt.Errorf("invalid month of holiday %s: %d", h.Name, h.Month)
+	}
+
+	days := 0
+	switch h.System {
+	case Gregorian:
+		year := h.Year
+		if year == 0 {
+			year = 2000 // a leap year, so 29 February recurs in leap years
+		}
+		days = time.Date(year, time.Month(h.Month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	case Jalali:
+		if h.Year == 0 {
+			days = JalaliMonthDays(1403, h.Month) // a leap year, so 30 Esfand recurs in leap years
+		} else {
+			days = JalaliMonthDays(h.Year, h.Month)
+		}
+	default:
+		return fmt.Errorf("unknown calendar system of holiday %s: %q", h.Name, h.System)
+	}
+
+	if h.Day < 1 || h.Day > days {
+		return fmt.Errorf("invalid day of holiday %s: %d", h.Name, h.Day)
+	}
+	return nil
+}
+
+// On reports whether the holiday falls on the date of t in its location
+func (h Holiday) On(t time.Time) bool {
+	year, month, day := t.Year(), int(t.Month()), t.Day()
+	if h.System == Jalali {
+		year, month, day = ToJalali(t)
+	}
+	return month == h.Month && day == h.Day && (h.Year == 0 || year == h.Year)
+}
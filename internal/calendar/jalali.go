@@ -0,0 +1,120 @@
+package calendar
+
+import "time"
+
+// Conversion between the Gregorian and the Jalali (Solar Hijri) calendar, after
+// the jalaali-js algorithm: Jalali leap years follow the 33-year cycles between
+// the break years below, which match the astronomical calendar for Jalali years
+// -61 to 3177.
+
+var jalaliBreaks = [...]int{-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178}
+
+// ToJalali returns the Jalali date of the date of t in its location
+func ToJalali(t time.Time) (year, month, day int) {
+	return dayToJalali(gregorianToDay(t.Year(), int(t.Month()), t.Day()))
+}
+
+// FromJalali returns midnight of a Jalali date in loc
+func FromJalali(year, month, day int, loc *time.Location) time.Time {
+	gy, gm, gd := dayToGregorian(jalaliToDay(year, month, day))
+	return time.Date(gy, time.Month(gm), gd, 0, 0, 0, 0, loc)
+}
+
+// JalaliMonthDays returns the number of days of a Jalali month
+func JalaliMonthDays(year, month int) int {
+	switch {
+	case month <= 6:
+		return 31
+	case month <= 11:
+		return 30
+	case jalaliLeap(year):
+		return 30
+	default:
+		return 29
+	}
+}
+
+func jalaliLeap(year int) bool {
+	leap, _, _ := jalaliYear(year)
+	return leap == 0
+}
+
+// jalaliYear returns where a Jalali year is in its leap cycle (0 for a leap
+// year), the Gregorian year it starts in and the day of March it starts on
+func jalaliYear(jy int) (leap, gy, march int) {
+	gy = jy + 621
+	leapJ := -14
+	jp := jalaliBreaks[0]
+	jump := 0
+	for i := 1; i < len(jalaliBreaks); i++ {
+		jm := jalaliBreaks[i]
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+		leapJ += jump/33*8 + jump%33/4
+		jp = jm
+	}
+	n := jy - jp
+
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march = 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	leap = ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+	return leap, gy, march
+}
+
+// jalaliToDay returns the Julian day number of a Jalali date
+func jalaliToDay(jy, jm, jd int) int {
+	_, gy, march := jalaliYear(jy)
+	return gregorianToDay(gy, 3, march) + (jm-1)*31 - jm/7*(jm-7) + jd - 1
+}
+
+// dayToJalali returns the Jalali date of a Julian day number
+func dayToJalali(jdn int) (jy, jm, jd int) {
+	gy, _, _ := dayToGregorian(jdn)
+	jy = gy - 621
+	leap, _, march := jalaliYear(jy)
+	k := jdn - gregorianToDay(gy, 3, march)
+	if k >= 0 {
+		if k <= 185 {
+			return jy, 1 + k/31, k%31 + 1
+		}
+		k -= 186
+	} else {
+		jy--
+		k += 179
+		if leap == 1 {
+			k++
+		}
+	}
+	return jy, 7 + k/30, k%30 + 1
+}
+
+// gregorianToDay returns the Julian day number of a Gregorian date
+func gregorianToDay(gy, gm, gd int) int {
+	d := (gy+(gm-8)/6+100100)*1461/4 + (153*((gm+9)%12)+2)/5 + gd - 34840408
+	return d - (gy+100100+(gm-8)/6)/100*3/4 + 752
+}
+
+// dayToGregorian returns the Gregorian date of a Julian day number
+func dayToGregorian(jdn int) (gy, gm, gd int) {
+	j := 4*jdn + 139361631
+	j += (4*jdn+183187720)/146097*3/4*4 - 3908
+	i := j%1461/4*5 + 308
+	gd = i%153/5 + 1
+	gm = i/153%12 + 1
+	gy = j/1461 - 100100 + (8-gm)/6
+	return gy, gm, gd
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"callcenter/internal/ticketnumber"
 )
 
 // Config holds all configuration for the application
@@ -26,9 +28,22 @@ type Config struct {
 	JWTSecret     string
 	JWTExpiration time.Duration
 
+	// Language used when a request does not ask for a supported one
+	DefaultLanguage string
+
+	// Base URL of the help center pages knowledge base articles link to
+	HelpCenterURL string
+
+	// How new support tickets are numbered
+	TicketNumberFormat ticketnumber.Format
+
 	// External API configuration
 	NLPAPIKey            string
 	NLPAPIURL            string
+	NLPReviewThreshold   float64
+	NLPFuzzyEditRatio    float64
+	NLPFuzzyMinLength    int
+	NLPFuzzyPhonetic     bool
 	MyTicketAPIKey       string
 	MyTicketAPIURL       string
 	FaranegarAPIKey      string
@@ -36,6 +51,18 @@ type Config struct {
 	PaymentGatewayAPIKey string
 	PaymentGatewayAPIURL string
 
+	// Refund payouts: the secret gateway callbacks are signed with, the URL the
+	// gateway posts them to, and how often pending payouts are polled
+	PaymentWebhookSecret string
+	PaymentCallbackURL   string
+	PaymentPollInterval  time.Duration
+
+	// How often the SLA targets of open tickets are checked for breaches
+	SLACheckInterval time.Duration
+
+	// How often queued bulk ticket jobs are looked for
+	BulkJobPollInterval time.Duration
+
 	// SMS/Email configuration
 	SMSAPIKey   string
 	SMSAPIURL   string
@@ -50,6 +77,11 @@ type Config struct {
 	// Logging configuration
 	LogLevel string
 	LogFile  string
+
+	// OpenAI configuration
+	OpenAIAPIKey string
+	OpenAIAPIURL string
+	OpenAIModel  string
 }
 
 // LoadConfig loads configuration from environment variables
@@ -76,15 +108,46 @@ func LoadConfig() (*Config, error) {
 	jwtExpiration, _ := strconv.Atoi(getEnvOrDefault("JWT_EXPIRATION", "24"))
 	config.JWTExpiration = time.Duration(jwtExpiration) * time.Hour
 
+	config.DefaultLanguage = getEnvOrDefault("DEFAULT_LANGUAGE", "en")
+	config.HelpCenterURL = getEnvOrDefault("HELP_CENTER_URL", "http://localhost:3000/help")
+
+	// Ticket number configuration
+	config.TicketNumberFormat = ticketnumber.DefaultFormat
+	config.TicketNumberFormat.Prefix = getEnvOrDefault("TICKET_NUMBER_PREFIX", ticketnumber.DefaultFormat.Prefix)
+	config.TicketNumberFormat.Year, _ = strconv.ParseBool(getEnvOrDefault("TICKET_NUMBER_YEAR", "true"))
+	config.TicketNumberFormat.Digits, _ = strconv.Atoi(getEnvOrDefault("TICKET_NUMBER_DIGITS", "6"))
+	config.TicketNumberFormat.CheckDigit, _ = strconv.ParseBool(getEnvOrDefault("TICKET_NUMBER_CHECK_DIGIT", "true"))
+
 	// External API configuration
 	config.NLPAPIKey = getEnvOrDefault("NLP_API_KEY", "")
 	config.NLPAPIURL = getEnvOrDefault("NLP_API_URL", "")
+	config.NLPReviewThreshold, _ = strconv.ParseFloat(getEnvOrDefault("NLP_REVIEW_THRESHOLD", "0.7"), 64)
+	config.NLPFuzzyEditRatio, _ = strconv.ParseFloat(getEnvOrDefault("NLP_FUZZY_EDIT_RATIO", "0.2"), 64)
+	config.NLPFuzzyMinLength, _ = strconv.Atoi(getEnvOrDefault("NLP_FUZZY_MIN_LENGTH", "5"))
+	config.NLPFuzzyPhonetic, _ = strconv.ParseBool(getEnvOrDefault("NLP_FUZZY_PHONETIC", "true"))
 	config.MyTicketAPIKey = getEnvOrDefault("MYTICKET_API_KEY", "")
 	config.MyTicketAPIURL = getEnvOrDefault("MYTICKET_API_URL", "")
 	config.FaranegarAPIKey = getEnvOrDefault("FARANEGAR_API_KEY", "")
 	config.FaranegarAPIURL = getEnvOrDefault("FARANEGAR_API_URL", "")
 	config.PaymentGatewayAPIKey = getEnvOrDefault("PAYMENT_GATEWAY_API_KEY", "")
 	config.PaymentGatewayAPIURL = getEnvOrDefault("PAYMENT_GATEWAY_API_URL", "")
+	config.PaymentWebhookSecret = getEnvOrDefault("PAYMENT_WEBHOOK_SECRET", "")
+	config.PaymentCallbackURL = getEnvOrDefault("PAYMENT_CALLBACK_URL", "")
+	paymentPollInterval, _ := strconv.Atoi(getEnvOrDefault("PAYMENT_POLL_INTERVAL", "60"))
+	if paymentPollInterval <= 0 {
+		paymentPollInterval = 60
+	}
+	config.PaymentPollInterval = time.Duration(paymentPollInterval) * time.Second
+	slaCheckInterval, _ := strconv.Atoi(getEnvOrDefault("SLA_CHECK_INTERVAL", "60"))
+	if slaCheckInterval <= 0 {
+		slaCheckInterval = 60
+	}
+	config.SLACheckInterval = time.Duration(slaCheckInterval) * time.Second
+	bulkJobPollInterval, _ := strconv.Atoi(getEnvOrDefault("BULK_JOB_POLL_INTERVAL", "5"))
+	if bulkJobPollInterval <= 0 {
+		bulkJobPollInterval = 5
+	}
+	config.BulkJobPollInterval = time.Duration(bulkJobPollInterval) * time.Second
 
 	// SMS/Email configuration
 	config.SMSAPIKey = getEnvOrDefault("SMS_API_KEY", "")
@@ -101,6 +164,11 @@ func LoadConfig() (*Config, error) {
 	config.LogLevel = getEnvOrDefault("LOG_LEVEL", "info")
 	config.LogFile = getEnvOrDefault("LOG_FILE", "app.log")
 
+	// OpenAI configuration
+	config.OpenAIAPIKey = getEnvOrDefault("OPENAI_API_KEY", "")
+	config.OpenAIAPIURL = getEnvOrDefault("OPENAI_API_URL", "https://api.openai.com/v1")
+	config.OpenAIModel = getEnvOrDefault("OPENAI_MODEL", "gpt-4o-mini")
+
 	// Validate required configuration
 	if err := config.validate(); err != nil {
 		return nil, err
@@ -122,6 +190,10 @@ func (c *Config) validate() error {
 		}
 	}
 
+	if err := c.TicketNumberFormat.Validate(); err != nil {
+		return fmt.Errorf("invalid ticket number configuration: %w", err)
+	}
+
 	return nil
 }
 
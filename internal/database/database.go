@@ -10,6 +10,216 @@ import (
 	"callcenter/internal/models"
 )
 
+// schemaStatements are idempotent DDL statements run after AutoMigrate
+var schemaStatements = []string{
+	// English stems the article text; simple keeps every word as is, which also
+	// covers Persian, for which Postgres has no dictionary
+	`ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('english', search_text) || to_tsvector('simple', search_text)) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles USING GIN (search_vector)`,
+	// Flight and refund data moved to bookings in bookingMigration
+	`ALTER TABLE tickets
+		DROP COLUMN IF EXISTS ticket_type,
+		DROP COLUMN IF EXISTS price,
+		DROP COLUMN IF EXISTS currency,
+		DROP COLUMN IF EXISTS refund_status,
+		DROP COLUMN IF EXISTS refund_amount,
+		DROP COLUMN IF EXISTS refund_processed`,
+	`ALTER TABLE refund_requests DROP COLUMN IF EXISTS ticket_number`,
+	// Refund requests stored a zero time before processed_at was nullable
+	`UPDATE refund_requests SET processed_at = NULL WHERE processed_at < '0002-01-01'`,
+	// Bookings cancelled before partial cancellations existed
+	`UPDATE passengers SET status = 'cancelled', cancelled_at = bookings.cancelled_at
+		FROM bookings WHERE bookings.id = passengers.booking_id AND bookings.status = 'cancelled' AND passengers.status = 'active'`,
+	`UPDATE segments SET status = 'cancelled', cancelled_at = bookings.cancelled_at
+		FROM bookings WHERE bookings.id = segments.booking_id AND bookings.status = 'cancelled' AND segments.status = 'active'`,
+	// Tiers of refund policies deleted before DeletePolicy removed them too
+	`DELETE FROM refund_penalty_tiers
+		USING refund_policies WHERE refund_policies.id = refund_penalty_tiers.policy_id AND refund_policies.deleted_at IS NOT NULL`,
+	// Concurrent tickets never share a number
+	`CREATE SEQUENCE IF NOT EXISTS ticket_number_seq`,
+	// Ticket search: free text, keyset cursors on (sort column, id) and
+	// case-insensitive emails
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', number || ' ' || subject || ' ' || description)) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_tickets_search_vector ON tickets USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_tickets_created_at_id ON tickets (created_at, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_tickets_updated_at_id ON tickets (updated_at, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_tickets_user_created_at ON tickets (user_id, created_at, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_bookings_contact_email ON bookings (lower(contact_email))`,
+	`CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email))`,
+	// Default queues and their routes, created once; later changes are left alone
+	`DO $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM queues) THEN
+			RETURN;
+		END IF;
+		INSERT INTO queues (id, name, description, strategy, auto_assign, created_at, updated_at) VALUES
+			(gen_random_uuid(), 'refunds', 'Cancellations and refunds', 'round_robin', true, now(), now()),
+			(gen_random_uuid(), 'changes', 'Date, name and itinerary changes', 'round_robin', true, now(), now()),
+			(gen_random_uuid(), 'complaints', 'Complaints and baggage claims', 'least_loaded', true, now(), now());
+		INSERT INTO queue_routes (ticket_type, queue_id)
+			SELECT route.ticket_type, queues.id FROM queues JOIN (VALUES
+				('cancellation', 'refunds'), ('refund', 'refunds'), ('change', 'changes'),
+				('complaint', 'complaints'), ('baggage', 'complaints')) AS route (ticket_type, queue)
+			ON queues.name = route.queue;
+	END $$`,
+	// Default SLA policies: a first response within 1, 4 and 8 business hours
+	// and a resolution within 1, 3 and 5 business days
+	`INSERT INTO sla_policies (id, name, priority, first_response_minutes, resolution_minutes, warning_percent, created_at, updated_at)
+		SELECT gen_random_uuid(), policy.name, policy.priority, policy.first_response, policy.resolution, 80, now(), now()
+		FROM (VALUES ('High priority', 'high', 60, 480), ('Medium priority', 'medium', 240, 1440), ('Low priority', 'low', 480, 2400))
+			AS policy (name, priority, first_response, resolution)
+		WHERE NOT EXISTS (SELECT 1 FROM sla_policies)`,
+	// Default calendar: Saturday to Wednesday, Thursday morning and the
+	// holidays of a fixed Jalali date
+	`DO $$
+	DECLARE
+		tehran uuid := gen_random_uuid();
+	BEGIN
+		IF EXISTS (SELECT 1 FROM business_calendars) THEN
+			RETURN;
+		END IF;
+		INSERT INTO business_calendars (id, name, time_zone, is_default, created_at, updated_at)
+			VALUES (tehran, 'tehran', 'Asia/Tehran', true, now(), now());
+		INSERT INTO working_hours (id, calendar_id, weekday, opens, closes)
+			SELECT gen_random_uuid(), tehran, hours.weekday, hours.opens, hours.closes FROM (VALUES
+				(6, '08:00', '16:00'), (0, '08:00', '16:00'), (1, '08:00', '16:00'),
+				(2, '08:00', '16:00'), (3, '08:00', '16:00'), (4, '08:00', '12:00')) AS hours (weekday, opens, closes);
+		INSERT INTO calendar_holidays (id, calendar_id, name, system, year, month, day)
+			SELECT gen_random_uuid(), tehran, holiday.name, 'jalali', 0, holiday.month, holiday.day FROM (VALUES
+				('Nowruz', 1, 1), ('Nowruz', 1, 2), ('Nowruz', 1, 3), ('Nowruz', 1, 4),
+				('Islamic Republic Day', 1, 12), ('Nature Day', 1, 13),
+				('Death of Imam Khomeini', 3, 14), ('15 Khordad Uprising', 3, 15),
+				('Revolution Day', 11, 22), ('Oil Nationalization Day', 12, 29)) AS holiday (name, month, day);
+	END $$`,
+	// Timelines of tickets opened before they were recorded: opened at creation
+	// and, if they moved on, changed to their status at their last update
+	`INSERT INTO ticket_statuses (id, ticket_id, from_status, status, reason, created_at, updated_at)
+		SELECT gen_random_uuid(), tickets.id, change.from_status, change.status, change.reason, change.at, change.at
+		FROM tickets CROSS JOIN LATERAL (VALUES
+			('', 'open', 'created', tickets.created_at),
+			('open', tickets.status, 'other', tickets.updated_at)) AS change (from_status, status, reason, at)
+		WHERE NOT EXISTS (SELECT 1 FROM ticket_statuses WHERE ticket_statuses.ticket_id = tickets.id)
+			AND (change.from_status = '' OR tickets.status <> 'open')`,
+}
+
+// bookingMigration moves the flight and refund data that used to live on
+// tickets to bookings before the old columns are dropped. Each ticket becomes a
+// booking with the same ID, without a PNR or airline since tickets had none,
+// and refund requests are linked to it through their ticket number. Fares are
+// left floating point for moneyMigration to convert. It only runs while
+// tickets.price still exists.
+const bookingMigration = `DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'tickets' AND column_name = 'price') THEN
+		RETURN;
+	END IF;
+
+	CREATE TABLE IF NOT EXISTS bookings (
+		id uuid PRIMARY KEY,
+		user_id uuid NOT NULL,
+		pnr varchar(6) NOT NULL,
+		airline varchar(2) NOT NULL,
+		ticket_type text NOT NULL,
+		status text NOT NULL DEFAULT 'active',
+		base_fare double precision NOT NULL,
+		taxes double precision NOT NULL,
+		total_fare double precision NOT NULL,
+		currency varchar(3) NOT NULL,
+		refund_status text,
+		refund_amount double precision,
+		refund_processed_at timestamptz,
+		cancelled_at timestamptz,
+		created_at timestamptz,
+		updated_at timestamptz,
+		deleted_at timestamptz
+	);
+	INSERT INTO bookings (id, user_id, pnr, airline, ticket_type, status, base_fare, taxes, total_fare, currency,
+		refund_status, refund_amount, refund_processed_at, cancelled_at, created_at, updated_at)
+		SELECT id, user_id, '', '', ticket_type,
+			CASE WHEN status = 'cancelled' THEN 'cancelled' ELSE 'active' END,
+			price, 0, price, currency, refund_status, refund_amount,
+			CASE WHEN refund_processed >= '0002-01-01' THEN refund_processed END,
+			CASE WHEN status = 'cancelled' THEN updated_at END,
+			created_at, updated_at
+		FROM tickets
+		ON CONFLICT (id) DO NOTHING;
+
+	ALTER TABLE tickets ADD COLUMN IF NOT EXISTS booking_id uuid;
+	UPDATE tickets SET booking_id = id WHERE booking_id IS NULL;
+	-- Cancelling is done to bookings now; the support case is closed
+	UPDATE tickets SET status = 'closed' WHERE status = 'cancelled';
+
+	IF EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'refund_requests' AND column_name = 'ticket_number') THEN
+		ALTER TABLE refund_requests ADD COLUMN IF NOT EXISTS booking_id uuid;
+		UPDATE refund_requests SET booking_id = tickets.id
+			FROM tickets WHERE tickets.number = refund_requests.ticket_number AND refund_requests.booking_id IS NULL;
+		IF EXISTS (SELECT 1 FROM refund_requests WHERE booking_id IS NULL) THEN
+			RAISE EXCEPTION 'refund requests without a ticket cannot be moved to bookings';
+		END IF;
+	END IF;
+END $$`
+
+// moneyMigration converts the fare and refund columns from floating point major
+// units to integer minor units before AutoMigrate changes their type, which it
+// would do without scaling them. Amounts in tomans (IRT) become rials first.
+// Rial amounts are whole; other currencies are scaled to cents. Refund policies
+// have no currency and their fees were entered in rials. It only runs while
+// bookings.base_fare is still floating point.
+const moneyMigration = `DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'bookings' AND column_name = 'base_fare' AND data_type = 'double precision') THEN
+		RETURN;
+	END IF;
+
+	IF to_regclass('passengers') IS NOT NULL THEN
+		UPDATE passengers SET base_fare = passengers.base_fare * 10, taxes = passengers.taxes * 10
+			FROM bookings WHERE bookings.id = passengers.booking_id AND bookings.currency = 'IRT';
+		UPDATE passengers SET base_fare = passengers.base_fare * 100, taxes = passengers.taxes * 100
+			FROM bookings WHERE bookings.id = passengers.booking_id AND bookings.currency NOT IN ('IRR', 'IRT');
+		ALTER TABLE passengers
+			ALTER COLUMN base_fare TYPE bigint USING round(base_fare),
+			ALTER COLUMN taxes TYPE bigint USING round(taxes);
+	END IF;
+	UPDATE refund_requests SET amount = amount * 10, currency = 'IRR' WHERE currency = 'IRT';
+	UPDATE bookings SET base_fare = base_fare * 10, taxes = taxes * 10, total_fare = total_fare * 10,
+		refund_amount = refund_amount * 10, currency = 'IRR' WHERE currency = 'IRT';
+
+	UPDATE refund_requests SET amount = amount * 100 WHERE currency <> 'IRR';
+	UPDATE bookings SET base_fare = base_fare * 100, taxes = taxes * 100, total_fare = total_fare * 100,
+		refund_amount = refund_amount * 100 WHERE currency <> 'IRR';
+
+	ALTER TABLE bookings
+		ALTER COLUMN base_fare TYPE bigint USING round(base_fare),
+		ALTER COLUMN taxes TYPE bigint USING round(taxes),
+		ALTER COLUMN total_fare TYPE bigint USING round(total_fare),
+		ALTER COLUMN refund_amount TYPE bigint USING round(refund_amount);
+	ALTER TABLE refund_requests ALTER COLUMN amount TYPE bigint USING round(amount);
+
+	IF to_regclass('refund_quotes') IS NOT NULL THEN
+		UPDATE refund_quotes SET base_fare = base_fare * 10, taxes = taxes * 10, penalty = penalty * 10,
+			fee = fee * 10, taxes_refunded = taxes_refunded * 10, amount = amount * 10, currency = 'IRR'
+			WHERE currency = 'IRT';
+		UPDATE refund_quotes SET base_fare = base_fare * 100, taxes = taxes * 100, penalty = penalty * 100,
+			fee = fee * 100, taxes_refunded = taxes_refunded * 100, amount = amount * 100
+			WHERE currency <> 'IRR';
+		ALTER TABLE refund_quotes
+			ALTER COLUMN base_fare TYPE bigint USING round(base_fare),
+			ALTER COLUMN taxes TYPE bigint USING round(taxes),
+			ALTER COLUMN penalty TYPE bigint USING round(penalty),
+			ALTER COLUMN fee TYPE bigint USING round(fee),
+			ALTER COLUMN taxes_refunded TYPE bigint USING round(taxes_refunded),
+			ALTER COLUMN amount TYPE bigint USING round(amount);
+	END IF;
+	IF to_regclass('refund_policies') IS NOT NULL THEN
+		ALTER TABLE refund_policies ALTER COLUMN fixed_fee TYPE bigint USING round(fixed_fee);
+	END IF;
+END $$`
+
 func InitDB() (*gorm.DB, error) {
 	// Get database configuration from environment variables
 	dbHost := os.Getenv("DB_HOST")
@@ -29,18 +239,66 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	if err := Migrate(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate brings the schema of a database up to date
+func Migrate(db *gorm.DB) error {
+	if err := db.Exec(bookingMigration).Error; err != nil {
+		return fmt.Errorf("failed to move ticket fares to bookings: %v", err)
+	}
+	if err := db.Exec(moneyMigration).Error; err != nil {
+		return fmt.Errorf("failed to migrate money columns: %v", err)
+	}
+
 	// Auto-migrate database schema
-	err = db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.User{},
+		&models.Booking{},
+		&models.Passenger{},
+		&models.Segment{},
 		&models.ChatSession{},
 		&models.ChatMessage{},
 		&models.Ticket{},
 		&models.TicketStatus{},
 		&models.TicketHistory{},
+		&models.TicketTag{},
+		&models.TicketComment{},
+		&models.CommentMention{},
+		&models.CommentEdit{},
+		&models.CommentAttachment{},
+		&models.BulkJob{},
+		&models.BulkJobItem{},
+		&models.Queue{},
+		&models.QueueMember{},
+		&models.QueueRoute{},
+		&models.AgentSkill{},
+		&models.BusinessCalendar{},
+		&models.WorkingHours{},
+		&models.CalendarHoliday{},
+		&models.SLAPolicy{},
+		&models.TicketSLA{},
+		&models.RefundRequest{},
+		&models.RefundStatusChange{},
+		&models.RefundQuote{},
+		&models.RefundLineItem{},
+		&models.RefundPolicy{},
+		&models.RefundPenaltyTier{},
+		&models.LabelingTask{},
+		&models.Article{},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %v", err)
+		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
-	return db, nil
+	// Create what AutoMigrate cannot express
+	for _, statement := range schemaStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate database: %v", err)
+		}
+	}
+	return nil
 }
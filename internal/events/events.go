@@ -0,0 +1,96 @@
+// Package events publishes domain events, such as a booking being cancelled, to
+// in-process subscribers once the change that caused them is committed.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	BookingCancelled = "booking.cancelled"
+	TicketSLAWarning = "ticket.sla_warning"  // an SLA target of a ticket is about to be missed
+	TicketSLABreach  = "ticket.sla_breached" // an SLA target of a ticket was missed
+	TicketEscalated  = "ticket.escalated"    // supervisors are alerted to a ticket that missed a target
+	TicketCommented  = "ticket.commented"    // a reply or an internal note was added to a ticket
+	TicketMentioned  = "ticket.mentioned"    // agents were mentioned in a comment on a ticket
+)
+
+// Event is something that happened to a record
+type Event struct {
+	ID          uuid.UUID
+	Type        string
+	AggregateID uuid.UUID // the record the event is about
+	Data        map[string]interface{}
+	OccurredAt  time.Time
+}
+
+// New creates an event of the given type about a record
+func New(eventType string, aggregateID uuid.UUID, data map[string]interface{}) Event {
+	return Event{
+		ID:          uuid.New(),
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Data:        data,
+		OccurredAt:  time.Now(),
+	}
+}
+
+// Handler reacts to an event. Handlers run synchronously in the order they
+// subscribed, so slow work should be handed off.
+type Handler func(ctx context.Context, event Event)
+
+// Bus delivers events to the handlers subscribed to their type
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event type
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish delivers an event to its subscribers. A panicking handler is logged
+// and does not stop the others.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("event handler for %s panicked: %v", event.Type, r)
+				}
+			}()
+			handler(ctx, event)
+		}()
+	}
+}
+
+// Default is the bus services publish to
+var Default = NewBus()
+
+// Subscribe registers a handler on the default bus
+func Subscribe(eventType string, handler Handler) {
+	Default.Subscribe(eventType, handler)
+}
+
+// Publish delivers an event on the default bus
+func Publish(ctx context.Context, event Event) {
+	Default.Publish(ctx, event)
+}
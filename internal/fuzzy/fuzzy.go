@@ -0,0 +1,237 @@
+// Package fuzzy provides typo-tolerant matching of keywords and known entity
+// values in English and Persian text, using edit distance and phonetic keys.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Options controls how tolerant matching is
+type Options struct {
+	// MaxEditRatio is the share of a term's length that may be edited, e.g. 0.2
+	// allows one typo per five characters. Zero disables edit-distance matching.
+	MaxEditRatio float64
+	// MinLength is the shortest term, in characters, matched fuzzily. Shorter
+	// terms must match exactly because one typo turns them into other words.
+	MinLength int
+	// Phonetic enables matching words that sound alike
+	Phonetic bool
+}
+
+// DefaultOptions allow one typo per five characters for terms of five or more characters
+var DefaultOptions = Options{
+	MaxEditRatio: 0.2,
+	MinLength:    5,
+	Phonetic:     true,
+}
+
+// Matcher matches dictionary phrases against text
+type Matcher struct {
+	opts Options
+}
+
+// NewMatcher creates a matcher with the given tolerance
+func NewMatcher(opts Options) *Matcher {
+	return &Matcher{opts: opts}
+}
+
+// Text is a message prepared for matching
+type Text struct {
+	normalized string
+	words      []word
+}
+
+type word struct {
+	text  string
+	runes []rune
+	key   string
+}
+
+// Entry maps one canonical value to the phrases that refer to it
+type Entry struct {
+	Value   string
+	Phrases []string
+}
+
+// Dictionary is a precompiled list of entries
+type Dictionary struct {
+	entries []compiledEntry
+}
+
+type compiledEntry struct {
+	value   string
+	phrases []phrase
+}
+
+type phrase struct {
+	normalized string
+	words      []word
+}
+
+// NewDictionary compiles the entries; their order decides match priority
+func NewDictionary(entries []Entry) *Dictionary {
+	d := &Dictionary{entries: make([]compiledEntry, 0, len(entries))}
+	for _, e := range entries {
+		ce := compiledEntry{value: e.Value}
+		for _, p := range e.Phrases {
+			normalized := Normalize(p)
+			ce.phrases = append(ce.phrases, phrase{normalized: normalized, words: splitWords(normalized)})
+		}
+		d.entries = append(d.entries, ce)
+	}
+	return d
+}
+
+// Prepare normalizes and tokenizes a message once so it can be matched against many phrases
+func Prepare(text string) *Text {
+	normalized := Normalize(text)
+	return &Text{normalized: normalized, words: splitWords(normalized)}
+}
+
+// Match returns the values of every dictionary entry with a phrase found in the text,
+// in dictionary order
+func (m *Matcher) Match(t *Text, d *Dictionary) []string {
+	var values []string
+	for _, e := range d.entries {
+		for _, p := range e.phrases {
+			if m.contains(t, p) {
+				values = append(values, e.value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// Find returns the value of the first dictionary entry found in the text
+func (m *Matcher) Find(t *Text, d *Dictionary) (string, bool) {
+	for _, e := range d.entries {
+		for _, p := range e.phrases {
+			if m.contains(t, p) {
+				return e.value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Contains reports whether the keyword occurs in the text, allowing typos
+func (m *Matcher) Contains(text, keyword string) bool {
+	normalized := Normalize(keyword)
+	return m.contains(Prepare(text), phrase{normalized: normalized, words: splitWords(normalized)})
+}
+
+func (m *Matcher) contains(t *Text, p phrase) bool {
+	if p.normalized == "" {
+		return false
+	}
+	if strings.Contains(t.normalized, p.normalized) {
+		return true
+	}
+	if len(p.words) == 0 || len(p.words) > len(t.words) {
+		return false
+	}
+
+	// Slide the phrase over the text word by word
+	for start := 0; start+len(p.words) <= len(t.words); start++ {
+		matched := true
+		for i, pw := range p.words {
+			if !m.wordsMatch(t.words[start+i], pw) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// wordsMatch compares a text word with a phrase word. Fuzzy matches must start
+// with the same letter, since typos rarely hit the first one, and a text word may
+// carry a short suffix (Persian possessives and plurals) beyond the phrase word.
+func (m *Matcher) wordsMatch(tw, pw word) bool {
+	if tw.text == pw.text {
+		return true
+	}
+	if len(pw.runes) < m.opts.MinLength || len(tw.runes) == 0 || tw.runes[0] != pw.runes[0] {
+		return false
+	}
+
+	maxEdits := int(float64(len(pw.runes)) * m.opts.MaxEditRatio)
+	if maxEdits > 0 {
+		if Distance(tw.runes, pw.runes, maxEdits) <= maxEdits {
+			return true
+		}
+		if len(tw.runes) > len(pw.runes) && len(tw.runes)-len(pw.runes) <= 2 &&
+			Distance(tw.runes[:len(pw.runes)], pw.runes, maxEdits) <= maxEdits {
+			return true
+		}
+	}
+
+	return m.opts.Phonetic && pw.key != "" && tw.key == pw.key
+}
+
+func splitWords(normalized string) []word {
+	fields := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := make([]word, 0, len(fields))
+	for _, f := range fields {
+		words = append(words, word{text: f, runes: []rune(f), key: PhoneticKey(f)})
+	}
+	return words
+}
+
+// Distance returns the optimal string alignment distance between a and b: the
+// number of insertions, deletions, substitutions and adjacent transpositions
+// needed to turn one into the other. Distances above limit are reported as
+// limit+1, which lets computation stop early.
+func Distance(a, b []rune, limit int) int {
+	if abs(len(a)-len(b)) > limit {
+		return limit + 1
+	}
+
+	// Three rolling rows are enough for transpositions
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	// A transposition can reach back two rows, so stop only when two consecutive rows exceed the limit
+	prevRowMin := 0
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = min(d, prev2[j-2]+1)
+			}
+			curr[j] = d
+			rowMin = min(rowMin, d)
+		}
+		if rowMin > limit && prevRowMin > limit {
+			return limit + 1
+		}
+		prevRowMin = rowMin
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return min(prev[len(b)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
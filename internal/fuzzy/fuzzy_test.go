@@ -0,0 +1,203 @@
+package fuzzy
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"", "", 2, 0},
+		{"refund", "refund", 2, 0},
+		{"refund", "refnd", 2, 1},
+		{"refund", "refunds", 2, 1},
+		{"refund", "refumd", 2, 1},
+		{"cancel", "cancle", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"کنسل", "کسنل", 2, 1},
+		{"refined", "refund", 2, 2},
+		{"kitten", "sitting", 2, 3},    // stops above the limit
+		{"abcdef", "ghijkl", 2, 3},     // no common letters
+		{"refund", "refundable", 2, 3}, // lengths differ by more than the limit
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := Distance([]rune(tt.a), []rune(tt.b), tt.limit); got != tt.want {
+				t.Errorf("Distance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+			}
+			if got := Distance([]rune(tt.b), []rune(tt.a), tt.limit); got != tt.want {
+				t.Errorf("Distance(%q, %q, %d) = %d, want %d", tt.b, tt.a, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"lowercases", "REFUND my Ticket", "refund my ticket"},
+		{"Arabic kaf", "كنسل", "کنسل"},
+		{"Arabic yeh", "بليط", "بلیط"},
+		{"alef maksura", "الغى", "الغی"},
+		{"teh marbuta", "مسترجعة", "مسترجعه"},
+		{"hamza on alef", "أنا", "انا"},
+		{"Persian digits", "۱۲۳۴", "1234"},
+		{"Arabic digits", "٤٥٦", "456"},
+		{"zero-width non-joiner", "می\u200cخواهم", "می خواهم"},
+		{"tatweel", "کنــسل", "کنسل"},
+		{"diacritics", "بَلیط", "بلیط"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhoneticKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"refund", "refand", true},
+		{"mashhad", "mashad", true},
+		{"reservation", "rezervation", true},
+		{"baggage", "bagage", true},
+		{"ساعت", "صاعط", true},
+		{"حذف", "هزف", true},
+		{"refined", "refund", false},
+		{"refuel", "refund", false},
+		{"cancel", "candle", false},
+		{"بلیط", "بلند", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, b := PhoneticKey(tt.a), PhoneticKey(tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("PhoneticKey(%q) = %q, PhoneticKey(%q) = %q, want same = %t", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+
+	for _, word := range []string{"", "2026", "привет"} {
+		if key := PhoneticKey(word); key != "" {
+			t.Errorf("PhoneticKey(%q) = %q, want no key", word, key)
+		}
+	}
+}
+
+func TestMatcherContains(t *testing.T) {
+	tests := []struct {
+		text, keyword string
+		want          bool
+	}{
+		{"I want a refnd", "refund", true},
+		{"please cancle my flight", "cancel", true},
+		{"Cancelation please", "cancellation", true},
+		{"how do I get my refand", "refund", true},
+		{"luggage allowance", "luggage", true},
+		{"lugage allowence", "luggage allowance", true},
+		{"كنسل كنيد", "کنسل", true},
+		{"کنسلش کنید", "کنسل", true},
+		{"بليطم را لغو کنید", "بلیط", true},
+		{"استردادش کی انجام میشه", "استرداد", true},
+		{"mashad flight", "mashhad", true},
+		{"a refined service", "refund", false},
+		{"I need to refuel", "refund", false},
+		{"fund my account", "refund", false},
+		{"a bug in the app", "bag", false},
+		{"cancel", "cancel my ticket", false},
+		{"anything", "", false},
+	}
+
+	m := NewMatcher(DefaultOptions)
+	for _, tt := range tests {
+		t.Run(tt.text+" "+tt.keyword, func(t *testing.T) {
+			if got := m.Contains(tt.text, tt.keyword); got != tt.want {
+				t.Errorf("Contains(%q, %q) = %t, want %t", tt.text, tt.keyword, got, tt.want)
+			}
+		})
+	}
+
+	exact := NewMatcher(Options{})
+	for _, tt := range []struct{ text, keyword string }{{"I want a refnd", "refund"}, {"please cancle my flight", "cancel"}} {
+		if exact.Contains(tt.text, tt.keyword) {
+			t.Errorf("exact matcher found %q in %q", tt.keyword, tt.text)
+		}
+	}
+}
+
+// benchDictionary is shaped like the airline catalog of the NLP service
+var benchDictionary = NewDictionary([]Entry{
+	{Value: "IR", Phrases: []string{"iran air", "iranair", "homa", "ایران ایر", "هما"}},
+	{Value: "B9", Phrases: []string{"iran airtour", "airtour", "ایران ایرتور", "ایرتور"}},
+	{Value: "W5", Phrases: []string{"mahan air", "mahan", "ماهان"}},
+	{Value: "EP", Phrases: []string{"aseman", "iran aseman", "آسمان"}},
+	{Value: "QB", Phrases: []string{"qeshm air", "qeshm airlines", "قشم ایر"}},
+	{Value: "Y9", Phrases: []string{"kish air", "کیش ایر"}},
+	{Value: "RV", Phrases: []string{"caspian", "کاسپین"}},
+	{Value: "ZV", Phrases: []string{"zagros", "زاگرس"}},
+	{Value: "VR", Phrases: []string{"varesh", "وارش"}},
+	{Value: "HH", Phrases: []string{"taban", "تابان"}},
+	{Value: "IS", Phrases: []string{"sepehran", "سپهران"}},
+	{Value: "NV", Phrases: []string{"karun", "karoon", "کارون"}},
+	{Value: "TK", Phrases: []string{"turkish airlines", "ترکیش"}},
+	{Value: "EK", Phrases: []string{"emirates", "امارات"}},
+})
+
+var benchMessages = []string{
+	"I want to cancel my Isfahn flight on Caspain",
+	"what is the luggage allowance on Iran Air",
+	"baggage allowance on mahaan air to Mashhad",
+	"بار مجاز پرواز ماهان به استانبول",
+	"استرداد بلیط قشم ایر",
+	"Where is my ticket TKT-2026-0012348?",
+	"I am looking forward to your answer",
+}
+
+func benchmarkMatch(b *testing.B, opts Options) {
+	m := NewMatcher(opts)
+	texts := make([]*Text, len(benchMessages))
+	for i, msg := range benchMessages {
+		texts[i] = Prepare(msg)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(texts[i%len(texts)], benchDictionary)
+	}
+}
+
+func BenchmarkMatcherExact(b *testing.B) {
+	benchmarkMatch(b, Options{})
+}
+
+func BenchmarkMatcherEditDistance(b *testing.B) {
+	benchmarkMatch(b, Options{MaxEditRatio: DefaultOptions.MaxEditRatio, MinLength: DefaultOptions.MinLength})
+}
+
+func BenchmarkMatcherDefault(b *testing.B) {
+	benchmarkMatch(b, DefaultOptions)
+}
+
+func BenchmarkMatcherPrepare(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Prepare(benchMessages[i%len(benchMessages)])
+	}
+}
+
+func BenchmarkMatcherContains(b *testing.B) {
+	m := NewMatcher(DefaultOptions)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Contains(benchMessages[i%len(benchMessages)], "cancellation")
+	}
+}
@@ -0,0 +1,28 @@
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// persianReplacer unifies Arabic and Persian letter variants and digits
+var persianReplacer = strings.NewReplacer(
+	"ي", "ی", "ى", "ی", "ك", "ک", "ة", "ه", "ۀ", "ه",
+	"أ", "ا", "إ", "ا", "ٱ", "ا",
+	"\u200c", " ", // zero-width non-joiner separates word parts
+	"\u0640", "", // tatweel
+	"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4", "۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4", "٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
+
+// Normalize lowercases text, unifies Persian/Arabic letter variants and digits
+// and strips diacritics so that spelling variants compare equal
+func Normalize(text string) string {
+	text = persianReplacer.Replace(strings.ToLower(text))
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,101 @@
+package fuzzy
+
+import "strings"
+
+// latinCodes assigns Soundex digits to consonants; h and w are dropped and vowels
+// (a, e, i, o, u, y) are kept as 0
+var latinCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// persianClasses groups Persian letters that are pronounced alike, so common
+// misspellings (ت/ط, س/ص/ث, ز/ذ/ض/ظ, ه/ح, ق/غ) share a key
+var persianClasses = map[rune]rune{
+	'ت': 'ت', 'ط': 'ت',
+	'س': 'س', 'ص': 'س', 'ث': 'س',
+	'ز': 'ز', 'ذ': 'ز', 'ض': 'ز', 'ظ': 'ز',
+	'ه': 'ه', 'ح': 'ه',
+	'ق': 'ق', 'غ': 'ق',
+	'ا': 'ا', 'آ': 'ا', 'ع': 'ا', 'ء': 'ا', 'ئ': 'ا', 'ؤ': 'ا',
+}
+
+// persianVowels are letters that mostly spell vowels and are dropped after the first letter
+var persianVowels = map[rune]bool{'ا': true, 'و': true, 'ی': true}
+
+// PhoneticKey returns a key shared by words that sound alike. Latin words use
+// untruncated Soundex that keeps where vowels fall, so words whose consonants
+// only line up once vowels are dropped ("refined", "refund") get different keys;
+// Persian words collapse letters with the same sound and drop long vowels. Words
+// in other scripts have no key.
+func PhoneticKey(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return ""
+	}
+	switch {
+	case runes[0] >= 'a' && runes[0] <= 'z':
+		return latinKey(runes)
+	case runes[0] >= 0x0600 && runes[0] <= 0x06FF:
+		return persianKey(runes)
+	default:
+		return ""
+	}
+}
+
+func latinKey(runes []rune) string {
+	var b strings.Builder
+	b.WriteRune(runes[0])
+	last := latinCodes[runes[0]]
+	if isLatinVowel(runes[0]) {
+		last = '0'
+	}
+	for _, r := range runes[1:] {
+		code, ok := latinCodes[r]
+		if !ok {
+			// h and w do not separate equal codes; a run of vowels does and counts once
+			switch {
+			case r == 'h' || r == 'w':
+			case isLatinVowel(r):
+				if last != '0' {
+					b.WriteByte('0')
+				}
+				last = '0'
+			default:
+				last = 0
+			}
+			continue
+		}
+		if code != last {
+			b.WriteByte(code)
+		}
+		last = code
+	}
+	return b.String()
+}
+
+func isLatinVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
+
+func persianKey(runes []rune) string {
+	var b strings.Builder
+	var last rune
+	for i, r := range runes {
+		if class, ok := persianClasses[r]; ok {
+			r = class
+		}
+		if i > 0 && persianVowels[r] {
+			continue
+		}
+		if r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+	"callcenter/internal/refund"
+	"callcenter/internal/services"
+)
+
+// BookingHandler handles flight booking HTTP requests
+type BookingHandler struct {
+	bookingService *services.BookingService
+}
+
+// NewBookingHandler creates a new instance of BookingHandler
+func NewBookingHandler(bookingService *services.BookingService) *BookingHandler {
+	return &BookingHandler{
+		bookingService: bookingService,
+	}
+}
+
+// PassengerRequest represents a passenger of a new booking
+type PassengerRequest struct {
+	FirstName      string       `json:"first_name" binding:"required"`
+	LastName       string       `json:"last_name" binding:"required"`
+	Type           string       `json:"type" binding:"omitempty,oneof=adult child infant"`
+	DocumentNumber string       `json:"document_number"`
+	TicketNumber   string       `json:"ticket_number"`
+	BaseFare       money.Amount `json:"base_fare"` // in minor units of the booking currency
+	Taxes          money.Amount `json:"taxes"`
+}
+
+// SegmentRequest represents a flight of a new booking
+type SegmentRequest struct {
+	Airline      string    `json:"airline" binding:"required"`
+	FlightNumber string    `json:"flight_number" binding:"required"`
+	Origin       string    `json:"origin" binding:"required"`
+	Destination  string    `json:"destination" binding:"required"`
+	DepartureAt  time.Time `json:"departure_at" binding:"required"`
+	ArrivalAt    time.Time `json:"arrival_at"`
+	CabinClass   string    `json:"cabin_class"`
+}
+
+// CreateBookingRequest represents the request body for recording a booking
+type CreateBookingRequest struct {
+	UserID       uuid.UUID          `json:"user_id" binding:"required"`
+	PNR          string             `json:"pnr" binding:"required"`
+	Airline      string             `json:"airline" binding:"required"`
+	TicketType   string             `json:"ticket_type" binding:"required,oneof=charter systematic"`
+	FareClass    string             `json:"fare_class"`
+	ContactPhone string             `json:"contact_phone"`
+	ContactEmail string             `json:"contact_email"`
+	Currency     string             `json:"currency" binding:"required"` // ISO code, or IRT for fares in tomans
+	Passengers   []PassengerRequest `json:"passengers" binding:"required,min=1,dive"`
+	Segments     []SegmentRequest   `json:"segments" binding:"required,min=1,dive"`
+}
+
+// booking converts the request into a booking model
+func (r CreateBookingRequest) booking() *models.Booking {
+	booking := &models.Booking{
+		UserID:       r.UserID,
+		PNR:          r.PNR,
+		Airline:      r.Airline,
+		TicketType:   r.TicketType,
+		FareClass:    r.FareClass,
+		ContactPhone: r.ContactPhone,
+		ContactEmail: r.ContactEmail,
+		Currency:     r.Currency,
+	}
+	for _, p := range r.Passengers {
+		booking.Passengers = append(booking.Passengers, models.Passenger{
+			FirstName:      p.FirstName,
+			LastName:       p.LastName,
+			Type:           p.Type,
+			DocumentNumber: p.DocumentNumber,
+			TicketNumber:   p.TicketNumber,
+			BaseFare:       p.BaseFare,
+			Taxes:          p.Taxes,
+		})
+	}
+	for _, s := range r.Segments {
+		booking.Segments = append(booking.Segments, models.Segment{
+			Airline:      s.Airline,
+			FlightNumber: s.FlightNumber,
+			Origin:       s.Origin,
+			Destination:  s.Destination,
+			DepartureAt:  s.DepartureAt,
+			ArrivalAt:    s.ArrivalAt,
+			CabinClass:   s.CabinClass,
+		})
+	}
+	return booking
+}
+
+// CreateBooking records a booking issued by the reservation system
+func (h *BookingHandler) CreateBooking(c *gin.Context) {
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	booking := req.booking()
+	if err := h.bookingService.CreateBooking(c.Request.Context(), booking); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// ListBookings lists the customer's own bookings. Staff can look bookings up by
+// contact phone or by PNR/e-ticket number instead.
+func (h *BookingHandler) ListBookings(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if models.IsStaff(middleware.CurrentUserRole(c)) {
+		if phone := c.Query("phone"); phone != "" {
+			bookings, err := h.bookingService.GetBookingsByPhone(ctx, phone)
+			if err != nil {
+				respondError(c, http.StatusInternalServerError, "error.bookings_fetch_failed")
+				return
+			}
+			c.JSON(http.StatusOK, bookings)
+			return
+		}
+		if reference := c.Query("reference"); reference != "" {
+			booking, err := h.bookingService.GetBookingByReference(ctx, reference)
+			if err != nil {
+				respondServiceError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, []models.Booking{*booking})
+			return
+		}
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	bookings, err := h.bookingService.ListBookings(ctx, userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.bookings_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, bookings)
+}
+
+// GetBooking returns a booking with its passengers and segments
+func (h *BookingHandler) GetBooking(c *gin.Context) {
+	booking, ok := h.accessibleBooking(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// RefundQuoteRequest represents the optional request body of a refund quote.
+// Leaving both lists empty quotes whatever is left of the booking.
+type RefundQuoteRequest struct {
+	PassengerIDs []uuid.UUID `json:"passenger_ids"` // passengers to cancel; all when empty
+	SegmentIDs   []uuid.UUID `json:"segment_ids"`   // segments to cancel; all when empty
+}
+
+// bindRefundSelection reads the passengers and segments to cancel from an optional request body
+func bindRefundSelection(c *gin.Context) (services.RefundSelection, bool) {
+	var req RefundQuoteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindError(c, err)
+			return services.RefundSelection{}, false
+		}
+	}
+	return services.RefundSelection{PassengerIDs: req.PassengerIDs, SegmentIDs: req.SegmentIDs}, true
+}
+
+// QuoteRefund quotes the refund for cancelling a booking, or some of its
+// passengers and segments, now. The returned quote token must be sent back to
+// CancelBooking before it expires.
+func (h *BookingHandler) QuoteRefund(c *gin.Context) {
+	booking, ok := h.accessibleBooking(c)
+	if !ok {
+		return
+	}
+
+	selection, ok := bindRefundSelection(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.bookingService.QuoteRefund(c.Request.Context(), booking, selection, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quoteResponse(quote))
+}
+
+// quoteResponse presents a refund quote with its token. Amounts are in minor
+// units of the currency; amount_display is the refund as shown to customers.
+func quoteResponse(quote *models.RefundQuote) gin.H {
+	return gin.H{
+		"quote_token": quote.ID,
+		"expires_at":  quote.ExpiresAt,
+		"breakdown": gin.H{
+			"policy":          quote.PolicyName,
+			"base_fare":       quote.BaseFare,
+			"penalty_percent": quote.PenaltyPercent,
+			"penalty":         quote.Penalty,
+			"fee":             quote.Fee,
+			"taxes":           quote.Taxes,
+			"taxes_refunded":  quote.TaxesRefunded,
+			"amount":          quote.Amount,
+			"currency":        quote.Currency,
+			"amount_display":  money.Display(quote.Amount, quote.Currency),
+		},
+		"items": quote.Items,
+	}
+}
+
+// CancelBooking cancels a booking at the amount of an accepted refund quote and opens a refund request for it
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	booking, ok := h.accessibleBooking(c)
+	if !ok {
+		return
+	}
+
+	var req CancelTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	refundRequest, err := h.bookingService.CancelBooking(c.Request.Context(), services.Cancellation{
+		BookingID: booking.ID,
+		QuoteID:   req.QuoteToken,
+		Reason:    req.Reason,
+		Actor:     actorFromContext(c),
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "message.booking_cancellation_submitted"),
+		"refund_request": refundRequest,
+	})
+}
+
+// RefundRequestResponse is a refund request with the statuses it can move to
+type RefundRequestResponse struct {
+	*models.RefundRequest
+	NextStatuses []string `json:"next_statuses"`
+}
+
+// refundResponses adds the next statuses to refund requests
+func refundResponses(refundRequests []models.RefundRequest) []RefundRequestResponse {
+	responses := make([]RefundRequestResponse, 0, len(refundRequests))
+	for i := range refundRequests {
+		responses = append(responses, RefundRequestResponse{
+			RefundRequest: &refundRequests[i],
+			NextStatuses:  refund.NextStatuses(refundRequests[i].Status),
+		})
+	}
+	return responses
+}
+
+// ListRefundRequests lists the refund requests of a booking, newest first
+func (h *BookingHandler) ListRefundRequests(c *gin.Context) {
+	booking, ok := h.accessibleBooking(c)
+	if !ok {
+		return
+	}
+
+	refundRequests, err := h.bookingService.ListRefundRequests(c.Request.Context(), booking.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"refund_requests": refundResponses(refundRequests),
+	})
+}
+
+// GetRefundRequest returns a refund request of a booking
+func (h *BookingHandler) GetRefundRequest(c *gin.Context) {
+	booking, ok := h.accessibleBooking(c)
+	if !ok {
+		return
+	}
+	refundRequestID, ok := refundRequestParam(c)
+	if !ok {
+		return
+	}
+
+	refundRequest, err := h.bookingService.GetRefundRequest(c.Request.Context(), booking.ID, refundRequestID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RefundRequestResponse{
+		RefundRequest: refundRequest,
+		NextStatuses:  refund.NextStatuses(refundRequest.Status),
+	})
+}
+
+// UpdateRefundStatus moves a refund request of a booking to a new status
+func (h *BookingHandler) UpdateRefundStatus(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_booking_id")
+		return
+	}
+	refundRequestID, ok := refundRequestParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRefundStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	refundRequest, err := h.bookingService.UpdateRefundStatus(c.Request.Context(), bookingID, refundRequestID, req.Status, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "message.refund_status_updated"),
+		"refund_request": refundRequest,
+	})
+}
+
+// refundRequestParam parses the :refundId parameter
+func refundRequestParam(c *gin.Context) (uint, bool) {
+	refundRequestID, err := strconv.ParseUint(c.Param("refundId"), 10, 0)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_refund_id")
+		return 0, false
+	}
+	return uint(refundRequestID), true
+}
+
+// accessibleBooking loads the booking in the :id parameter. Customers only see
+// their own bookings; anything else is reported as not found.
+func (h *BookingHandler) accessibleBooking(c *gin.Context) (*models.Booking, bool) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_booking_id")
+		return nil, false
+	}
+
+	booking, err := h.bookingService.GetBooking(c.Request.Context(), bookingID)
+	if err != nil {
+		respondServiceError(c, err)
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if booking.UserID != userID && !models.IsStaff(middleware.CurrentUserRole(c)) {
+		respondError(c, http.StatusNotFound, "error.booking_not_found")
+		return nil, false
+	}
+	return booking, true
+}
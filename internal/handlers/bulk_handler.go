@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// BulkHandler handles bulk jobs over the tickets matching a search
+type BulkHandler struct {
+	bulkService *services.BulkService
+}
+
+// NewBulkHandler creates a new instance of BulkHandler
+func NewBulkHandler(bulkService *services.BulkService) *BulkHandler {
+	return &BulkHandler{bulkService: bulkService}
+}
+
+// BulkJobRequest represents the request body for queuing a bulk job. The
+// fields each action reads are those of its single-ticket endpoint: status,
+// reason and description to update the status; queue_id, agent_id, auto,
+// unassign and note to assign; add_tags and remove_tags to tag; body and
+// visibility to comment; and reason to cancel the bookings and refund them.
+type BulkJobRequest struct {
+	Action      string     `json:"action" binding:"required,oneof=status assign tag comment cancel"`
+	Status      string     `json:"status"`
+	Reason      string     `json:"reason"`
+	Description string     `json:"description"`
+	QueueID     *uuid.UUID `json:"queue_id"`
+	AgentID     *uuid.UUID `json:"agent_id"`
+	Auto        bool       `json:"auto"`
+	Unassign    bool       `json:"unassign"`
+	Note        string     `json:"note"`
+	AddTags     []string   `json:"add_tags"`
+	RemoveTags  []string   `json:"remove_tags"`
+	Body        string     `json:"body"`
+	Visibility  string     `json:"visibility" binding:"omitempty,oneof=public internal"`
+}
+
+// CreateBulkJob queues a bulk job for the tickets matching the search filters
+// of the query, those of SearchTickets
+func (h *BulkHandler) CreateBulkJob(c *gin.Context) {
+	var req BulkJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	search, ok := ticketSearch(c, services.TicketSearch{})
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkService.CreateJob(c.Request.Context(), services.NewBulkJob{
+		Action: req.Action,
+		Params: services.BulkParams{
+			Status:      req.Status,
+			Reason:      req.Reason,
+			Description: req.Description,
+			QueueID:     req.QueueID,
+			AgentID:     req.AgentID,
+			Auto:        req.Auto,
+			Unassign:    req.Unassign,
+			Note:        req.Note,
+			AddTags:     req.AddTags,
+			RemoveTags:  req.RemoveTags,
+			Body:        req.Body,
+			Visibility:  req.Visibility,
+		},
+		Search: search,
+	}, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListBulkJobs lists the latest bulk jobs with their progress
+func (h *BulkHandler) ListBulkJobs(c *gin.Context) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.bulkService.ListJobs(c.Request.Context(), limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.bulk_jobs_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// GetBulkJob returns a bulk job with the result of each of its tickets, only
+// those of one result when the status query parameter is pending, succeeded
+// or failed
+func (h *BulkHandler) GetBulkJob(c *gin.Context) {
+	jobID, ok := h.jobID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.BulkItemPending, models.BulkItemSucceeded, models.BulkItemFailed:
+	default:
+		respondError(c, http.StatusBadRequest, "error.invalid_job_item_status")
+		return
+	}
+
+	job, err := h.bulkService.GetJob(c.Request.Context(), jobID, status)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *BulkHandler) jobID(c *gin.Context) (uuid.UUID, bool) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_job_id")
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
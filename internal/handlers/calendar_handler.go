@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// CalendarHandler handles business calendar administration and business time queries
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+}
+
+// NewCalendarHandler creates a new instance of CalendarHandler
+func NewCalendarHandler(calendarService *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+// WorkingHoursRequest represents working hours on a day of the week
+type WorkingHoursRequest struct {
+	Weekday int    `json:"weekday" binding:"min=0,max=6"` // 0 is Sunday, 6 is Saturday
+	Opens   string `json:"opens" binding:"required"`      // e.g. 08:00
+	Closes  string `json:"closes" binding:"required"`     // e.g. 16:00
+}
+
+// HolidayRequest represents a day off. Without a year it recurs every year.
+type HolidayRequest struct {
+	Name   string `json:"name" binding:"required"`
+	System string `json:"system" binding:"omitempty,oneof=gregorian jalali"` // gregorian by default
+	Year   int    `json:"year" binding:"min=0"`
+	Month  int    `json:"month" binding:"required,min=1,max=12"`
+	Day    int    `json:"day" binding:"required,min=1,max=31"`
+}
+
+// holiday converts the request into a holiday model
+func (r HolidayRequest) holiday() models.CalendarHoliday {
+	return models.CalendarHoliday{
+		Name:   r.Name,
+		System: r.System,
+		Year:   r.Year,
+		Month:  r.Month,
+		Day:    r.Day,
+	}
+}
+
+// CalendarRequest represents the request body for creating or replacing a calendar
+type CalendarRequest struct {
+	Name      string                `json:"name" binding:"required"`
+	TimeZone  string                `json:"time_zone" binding:"required"`
+	IsDefault bool                  `json:"is_default"`
+	Hours     []WorkingHoursRequest `json:"hours" binding:"dive"`
+	Holidays  []HolidayRequest      `json:"holidays" binding:"dive"`
+}
+
+// calendar converts the request into a calendar model
+func (r CalendarRequest) calendar(id uuid.UUID) *models.BusinessCalendar {
+	model := &models.BusinessCalendar{
+		ID:        id,
+		Name:      r.Name,
+		TimeZone:  r.TimeZone,
+		IsDefault: r.IsDefault,
+	}
+	for _, hours := range r.Hours {
+		model.Hours = append(model.Hours, models.WorkingHours{
+			Weekday: hours.Weekday,
+			Opens:   hours.Opens,
+			Closes:  hours.Closes,
+		})
+	}
+	for _, holiday := range r.Holidays {
+		model.Holidays = append(model.Holidays, holiday.holiday())
+	}
+	return model
+}
+
+// ListCalendars lists the calendars with their working hours and holidays
+func (h *CalendarHandler) ListCalendars(c *gin.Context) {
+	calendars, err := h.calendarService.ListCalendars(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.calendars_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calendars": calendars})
+}
+
+// GetCalendar returns a calendar with its working hours and holidays
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	calendar, err := h.calendarService.GetCalendar(c.Request.Context(), calendarID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+// CreateCalendar adds a calendar
+func (h *CalendarHandler) CreateCalendar(c *gin.Context) {
+	var req CalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	calendar := req.calendar(uuid.Nil)
+	if err := h.calendarService.SaveCalendar(c.Request.Context(), calendar); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, calendar)
+}
+
+// UpdateCalendar replaces a calendar with its working hours and holidays
+func (h *CalendarHandler) UpdateCalendar(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	var req CalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	calendar := req.calendar(calendarID)
+	if err := h.calendarService.SaveCalendar(c.Request.Context(), calendar); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+// DeleteCalendar removes a calendar other than the default one
+func (h *CalendarHandler) DeleteCalendar(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.calendarService.DeleteCalendar(c.Request.Context(), calendarID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.calendar_deleted")})
+}
+
+// AddHoliday adds a day off to a calendar
+func (h *CalendarHandler) AddHoliday(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	var req HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	holiday := req.holiday()
+	if err := h.calendarService.AddHoliday(c.Request.Context(), calendarID, &holiday); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// RemoveHoliday removes a day off from a calendar
+func (h *CalendarHandler) RemoveHoliday(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+	holidayID, err := uuid.Parse(c.Param("holidayId"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_holiday_id")
+		return
+	}
+
+	if err := h.calendarService.RemoveHoliday(c.Request.Context(), calendarID, holidayID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.holiday_removed")})
+}
+
+// BusinessTime computes business time on a calendar: the business minutes
+// from from (now by default) to to, or the time a number of business minutes
+// after from. Times are RFC 3339.
+func (h *CalendarHandler) BusinessTime(c *gin.Context) {
+	calendarID, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	from := time.Now()
+	if value := c.Query("from"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_date")
+			return
+		}
+		from = t
+	}
+
+	cal, err := h.calendarService.Calendar(c.Request.Context(), calendarID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	from = from.In(cal.Location)
+	response := gin.H{
+		"from":      from,
+		"open":      cal.Open(from),
+		"next_open": cal.NextOpen(from),
+	}
+	if holiday := cal.Holiday(from); holiday != nil {
+		response["holiday"] = holiday.Name
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_date")
+			return
+		}
+		response["to"] = to.In(cal.Location)
+		response["business_minutes"] = int(cal.Between(from, to) / time.Minute)
+	}
+	if value := c.Query("minutes"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			respondError(c, http.StatusBadRequest, "error.invalid_minutes")
+			return
+		}
+		response["minutes"] = minutes
+		response["due_at"] = cal.Add(from, time.Duration(minutes)*time.Minute)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CalendarHandler) calendarID(c *gin.Context) (uuid.UUID, bool) {
+	calendarID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_calendar_id")
+		return uuid.Nil, false
+	}
+	return calendarID, true
+}
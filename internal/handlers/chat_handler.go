@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -9,38 +12,51 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"callcenter/internal/calendar"
+	"callcenter/internal/i18n"
+	"callcenter/internal/middleware"
 	"callcenter/internal/models"
 	"callcenter/internal/services"
 )
 
 // ChatHandler handles chat-related HTTP requests
 type ChatHandler struct {
-	db          *gorm.DB
-	nlpService  *services.NLPService
-	chatService *services.ChatService
+	db               *gorm.DB
+	nlpService       services.NLPService
+	chatService      *services.ChatService
+	labelingService  *services.LabelingService
+	knowledgeService *services.KnowledgeService
+	responseService  *services.ResponseService
+	calendarService  *services.CalendarService
 }
 
-// NewChatHandler creates a new instance of ChatHandler
-func NewChatHandler(db *gorm.DB, nlpService *services.NLPService, chatService *services.ChatService) *ChatHandler {
+// NewChatHandler creates a new instance of ChatHandler.
+// responseService may be nil, in which case replies are rule based.
+func NewChatHandler(db *gorm.DB, nlpService services.NLPService, chatService *services.ChatService, labelingService *services.LabelingService, knowledgeService *services.KnowledgeService, responseService *services.ResponseService, calendarService *services.CalendarService) *ChatHandler {
 	return &ChatHandler{
-		db:          db,
-		nlpService:  nlpService,
-		chatService: chatService,
+		db:               db,
+		nlpService:       nlpService,
+		chatService:      chatService,
+		labelingService:  labelingService,
+		knowledgeService: knowledgeService,
+		responseService:  responseService,
+		calendarService:  calendarService,
 	}
 }
 
-// MessageRequest represents the request body for sending a message
+// MessageRequest represents the request body for sending a message. Without a
+// session_id a new session is started for the current user.
 type MessageRequest struct {
-	Content  string `json:"content" binding:"required"`
-	Platform string `json:"platform" binding:"required"`
-	UserID   string `json:"user_id" binding:"required"`
+	Content   string `json:"content" binding:"required"`
+	Platform  string `json:"platform" binding:"required"`
+	SessionID string `json:"session_id"`
 }
 
 // HandleMessage handles incoming chat messages
 func (h *ChatHandler) HandleMessage(c *gin.Context) {
 	var req MessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
@@ -50,35 +66,58 @@ func (h *ChatHandler) HandleMessage(c *gin.Context) {
 	// Detect intent and extract entities
 	intent, err := h.nlpService.DetectIntent(c.Request.Context(), req.Content)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process message"})
+		respondError(c, http.StatusInternalServerError, "error.message_process_failed")
 		return
 	}
 
 	// Create or get chat session
-	session, err := h.chatService.CreateSession(c.Request.Context(), req.Platform, req.UserID)
+	var session *models.ChatSession
+	if req.SessionID != "" {
+		sessionID, err := uuid.Parse(req.SessionID)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_session_id")
+			return
+		}
+		var ok bool
+		if session, ok = h.sessionParam(c, sessionID); !ok {
+			return
+		}
+	} else {
+		userID, _ := middleware.CurrentUserID(c)
+		session, err = h.chatService.CreateSession(c.Request.Context(), req.Platform, userID.String())
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "error.session_create_failed")
+			return
+		}
+	}
+
+	// Add user message
+	userMessage, err := h.chatService.AddMessage(c.Request.Context(), session.ID, req.Content, "user", intent.Name, intent.Score, intent.Entities)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat session"})
+		respondError(c, http.StatusInternalServerError, "error.message_save_failed")
 		return
 	}
+	h.reviewMessage(c.Request.Context(), userMessage)
 
-	// Add user message
-	_, err = h.chatService.AddMessage(c.Request.Context(), session.ID, req.Content, "user", intent.Name, intent.Entities)
+	// Summarize the dialog state for intent validation and suggestions
+	dialogContext, err := h.chatService.BuildDialogContext(c.Request.Context(), session, actorFromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		respondError(c, http.StatusInternalServerError, "error.message_process_failed")
 		return
 	}
 
-	// Generate bot response based on intent
-	response, err := h.generateResponse(c.Request.Context(), intent, session.ID)
+	// Generate bot response based on intent, in the language of the conversation
+	lang := h.replyLanguage(c, session, req.Content)
+	response, err := h.generateResponse(c.Request.Context(), actorFromContext(c), lang, req.Content, intent, dialogContext, session.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate response"})
+		respondError(c, http.StatusInternalServerError, "error.response_generate_failed")
 		return
 	}
 
 	// Add bot response
-	_, err = h.chatService.AddMessage(c.Request.Context(), session.ID, response, "assistant", "", nil)
+	_, err = h.chatService.AddMessage(c.Request.Context(), session.ID, response, "assistant", "", 0, nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bot response"})
+		respondError(c, http.StatusInternalServerError, "error.response_save_failed")
 		return
 	}
 
@@ -93,8 +132,10 @@ func (h *ChatHandler) HandleMessage(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"session_id": session.ID,
-		"response":   response,
+		"session_id":  session.ID,
+		"response":    response,
+		"language":    lang,
+		"suggestions": h.suggestions(c.Request.Context(), lang, dialogContext),
 	})
 }
 
@@ -102,13 +143,17 @@ func (h *ChatHandler) HandleMessage(c *gin.Context) {
 func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	sessionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
+		return
+	}
+
+	if _, ok := h.sessionParam(c, sessionID); !ok {
 		return
 	}
 
 	messages, err := h.chatService.GetChatHistory(c.Request.Context(), sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat history"})
+		respondError(c, http.StatusInternalServerError, "error.chat_history_failed")
 		return
 	}
 
@@ -117,27 +162,203 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	})
 }
 
-// generateResponse generates a bot response based on the detected intent
-func (h *ChatHandler) generateResponse(ctx context.Context, intent *services.Intent, sessionID uuid.UUID) (string, error) {
-	// TODO: Implement actual response generation logic
-	// This should consider:
-	// 1. The detected intent
-	// 2. Extracted entities
-	// 3. Conversation context
-	// 4. Business rules and policies
+// sessionParam gets a chat session the current user may use: their own, or
+// any session for staff. Other sessions are reported as not found.
+func (h *ChatHandler) sessionParam(c *gin.Context, sessionID uuid.UUID) (*models.ChatSession, bool) {
+	session, err := h.chatService.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "error.session_not_found")
+		return nil, false
+	}
+	userID, _ := middleware.CurrentUserID(c)
+	if session.UserID != userID && !models.IsStaff(middleware.CurrentUserRole(c)) {
+		respondError(c, http.StatusNotFound, "error.session_not_found")
+		return nil, false
+	}
+	return session, true
+}
+
+// Suggestion is a quick-reply chip offered to the client with a bot reply
+type Suggestion struct {
+	Intent string `json:"intent"`
+	Label  string `json:"label"`
+}
+
+// faqTopics maps the intents answered from the knowledge base to the article topic
+// searched. General inquiries search every topic.
+var faqTopics = map[string]string{
+	services.IntentBaggagePolicy:  models.ArticleTopicBaggage,
+	services.IntentCheckIn:        models.ArticleTopicCheckIn,
+	services.IntentGeneralInquiry: "",
+}
 
-	// Placeholder implementation
+// generateResponse generates a bot response in lang for the latest message in the session.
+// Intents that contradict the dialog state are answered with an explanation, requests
+// for an agent outside working hours with when agents are back, and FAQ intents with
+// the best matching knowledge base article. Otherwise, when a language
+// model is configured it answers from the conversation history and may call ticket
+// tools; if it is not configured or fails, the reply is chosen by intent.
+func (h *ChatHandler) generateResponse(ctx context.Context, actor services.Actor, lang, message string, intent *services.Intent, dialogContext map[string]interface{}, sessionID uuid.UUID) (string, error) {
+	if err := h.nlpService.ValidateIntent(ctx, intent, dialogContext); err != nil {
+		var invalid *services.IntentValidationError
+		if !errors.As(err, &invalid) {
+			return "", err
+		}
+		return i18n.T(lang, "bot.invalid_intent."+invalid.Code, invalid.Params), nil
+	}
+
+	if response, ok := h.agentUnavailableResponse(ctx, lang, intent); ok {
+		return response, nil
+	}
+
+	if response, ok := h.articleResponse(ctx, lang, message, intent); ok {
+		return response, nil
+	}
+
+	if h.responseService != nil {
+		history, err := h.chatService.GetChatHistory(ctx, sessionID)
+		if err != nil {
+			return "", err
+		}
+
+		response, err := h.responseService.GenerateResponse(ctx, actor, lang, history)
+		if err == nil && response != "" {
+			return response, nil
+		}
+		log.Printf("LLM response generation failed, falling back to intent rules: %v", err)
+	}
+
+	return intentResponse(lang, intent), nil
+}
+
+// intentResponse returns the canned reply in lang for a detected intent
+func intentResponse(lang string, intent *services.Intent) string {
 	switch intent.Name {
-	case "ticket_lookup":
-		return "I can help you find your ticket. Could you please provide your ticket number or booking reference?", nil
-	case "ticket_cancellation":
-		return "I can help you cancel your ticket. Could you please provide your ticket number?", nil
-	case "refund_inquiry":
-		return "I can help you check your refund status. Could you please provide your ticket number?", nil
-	case "baggage_policy":
-		return "I can help you with baggage policy information. Which airline are you flying with?", nil
+	case services.IntentTicketLookup, services.IntentTicketCancellation, services.IntentRefundInquiry,
+		services.IntentBaggagePolicy, services.IntentCheckIn, services.IntentAgentRequest:
+		return i18n.T(lang, "bot."+intent.Name, nil)
 	default:
-		return "I'm not sure I understand. Could you please rephrase your question?", nil
+		return i18n.T(lang, "bot.fallback", nil)
+	}
+}
+
+// agentUnavailableResponse answers a request for an agent outside the working
+// hours of the default calendar with when agents are available again. Dates are
+// written in the Jalali calendar to Persian speakers. It reports false during
+// working hours, for other intents, or when the calendar cannot be read.
+func (h *ChatHandler) agentUnavailableResponse(ctx context.Context, lang string, intent *services.Intent) (string, bool) {
+	if intent.Name != services.IntentAgentRequest || h.calendarService == nil {
+		return "", false
+	}
+
+	cal, err := h.calendarService.DefaultCalendar(ctx)
+	if err != nil {
+		log.Printf("Failed to get business hours: %v", err)
+		return "", false
+	}
+	now := time.Now()
+	if cal.Open(now) {
+		return "", false
+	}
+
+	opens := cal.NextOpen(now).In(cal.Location)
+	date := opens.Format("2006-01-02")
+	if lang == "fa" {
+		year, month, day := calendar.ToJalali(opens)
+		date = fmt.Sprintf("%04d/%02d/%02d", year, month, day)
+	}
+	return i18n.T(lang, "bot.agent_unavailable", i18n.Params{
+		"date": date,
+		"time": opens.Format("15:04"),
+	}), true
+}
+
+// articleResponse answers an FAQ intent with the best matching knowledge base
+// article, preferring articles of the airline the customer mentioned. It reports
+// false when the intent is not an FAQ or no article matches.
+func (h *ChatHandler) articleResponse(ctx context.Context, lang, message string, intent *services.Intent) (string, bool) {
+	topic, ok := faqTopics[intent.Name]
+	if !ok || h.knowledgeService == nil {
+		return "", false
+	}
+
+	airline, _ := intent.Entities["airline"].(string)
+	article, err := h.knowledgeService.BestMatch(ctx, services.ArticleQuery{
+		Text:     message,
+		Airline:  airline,
+		Topic:    topic,
+		Language: lang,
+	})
+	if err != nil {
+		log.Printf("Knowledge base search failed: %v", err)
+		return "", false
+	}
+	if article == nil {
+		return "", false
+	}
+
+	return i18n.T(lang, "bot.article_answer", i18n.Params{
+		"title":   article.Title,
+		"excerpt": services.ArticleExcerpt(article),
+		"url":     h.knowledgeService.ArticleURL(article),
+	}), true
+}
+
+// replyLanguage resolves the language to answer a message in. A failure to
+// remember the language on the session is logged and never fails the request.
+func (h *ChatHandler) replyLanguage(c *gin.Context, session *models.ChatSession, content string) string {
+	lang, err := h.chatService.ResolveLanguage(c.Request.Context(), session, content, middleware.CurrentLanguage(c))
+	if err != nil {
+		log.Printf("Failed to resolve session language: %v", err)
+		return middleware.CurrentLanguage(c)
+	}
+	return lang
+}
+
+// reviewMessage feeds a saved user message to the labeling queue. Low-confidence
+// messages are queued directly; a request for a human escalates the session and
+// queues the whole conversation. Failures are logged and never fail the request.
+func (h *ChatHandler) reviewMessage(ctx context.Context, message *models.ChatMessage) {
+	if err := h.labelingService.EnqueueIfLowConfidence(ctx, message); err != nil {
+		log.Printf("Failed to queue message for labeling: %v", err)
+	}
+
+	if message.Intent == services.IntentAgentRequest {
+		if err := h.escalate(ctx, message.SessionID); err != nil {
+			log.Printf("Failed to escalate session: %v", err)
+		}
+	}
+}
+
+// escalate hands the session over to human support and queues it for labeling
+func (h *ChatHandler) escalate(ctx context.Context, sessionID uuid.UUID) error {
+	if err := h.chatService.EscalateSession(ctx, sessionID); err != nil {
+		return err
+	}
+	return h.labelingService.EnqueueEscalatedSession(ctx, sessionID)
+}
+
+// suggestions returns the suggestion chips in lang for the current dialog state
+func (h *ChatHandler) suggestions(ctx context.Context, lang string, dialogContext map[string]interface{}) []Suggestion {
+	intents, err := h.nlpService.GetIntentSuggestions(ctx, dialogContext)
+	if err != nil {
+		log.Printf("Failed to get intent suggestions: %v", err)
+		return []Suggestion{}
+	}
+
+	suggestions := make([]Suggestion, 0, len(intents))
+	for _, name := range intents {
+		suggestions = append(suggestions, Suggestion{Intent: name, Label: i18n.T(lang, "suggestion."+name, nil)})
+	}
+	return suggestions
+}
+
+// actorFromContext builds the bot actor from the authenticated request
+func actorFromContext(c *gin.Context) services.Actor {
+	userID, _ := middleware.CurrentUserID(c)
+	return services.Actor{
+		UserID: userID,
+		Role:   middleware.CurrentUserRole(c),
 	}
 }
 
@@ -148,7 +369,7 @@ type CreateSessionRequest struct {
 func (h *ChatHandler) CreateSession(c *gin.Context) {
 	var req CreateSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
@@ -163,7 +384,7 @@ func (h *ChatHandler) CreateSession(c *gin.Context) {
 	}
 
 	if err := h.db.Create(&session).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		respondError(c, http.StatusInternalServerError, "error.session_create_failed")
 		return
 	}
 
@@ -175,7 +396,7 @@ func (h *ChatHandler) ListSessions(c *gin.Context) {
 
 	var sessions []models.ChatSession
 	if err := h.db.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		respondError(c, http.StatusInternalServerError, "error.sessions_fetch_failed")
 		return
 	}
 
@@ -186,13 +407,13 @@ func (h *ChatHandler) GetSession(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	sessionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
 		return
 	}
 
 	var session models.ChatSession
 	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondError(c, http.StatusNotFound, "error.session_not_found")
 		return
 	}
 
@@ -207,53 +428,61 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	sessionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
 		return
 	}
 
 	var req SendMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
 	// Verify session exists and belongs to user
 	var session models.ChatSession
 	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondError(c, http.StatusNotFound, "error.session_not_found")
+		return
+	}
+
+	intent, err := h.nlpService.DetectIntent(c.Request.Context(), req.Content)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.message_process_failed")
 		return
 	}
 
 	// Create user message
-	userMessage := models.ChatMessage{
-		ID:        uuid.New(),
-		SessionID: sessionID,
-		Content:   req.Content,
-		Role:      "user",
+	userMessage, err := h.chatService.AddMessage(c.Request.Context(), sessionID, req.Content, "user", intent.Name, intent.Score, intent.Entities)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.message_save_failed")
+		return
 	}
+	h.reviewMessage(c.Request.Context(), userMessage)
 
-	if err := h.db.Create(&userMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+	dialogContext, err := h.chatService.BuildDialogContext(c.Request.Context(), &session, actorFromContext(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.message_process_failed")
 		return
 	}
 
-	// TODO: Process message with NLP service and generate response
-	// For now, just echo the message
-	assistantMessage := models.ChatMessage{
-		ID:        uuid.New(),
-		SessionID: sessionID,
-		Content:   "I received your message: " + req.Content,
-		Role:      "assistant",
+	lang := h.replyLanguage(c, &session, req.Content)
+	response, err := h.generateResponse(c.Request.Context(), actorFromContext(c), lang, req.Content, intent, dialogContext, sessionID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.response_generate_failed")
+		return
 	}
 
-	if err := h.db.Create(&assistantMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save response"})
+	assistantMessage, err := h.chatService.AddMessage(c.Request.Context(), sessionID, response, "assistant", "", 0, nil)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.response_save_failed")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"userMessage":      userMessage,
 		"assistantMessage": assistantMessage,
+		"language":         lang,
+		"suggestions":      h.suggestions(c.Request.Context(), lang, dialogContext),
 	})
 }
 
@@ -261,22 +490,90 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	sessionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
 		return
 	}
 
 	// Verify session exists and belongs to user
 	var session models.ChatSession
 	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondError(c, http.StatusNotFound, "error.session_not_found")
 		return
 	}
 
 	var messages []models.ChatMessage
 	if err := h.db.Where("session_id = ?", sessionID).Order("created_at asc").Find(&messages).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		respondError(c, http.StatusInternalServerError, "error.messages_fetch_failed")
 		return
 	}
 
 	c.JSON(http.StatusOK, messages)
 }
+
+// EscalateSession hands a chat session over to human support
+func (h *ChatHandler) EscalateSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
+		return
+	}
+
+	// Verify session exists and belongs to user
+	var session models.ChatSession
+	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		respondError(c, http.StatusNotFound, "error.session_not_found")
+		return
+	}
+
+	if err := h.escalate(c.Request.Context(), sessionID); err != nil {
+		respondError(c, http.StatusInternalServerError, "error.escalate_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "message.session_escalated"),
+	})
+}
+
+// SetLanguageRequest represents the request body for choosing the session language
+type SetLanguageRequest struct {
+	Language string `json:"language" binding:"required"`
+}
+
+// SetSessionLanguage pins the language the bot replies in for a session
+func (h *ChatHandler) SetSessionLanguage(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_session_id")
+		return
+	}
+
+	var req SetLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if !i18n.IsSupported(req.Language) {
+		respondError(c, http.StatusBadRequest, "error.unsupported_language")
+		return
+	}
+
+	// Verify session exists and belongs to user
+	var session models.ChatSession
+	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		respondError(c, http.StatusNotFound, "error.session_not_found")
+		return
+	}
+
+	if err := h.chatService.SetSessionLanguage(c.Request.Context(), sessionID, req.Language); err != nil {
+		respondError(c, http.StatusInternalServerError, "error.language_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"language": req.Language,
+		"message":  i18n.T(req.Language, "message.language_updated", nil),
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// CommentHandler handles the comments on support tickets
+type CommentHandler struct {
+	ticketService  *services.TicketService
+	commentService *services.CommentService
+}
+
+// NewCommentHandler creates a new instance of CommentHandler
+func NewCommentHandler(ticketService *services.TicketService, commentService *services.CommentService) *CommentHandler {
+	return &CommentHandler{
+		ticketService:  ticketService,
+		commentService: commentService,
+	}
+}
+
+// CommentRequest represents the request body for adding a comment to a ticket.
+// Comments by staff are internal notes unless visibility is public; comments
+// by customers are always public.
+type CommentRequest struct {
+	Body       string      `json:"body" binding:"required"`
+	Visibility string      `json:"visibility" binding:"omitempty,oneof=public internal"`
+	Mentions   []uuid.UUID `json:"mentions"` // IDs of the agents to mention
+}
+
+// EditCommentRequest represents the request body for editing a comment
+type EditCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// ListComments lists the comments on a ticket the current user may see
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	ticket, ok := findTicketParam(c, h.ticketService)
+	if !ok {
+		return
+	}
+
+	comments, err := h.commentService.ListComments(c.Request.Context(), ticket.ID, actorFromContext(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.comments_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comments": comments})
+}
+
+// AddComment adds a reply or an internal note to a ticket
+func (h *CommentHandler) AddComment(c *gin.Context) {
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ticket, ok := findTicketParam(c, h.ticketService)
+	if !ok {
+		return
+	}
+
+	actor := actorFromContext(c)
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.CommentPublic
+		if models.IsStaff(actor.Role) {
+			visibility = models.CommentInternal
+		}
+	}
+
+	comment, err := h.commentService.AddComment(c.Request.Context(), ticket, services.NewComment{
+		Body:       req.Body,
+		Visibility: visibility,
+		Mentions:   req.Mentions,
+	}, actor)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// EditComment replaces the body of a comment by the current user
+func (h *CommentHandler) EditComment(c *gin.Context) {
+	commentID, ok := h.commentID(c)
+	if !ok {
+		return
+	}
+
+	var req EditCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ticket, ok := findTicketParam(c, h.ticketService)
+	if !ok {
+		return
+	}
+
+	comment, err := h.commentService.EditComment(c.Request.Context(), ticket.ID, commentID, req.Body, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// AddAttachment attaches the file uploaded in the file form field to a
+// comment by the current user
+func (h *CommentHandler) AddAttachment(c *gin.Context) {
+	commentID, ok := h.commentID(c)
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.file_required")
+		return
+	}
+	if header.Size > services.MaxAttachmentSize {
+		respondError(c, http.StatusRequestEntityTooLarge, "error.file_too_large")
+		return
+	}
+
+	ticket, ok := findTicketParam(c, h.ticketService)
+	if !ok {
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.file_required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxAttachmentSize+1))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.file_required")
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+	attachment := &models.CommentAttachment{
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Data:        data,
+	}
+	if err := h.commentService.AddAttachment(c.Request.Context(), ticket.ID, commentID, attachment, actorFromContext(c)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment sends a file attached to a comment on a ticket
+func (h *CommentHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_attachment_id")
+		return
+	}
+
+	ticket, ok := findTicketParam(c, h.ticketService)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.commentService.GetAttachment(c.Request.Context(), ticket.ID, attachmentID, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	// Uploaded files are always downloaded, never rendered by the browser
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
+
+func (h *CommentHandler) commentID(c *gin.Context) (uuid.UUID, bool) {
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_comment_id")
+		return uuid.Nil, false
+	}
+	return commentID, true
+}
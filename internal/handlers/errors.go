@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"callcenter/internal/i18n"
+	"callcenter/internal/middleware"
+	"callcenter/internal/services"
+)
+
+// statusForError maps service sentinel errors to HTTP status codes
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// translate returns the catalog message for key in the request language
+func translate(c *gin.Context, key string) string {
+	return i18n.T(middleware.CurrentLanguage(c), key, nil)
+}
+
+// respondError writes a localized error message
+func respondError(c *gin.Context, status int, key string) {
+	c.JSON(status, gin.H{"error": translate(c, key)})
+}
+
+// respondBindError rejects a malformed request body. The validation details
+// are kept untranslated for API clients.
+func respondBindError(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   translate(c, "error.invalid_request"),
+		"details": err.Error(),
+	})
+}
+
+// respondServiceError writes a localized error for a service error, with the
+// status chosen by statusForError. Internal errors are not exposed.
+func respondServiceError(c *gin.Context, err error) {
+	status := statusForError(err)
+	switch status {
+	case http.StatusNotFound:
+		c.JSON(status, gin.H{"error": translate(c, "error.not_found"), "details": err.Error()})
+	case http.StatusBadRequest:
+		c.JSON(status, gin.H{"error": translate(c, "error.invalid_request"), "details": err.Error()})
+	case http.StatusConflict:
+		c.JSON(status, gin.H{"error": translate(c, "error.conflict"), "details": err.Error()})
+	case http.StatusForbidden:
+		c.JSON(status, gin.H{"error": translate(c, "error.insufficient_permissions"), "details": err.Error()})
+	default:
+		respondError(c, status, "error.internal")
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// KnowledgeHandler handles the FAQ knowledge base
+type KnowledgeHandler struct {
+	knowledgeService *services.KnowledgeService
+}
+
+// NewKnowledgeHandler creates a new instance of KnowledgeHandler
+func NewKnowledgeHandler(knowledgeService *services.KnowledgeService) *KnowledgeHandler {
+	return &KnowledgeHandler{
+		knowledgeService: knowledgeService,
+	}
+}
+
+// ArticleRequest represents the request body for creating or replacing an article
+type ArticleRequest struct {
+	Slug      string `json:"slug" binding:"required"`
+	Airline   string `json:"airline"`
+	Topic     string `json:"topic" binding:"required"`
+	Language  string `json:"language" binding:"required"`
+	Title     string `json:"title" binding:"required"`
+	Summary   string `json:"summary"`
+	Body      string `json:"body" binding:"required"`
+	URL       string `json:"url"`
+	Published *bool  `json:"published"`
+}
+
+// apply copies the request onto an article. Articles are published unless the
+// request says otherwise.
+func (r ArticleRequest) apply(article *models.Article) {
+	article.Slug = r.Slug
+	article.Airline = r.Airline
+	article.Topic = r.Topic
+	article.Language = r.Language
+	article.Title = r.Title
+	article.Summary = r.Summary
+	article.Body = r.Body
+	article.URL = r.URL
+	article.Published = r.Published == nil || *r.Published
+}
+
+// ArticleResponse is an article with the link customers are sent to
+type ArticleResponse struct {
+	*models.Article
+	Link string `json:"link"`
+}
+
+// ListArticles lists articles by airline, topic and language. Drafts are only listed for staff.
+func (h *KnowledgeHandler) ListArticles(c *gin.Context) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	filter := services.ArticleFilter{
+		Airline:       c.Query("airline"),
+		Topic:         c.Query("topic"),
+		Language:      c.Query("language"),
+		IncludeDrafts: models.IsStaff(middleware.CurrentUserRole(c)),
+	}
+	articles, total, err := h.knowledgeService.ListArticles(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.articles_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"articles": h.withLinks(articles),
+		"total":    total,
+	})
+}
+
+// SearchArticles runs a full-text search over published articles
+func (h *KnowledgeHandler) SearchArticles(c *gin.Context) {
+	text := c.Query("q")
+	if text == "" {
+		respondError(c, http.StatusBadRequest, "error.query_required")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 50 {
+		respondError(c, http.StatusBadRequest, "error.invalid_limit")
+		return
+	}
+
+	matches, err := h.knowledgeService.Search(c.Request.Context(), services.ArticleQuery{
+		Text:     text,
+		Airline:  c.Query("airline"),
+		Topic:    c.Query("topic"),
+		Language: c.DefaultQuery("language", middleware.CurrentLanguage(c)),
+		Limit:    limit,
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.articles_fetch_failed")
+		return
+	}
+
+	results := make([]gin.H, 0, len(matches))
+	for i := range matches {
+		results = append(results, gin.H{
+			"article": ArticleResponse{Article: &matches[i].Article, Link: h.knowledgeService.ArticleURL(&matches[i].Article)},
+			"rank":    matches[i].Rank,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// GetArticle retrieves an article. Drafts are only visible to staff.
+func (h *KnowledgeHandler) GetArticle(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_article_id")
+		return
+	}
+
+	article, err := h.knowledgeService.GetArticle(c.Request.Context(), articleID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if !article.Published && !models.IsStaff(middleware.CurrentUserRole(c)) {
+		respondError(c, http.StatusNotFound, "error.article_not_found")
+		return
+	}
+
+	c.JSON(http.StatusOK, ArticleResponse{Article: article, Link: h.knowledgeService.ArticleURL(article)})
+}
+
+// CreateArticle adds an article to the knowledge base
+func (h *KnowledgeHandler) CreateArticle(c *gin.Context) {
+	var req ArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	var article models.Article
+	req.apply(&article)
+	if err := h.knowledgeService.CreateArticle(c.Request.Context(), &article); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ArticleResponse{Article: &article, Link: h.knowledgeService.ArticleURL(&article)})
+}
+
+// UpdateArticle replaces the content of an article
+func (h *KnowledgeHandler) UpdateArticle(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_article_id")
+		return
+	}
+
+	var req ArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	article, err := h.knowledgeService.GetArticle(c.Request.Context(), articleID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	req.apply(article)
+	if err := h.knowledgeService.UpdateArticle(c.Request.Context(), article); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ArticleResponse{Article: article, Link: h.knowledgeService.ArticleURL(article)})
+}
+
+// DeleteArticle removes an article from the knowledge base
+func (h *KnowledgeHandler) DeleteArticle(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_article_id")
+		return
+	}
+
+	if err := h.knowledgeService.DeleteArticle(c.Request.Context(), articleID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.article_deleted")})
+}
+
+func (h *KnowledgeHandler) withLinks(articles []models.Article) []ArticleResponse {
+	responses := make([]ArticleResponse, 0, len(articles))
+	for i := range articles {
+		responses = append(responses, ArticleResponse{Article: &articles[i], Link: h.knowledgeService.ArticleURL(&articles[i])})
+	}
+	return responses
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/i18n"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/nlpeval"
+	"callcenter/internal/services"
+)
+
+// LabelingHandler handles the review queue of low-confidence and escalated messages
+type LabelingHandler struct {
+	labelingService *services.LabelingService
+}
+
+// NewLabelingHandler creates a new instance of LabelingHandler
+func NewLabelingHandler(labelingService *services.LabelingService) *LabelingHandler {
+	return &LabelingHandler{
+		labelingService: labelingService,
+	}
+}
+
+// ListTasks lists queued messages, pending ones by default
+func (h *LabelingHandler) ListTasks(c *gin.Context) {
+	status := c.DefaultQuery("status", models.LabelingStatusPending)
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	tasks, total, err := h.labelingService.ListTasks(c.Request.Context(), status, c.Query("reason"), limit, offset)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.labeling_tasks_fetch_failed")
+		return
+	}
+
+	response := gin.H{
+		"tasks": tasks,
+		"total": total,
+	}
+	if status == models.LabelingStatusPending {
+		response["summary"] = i18n.Tn(middleware.CurrentLanguage(c), "message.labeling_queue", int(total), nil)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// LabelTaskRequest represents the request body for correcting a message label
+type LabelTaskRequest struct {
+	Intent   string            `json:"intent"`
+	Entities map[string]string `json:"entities"`
+	Skip     bool              `json:"skip"`
+}
+
+// LabelTask records an agent's corrected intent and entities for a queued message
+func (h *LabelingHandler) LabelTask(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_task_id")
+		return
+	}
+
+	var req LabelTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if req.Skip {
+		if err := h.labelingService.SkipTask(c.Request.Context(), taskID, userID); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.labeling_task_skipped")})
+		return
+	}
+
+	if req.Intent == "" {
+		respondError(c, http.StatusBadRequest, "error.intent_required")
+		return
+	}
+
+	task, err := h.labelingService.LabelTask(c.Request.Context(), taskID, req.Intent, req.Entities, userID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// ExportLabels streams corrected examples as JSON Lines in the nlp-eval dataset format
+func (h *LabelingHandler) ExportLabels(c *gin.Context) {
+	tasks, err := h.labelingService.LabelledTasks(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.labels_export_failed")
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="labelled_intents.jsonl"`)
+	c.Status(http.StatusOK)
+	for _, task := range tasks {
+		example := nlpeval.Example{Text: task.Text, Intent: task.CorrectedIntent}
+		if task.CorrectedEntities != "" {
+			json.Unmarshal([]byte(task.CorrectedEntities), &example.Entities)
+		}
+		if err := nlpeval.WriteExample(c.Writer, example); err != nil {
+			return
+		}
+	}
+}
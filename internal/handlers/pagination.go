@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize caps the limit query parameter of list endpoints
+const maxPageSize = 500
+
+// pagination parses the limit and offset query parameters. On invalid values it
+// writes the error response and returns ok=false.
+func pagination(c *gin.Context) (limit, offset int, ok bool) {
+	limit, ok = pageLimit(c)
+	if !ok {
+		return 0, 0, false
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, http.StatusBadRequest, "error.invalid_offset")
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+// pageLimit parses the limit query parameter of endpoints paged with a cursor.
+// On an invalid value it writes the error response and returns ok=false.
+func pageLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		respondError(c, http.StatusBadRequest, "error.invalid_limit")
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"callcenter/internal/payment"
+	"callcenter/internal/services"
+)
+
+// PaymentHandler handles callbacks from the payment gateway
+type PaymentHandler struct {
+	payoutService *services.RefundPayoutService
+	webhookSecret string
+}
+
+// NewPaymentHandler creates a new instance of PaymentHandler
+func NewPaymentHandler(payoutService *services.RefundPayoutService, webhookSecret string) *PaymentHandler {
+	return &PaymentHandler{
+		payoutService: payoutService,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// PayoutCallback applies a payout update posted by the gateway. The body must
+// be signed with the webhook secret.
+func (h *PaymentHandler) PayoutCallback(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+	if !payment.VerifySignature(body, c.GetHeader(payment.SignatureHeader), h.webhookSecret) {
+		respondError(c, http.StatusUnauthorized, "error.invalid_payment_signature")
+		return
+	}
+
+	var payout payment.Payout
+	if err := json.Unmarshal(body, &payout); err != nil || payout.ID == "" {
+		respondError(c, http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+
+	refundRequest, err := h.payoutService.HandleCallback(c.Request.Context(), payout.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"refund_request_id": refundRequest.ID,
+		"status":            refundRequest.Status,
+	})
+}
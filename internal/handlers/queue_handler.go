@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// QueueHandler handles support queue administration and agent skills
+type QueueHandler struct {
+	assignmentService *services.AssignmentService
+}
+
+// NewQueueHandler creates a new instance of QueueHandler
+func NewQueueHandler(assignmentService *services.AssignmentService) *QueueHandler {
+	return &QueueHandler{assignmentService: assignmentService}
+}
+
+// QueueRequest represents the request body for creating or updating a queue
+type QueueRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	Strategy    string     `json:"strategy" binding:"required,oneof=round_robin least_loaded skills"`
+	AutoAssign  bool       `json:"auto_assign"`
+	CalendarID  *uuid.UUID `json:"calendar_id"`  // business hours of the queue; the default calendar when empty
+	TicketTypes []string   `json:"ticket_types"` // ticket types routed to the queue
+}
+
+// QueueMemberRequest represents the request body for adding an agent to a queue
+type QueueMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// AgentSkillsRequest represents the request body for replacing an agent's skills
+type AgentSkillsRequest struct {
+	Skills []string `json:"skills"`
+}
+
+// ListQueues lists the queues with their members and open ticket counts
+func (h *QueueHandler) ListQueues(c *gin.Context) {
+	queues, err := h.assignmentService.ListQueues(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.queues_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queues": queues})
+}
+
+// GetQueue returns a queue with its members and routes
+func (h *QueueHandler) GetQueue(c *gin.Context) {
+	queueID, ok := h.queueID(c)
+	if !ok {
+		return
+	}
+
+	queue, err := h.assignmentService.GetQueue(c.Request.Context(), queueID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// CreateQueue adds a queue
+func (h *QueueHandler) CreateQueue(c *gin.Context) {
+	var req QueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	queue := &models.Queue{
+		Name:        req.Name,
+		Description: req.Description,
+		Strategy:    req.Strategy,
+		AutoAssign:  req.AutoAssign,
+		CalendarID:  req.CalendarID,
+	}
+	if err := h.assignmentService.SaveQueue(c.Request.Context(), queue, req.TicketTypes); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, queue)
+}
+
+// UpdateQueue updates a queue and replaces its routes
+func (h *QueueHandler) UpdateQueue(c *gin.Context) {
+	queueID, ok := h.queueID(c)
+	if !ok {
+		return
+	}
+
+	var req QueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	queue := &models.Queue{
+		ID:          queueID,
+		Name:        req.Name,
+		Description: req.Description,
+		Strategy:    req.Strategy,
+		AutoAssign:  req.AutoAssign,
+		CalendarID:  req.CalendarID,
+	}
+	if err := h.assignmentService.SaveQueue(c.Request.Context(), queue, req.TicketTypes); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// AddMember adds an agent to a queue
+func (h *QueueHandler) AddMember(c *gin.Context) {
+	queueID, ok := h.queueID(c)
+	if !ok {
+		return
+	}
+
+	var req QueueMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.assignmentService.AddMember(c.Request.Context(), queueID, req.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.queue_member_added")})
+}
+
+// RemoveMember takes an agent off a queue
+func (h *QueueHandler) RemoveMember(c *gin.Context) {
+	queueID, ok := h.queueID(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_user_id")
+		return
+	}
+
+	if err := h.assignmentService.RemoveMember(c.Request.Context(), queueID, userID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.queue_member_removed")})
+}
+
+// SetAgentSkills replaces the skills of an agent
+func (h *QueueHandler) SetAgentSkills(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_user_id")
+		return
+	}
+
+	var req AgentSkillsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	skills, err := h.assignmentService.SetSkills(c.Request.Context(), userID, req.Skills)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"skills": skills})
+}
+
+func (h *QueueHandler) queueID(c *gin.Context) (uuid.UUID, bool) {
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_queue_id")
+		return uuid.Nil, false
+	}
+	return queueID, true
+}
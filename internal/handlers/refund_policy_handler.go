@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+	"callcenter/internal/refund"
+	"callcenter/internal/services"
+)
+
+// RefundPolicyHandler handles refund policy administration and refund quotes
+type RefundPolicyHandler struct {
+	policyService  *services.RefundPolicyService
+	bookingService *services.BookingService
+}
+
+// NewRefundPolicyHandler creates a new instance of RefundPolicyHandler
+func NewRefundPolicyHandler(policyService *services.RefundPolicyService, bookingService *services.BookingService) *RefundPolicyHandler {
+	return &RefundPolicyHandler{
+		policyService:  policyService,
+		bookingService: bookingService,
+	}
+}
+
+// RefundTierRequest represents a penalty tier of a refund policy
+type RefundTierRequest struct {
+	MinHoursBeforeDeparture int     `json:"min_hours_before_departure"`
+	PenaltyPercent          float64 `json:"penalty_percent"`
+}
+
+// RefundPolicyRequest represents the request body for creating or replacing a refund policy
+type RefundPolicyRequest struct {
+	Name                 string              `json:"name" binding:"required"`
+	Airline              string              `json:"airline"`
+	FareClass            string              `json:"fare_class"`
+	TicketType           string              `json:"ticket_type"`
+	NonRefundable        bool                `json:"non_refundable"`
+	TaxesNonRefundable   bool                `json:"taxes_non_refundable"`
+	FixedFee             money.Amount        `json:"fixed_fee"`
+	NoShowPenaltyPercent float64             `json:"no_show_penalty_percent"`
+	Tiers                []RefundTierRequest `json:"tiers"`
+}
+
+// policy converts the request into a refund policy model
+func (r RefundPolicyRequest) policy() *models.RefundPolicy {
+	policy := &models.RefundPolicy{
+		Name:                 r.Name,
+		Airline:              r.Airline,
+		FareClass:            r.FareClass,
+		TicketType:           r.TicketType,
+		NonRefundable:        r.NonRefundable,
+		TaxesNonRefundable:   r.TaxesNonRefundable,
+		FixedFee:             r.FixedFee,
+		NoShowPenaltyPercent: r.NoShowPenaltyPercent,
+	}
+	for _, tier := range r.Tiers {
+		policy.Tiers = append(policy.Tiers, models.RefundPenaltyTier{
+			MinHoursBeforeDeparture: tier.MinHoursBeforeDeparture,
+			PenaltyPercent:          tier.PenaltyPercent,
+		})
+	}
+	return policy
+}
+
+// ListPolicies lists refund policies, optionally filtered by airline
+func (h *RefundPolicyHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.policyService.ListPolicies(c.Request.Context(), c.Query("airline"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.refund_policies_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"defaults": refund.DefaultPolicies,
+	})
+}
+
+// GetPolicy returns a refund policy with its tiers
+func (h *RefundPolicyHandler) GetPolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.policyService.GetPolicy(c.Request.Context(), policyID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// CreatePolicy adds a refund policy
+func (h *RefundPolicyHandler) CreatePolicy(c *gin.Context) {
+	var req RefundPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	policy := req.policy()
+	if err := h.policyService.CreatePolicy(c.Request.Context(), policy); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdatePolicy replaces a refund policy and its tiers
+func (h *RefundPolicyHandler) UpdatePolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	var req RefundPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	policy := req.policy()
+	if err := h.policyService.UpdatePolicy(c.Request.Context(), policyID, policy); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy removes a refund policy
+func (h *RefundPolicyHandler) DeletePolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.policyService.DeletePolicy(c.Request.Context(), policyID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "message.refund_policy_deleted"),
+	})
+}
+
+// QuoteRequest represents the request body for a dry-run refund quote
+type QuoteRequest struct {
+	BookingID    uuid.UUID            `json:"booking_id" binding:"required"`
+	PassengerIDs []uuid.UUID          `json:"passenger_ids"` // passengers to quote; all when empty
+	SegmentIDs   []uuid.UUID          `json:"segment_ids"`   // segments to quote; all when empty
+	At           *time.Time           `json:"at"`            // cancellation time; defaults to now
+	Policy       *RefundPolicyRequest `json:"policy"`        // unsaved policy to try instead of the stored ones
+}
+
+// Quote computes the refund of a booking without cancelling it, under the
+// stored policies or under a draft policy sent with the request
+func (h *RefundPolicyHandler) Quote(c *gin.Context) {
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	booking, err := h.bookingService.GetBooking(ctx, req.BookingID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	coupons := refund.SelectCoupons(booking, req.PassengerIDs, req.SegmentIDs)
+
+	var quote *refund.Quote
+	if req.Policy != nil {
+		policy := req.Policy.policy()
+		if err := services.ValidateRefundPolicy(policy); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		quote, err = services.QuoteWithPolicy(policy, booking, coupons, at)
+	} else {
+		quote, err = h.policyService.Quote(ctx, booking, coupons, at)
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *RefundPolicyHandler) policyID(c *gin.Context) (uuid.UUID, bool) {
+	policyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_refund_policy_id")
+		return uuid.Nil, false
+	}
+	return policyID, true
+}
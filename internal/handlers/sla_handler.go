@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+)
+
+// SLAHandler handles SLA policy administration and SLA reports
+type SLAHandler struct {
+	slaService *services.SLAService
+}
+
+// NewSLAHandler creates a new instance of SLAHandler
+func NewSLAHandler(slaService *services.SLAService) *SLAHandler {
+	return &SLAHandler{slaService: slaService}
+}
+
+// SLAPolicyRequest represents the request body for creating or updating an SLA
+// policy. Targets are minutes of business time; a policy without a queue
+// covers all queues.
+type SLAPolicyRequest struct {
+	Name                 string     `json:"name" binding:"required"`
+	Priority             string     `json:"priority" binding:"required,oneof=low medium high"`
+	QueueID              *uuid.UUID `json:"queue_id"`
+	FirstResponseMinutes int        `json:"first_response_minutes" binding:"required,min=1"`
+	ResolutionMinutes    int        `json:"resolution_minutes" binding:"required,min=1"`
+	WarningPercent       int        `json:"warning_percent" binding:"omitempty,min=1,max=99"`
+}
+
+// policy converts the request into an SLA policy model
+func (r SLAPolicyRequest) policy(id uuid.UUID) *models.SLAPolicy {
+	return &models.SLAPolicy{
+		ID:                   id,
+		Name:                 r.Name,
+		Priority:             r.Priority,
+		QueueID:              r.QueueID,
+		FirstResponseMinutes: r.FirstResponseMinutes,
+		ResolutionMinutes:    r.ResolutionMinutes,
+		WarningPercent:       r.WarningPercent,
+	}
+}
+
+// ListPolicies lists the SLA policies
+func (h *SLAHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.slaService.ListPolicies(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.sla_policies_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// GetPolicy returns an SLA policy
+func (h *SLAHandler) GetPolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.slaService.GetPolicy(c.Request.Context(), policyID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// CreatePolicy adds an SLA policy
+func (h *SLAHandler) CreatePolicy(c *gin.Context) {
+	var req SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	policy := req.policy(uuid.Nil)
+	if err := h.slaService.SavePolicy(c.Request.Context(), policy); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdatePolicy updates an SLA policy
+func (h *SLAHandler) UpdatePolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	var req SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	policy := req.policy(policyID)
+	if err := h.slaService.SavePolicy(c.Request.Context(), policy); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy removes an SLA policy
+func (h *SLAHandler) DeletePolicy(c *gin.Context) {
+	policyID, ok := h.policyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.slaService.DeletePolicy(c.Request.Context(), policyID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": translate(c, "message.sla_policy_deleted")})
+}
+
+// Report summarizes how the tickets opened in a period fared against their SLA
+// targets, per queue and priority. The period defaults to the last 30 days;
+// from and to take RFC 3339 times or dates, with the end date included.
+func (h *SLAHandler) Report(c *gin.Context) {
+	from, to, queueID, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.slaService.Report(c.Request.Context(), from, to, queueID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *SLAHandler) policyID(c *gin.Context) (uuid.UUID, bool) {
+	policyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "error.invalid_sla_policy_id")
+		return uuid.Nil, false
+	}
+	return policyID, true
+}
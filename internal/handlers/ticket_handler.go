@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"callcenter/internal/middleware"
 	"callcenter/internal/models"
 	"callcenter/internal/services"
 )
@@ -26,266 +31,630 @@ func NewTicketHandler(db *gorm.DB, ticketService *services.TicketService) *Ticke
 }
 
 type CreateTicketRequest struct {
-	Subject     string `json:"subject" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	Priority    string `json:"priority" binding:"required,oneof=low medium high"`
+	BookingID   *uuid.UUID `json:"booking_id"`
+	Subject     string     `json:"subject" binding:"required"`
+	Description string     `json:"description" binding:"required"`
+	Priority    string     `json:"priority" binding:"required,oneof=low medium high"`
+	Type        string     `json:"type" binding:"omitempty,oneof=general cancellation refund change complaint baggage"`
 }
 
 func (h *TicketHandler) CreateTicket(c *gin.Context) {
 	var req CreateTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
 	userID, _ := c.Get("userID")
 
+	// A support ticket can only reference a booking the customer owns
+	if req.BookingID != nil {
+		var booking models.Booking
+		err := h.db.First(&booking, "id = ?", *req.BookingID).Error
+		if err != nil || (booking.UserID != userID.(uuid.UUID) && !models.IsStaff(middleware.CurrentUserRole(c))) {
+			respondError(c, http.StatusNotFound, "error.booking_not_found")
+			return
+		}
+	}
+
 	ticket := models.Ticket{
 		ID:          uuid.New(),
 		UserID:      userID.(uuid.UUID),
-		Number:      generateTicketNumber(), // TODO: Implement ticket number generation
+		BookingID:   req.BookingID,
 		Status:      "open",
 		Subject:     req.Subject,
 		Description: req.Description,
 		Priority:    req.Priority,
+		Type:        req.Type,
+	}
+	if ticket.Type == "" {
+		ticket.Type = models.TicketCategoryGeneral
 	}
 
-	if err := h.db.Create(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+	if err := h.ticketService.CreateTicket(c.Request.Context(), &ticket, actorFromContext(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, "error.ticket_create_failed")
 		return
 	}
 
-	// Create initial ticket history
-	history := models.TicketHistory{
-		ID:          uuid.New(),
-		TicketID:    ticket.ID,
-		Action:      "created",
-		Description: "Ticket created",
-		UserID:      userID.(uuid.UUID),
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// ListTickets returns a page of the current user's own tickets, newest first
+func (h *TicketHandler) ListTickets(c *gin.Context) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
 	}
 
-	if err := h.db.Create(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket history"})
+	actor := actorFromContext(c)
+	page, err := h.ticketService.SearchTickets(c.Request.Context(), services.TicketSearch{
+		UserID: &actor.UserID,
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}, actor)
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, ticket)
+	c.JSON(http.StatusOK, page)
 }
 
-func (h *TicketHandler) ListTickets(c *gin.Context) {
-	userID, _ := c.Get("userID")
+// SearchTickets searches the tickets the current user may see. List filters
+// take comma-separated values and date ranges take RFC 3339 times or dates,
+// with the end date included.
+func (h *TicketHandler) SearchTickets(c *gin.Context) {
+	h.search(c, services.TicketSearch{})
+}
+
+// MyTickets lists the open tickets assigned to the current agent, with the
+// filters of SearchTickets
+func (h *TicketHandler) MyTickets(c *gin.Context) {
+	h.search(c, services.TicketSearch{
+		Assignee: services.AssigneeMe,
+		Statuses: []string{"open", "in_progress", "waiting_customer"},
+	})
+}
 
-	var tickets []models.Ticket
-	if err := h.db.Where("user_id = ?", userID).Find(&tickets).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tickets"})
+// UnassignedTickets lists the open tickets nobody is assigned to, with the
+// filters of SearchTickets
+func (h *TicketHandler) UnassignedTickets(c *gin.Context) {
+	h.search(c, services.TicketSearch{
+		Assignee: services.AssigneeNone,
+		Statuses: []string{"open", "in_progress", "waiting_customer"},
+	})
+}
+
+// search runs a ticket search with the filters of the query on top of preset ones
+func (h *TicketHandler) search(c *gin.Context, preset services.TicketSearch) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
+	}
+	search, ok := ticketSearch(c, preset)
+	if !ok {
 		return
 	}
+	search.Limit = limit
 
-	c.JSON(http.StatusOK, tickets)
+	page, err := h.ticketService.SearchTickets(c.Request.Context(), search, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
 
-func (h *TicketHandler) GetTicket(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	ticketID, err := uuid.Parse(c.Param("id"))
+// ticketSearch parses the filters and order of a ticket search from the query
+// on top of preset ones. On invalid values it writes the error response and
+// returns ok=false.
+func ticketSearch(c *gin.Context, preset services.TicketSearch) (services.TicketSearch, bool) {
+	search := services.TicketSearch{
+		Number:     c.Query("number"),
+		Phone:      c.Query("phone"),
+		Email:      c.Query("email"),
+		Statuses:   queryList(c, "status"),
+		Priorities: queryList(c, "priority"),
+		Types:      queryList(c, "type"),
+		Assignee:   c.Query("assignee"),
+		Tags:       queryList(c, "tag"),
+		SLA:        c.Query("sla"),
+		Text:       c.Query("q"),
+		Sort:       c.Query("sort"),
+		Cursor:     c.Query("cursor"),
+	}
+	if preset.Assignee != "" {
+		search.Assignee = preset.Assignee
+	}
+	if len(search.Statuses) == 0 {
+		search.Statuses = preset.Statuses
+	}
+	if value := c.Query("queue_id"); value != "" {
+		queueID, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_queue_id")
+			return search, false
+		}
+		search.QueueID = &queueID
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		search.Ascending = true
+	case "desc":
+	default:
+		respondError(c, http.StatusBadRequest, "error.invalid_sort_order")
+		return search, false
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_user_id")
+			return search, false
+		}
+		search.UserID = &userID
+	}
+	for _, r := range []struct {
+		param string
+		end   bool
+		dest  **time.Time
+	}{
+		{"created_from", false, &search.CreatedFrom},
+		{"created_to", true, &search.CreatedTo},
+		{"updated_from", false, &search.UpdatedFrom},
+		{"updated_to", true, &search.UpdatedTo},
+	} {
+		value := c.Query(r.param)
+		if value == "" {
+			continue
+		}
+		t, err := parseQueryTime(value, r.end)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_date")
+			return search, false
+		}
+		*r.dest = &t
+	}
+	return search, true
+}
+
+// queryList splits a comma-separated query parameter, dropping empty values
+func queryList(c *gin.Context, param string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(param), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// reportDays is the period reports cover when no start is given
+const reportDays = 30
+
+// reportQuery reads the period of a report from the from and to query
+// parameters, by default the reportDays up to now, and the queue_id the report
+// is limited to, if any
+func reportQuery(c *gin.Context) (from, to time.Time, queueID *uuid.UUID, ok bool) {
+	to = time.Now()
+	if value := c.Query("to"); value != "" {
+		t, err := parseQueryTime(value, true)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_date")
+			return from, to, nil, false
+		}
+		to = t
+	}
+	from = to.AddDate(0, 0, -reportDays)
+	if value := c.Query("from"); value != "" {
+		t, err := parseQueryTime(value, false)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_date")
+			return from, to, nil, false
+		}
+		from = t
+	}
+
+	if value := c.Query("queue_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "error.invalid_queue_id")
+			return from, to, nil, false
+		}
+		queueID = &id
+	}
+	return from, to, queueID, true
+}
+
+// parseQueryTime parses an RFC 3339 time or a date. A date at the end of a
+// range stands for the whole day, so it parses to the start of the next day.
+func parseQueryTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// GetTicket retrieves a ticket with its booking by ID or ticket number. A
+// merged ticket redirects to the ticket it was merged into.
+func (h *TicketHandler) GetTicket(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
-	var ticket models.Ticket
-	if err := h.db.Where("id = ? AND user_id = ?", ticketID, userID).First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+	if ref := strings.TrimSpace(c.Param("id")); ref != ticket.ID.String() && !strings.EqualFold(ref, ticket.Number) {
+		c.Redirect(http.StatusPermanentRedirect, "/api/v1/tickets/"+url.PathEscape(ticket.Number))
 		return
 	}
 
 	c.JSON(http.StatusOK, ticket)
 }
 
-type UpdateStatusRequest struct {
-	Status      string `json:"status" binding:"required,oneof=open in_progress resolved closed"`
-	Description string `json:"description"`
+// GetDuplicates lists the likely duplicates of a ticket
+func (h *TicketHandler) GetDuplicates(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	duplicates, err := h.ticketService.FindDuplicates(c.Request.Context(), ticket)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.tickets_fetch_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"duplicates": duplicates})
 }
 
-func (h *TicketHandler) UpdateTicketStatus(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	ticketID, err := uuid.Parse(c.Param("id"))
+// MergeTicketsRequest represents the request body for merging duplicate
+// tickets into a ticket
+type MergeTicketsRequest struct {
+	DuplicateIDs []uuid.UUID `json:"duplicate_ids" binding:"required,min=1"`
+}
+
+// MergeTickets merges duplicate tickets into a ticket
+func (h *TicketHandler) MergeTickets(c *gin.Context) {
+	var req MergeTicketsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.ticketService.MergeTickets(c.Request.Context(), ticket.ID, req.DuplicateIDs, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GetTicketsByPhone retrieves the tickets whose booking was made with a contact
+// phone number. Customers only find their own tickets.
+func (h *TicketHandler) GetTicketsByPhone(c *gin.Context) {
+	tickets, err := h.ticketService.GetTicketByPhone(c.Request.Context(), c.Param("phone"), actorFromContext(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		respondError(c, http.StatusInternalServerError, "error.tickets_fetch_failed")
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"tickets": tickets})
+}
+
+// UpdateStatusRequest represents the request body for moving a ticket to a new
+// status for a reason code; without one the usual reason for the change is
+// recorded. Resolved and closed tickets have to be reopened, moved to open,
+// before anything else. While a ticket is waiting_customer its SLA clock is
+// paused.
+type UpdateStatusRequest struct {
+	Status      string `json:"status" binding:"required,oneof=open in_progress waiting_customer resolved closed"`
+	Reason      string `json:"reason" binding:"omitempty,oneof=working awaiting_info customer_replied solved no_response duplicate reopened other"`
+	Description string `json:"description"`
+}
+
+// UpdateTicketStatus moves a ticket to a new status
+func (h *TicketHandler) UpdateTicketStatus(c *gin.Context) {
 	var req UpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	// Verify ticket exists and belongs to user
-	var ticket models.Ticket
-	if err := h.db.Where("id = ? AND user_id = ?", ticketID, userID).First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
-	// Update ticket status
-	ticket.Status = req.Status
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ticket status"})
+	ticket, err := h.ticketService.UpdateStatus(c.Request.Context(), ticket.ID, req.Status, req.Reason, req.Description, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
-	// Create status history entry
-	history := models.TicketHistory{
-		ID:          uuid.New(),
-		TicketID:    ticketID,
-		Action:      "status_updated",
-		Description: req.Description,
-		UserID:      userID.(uuid.UUID),
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GetTicketTimeline returns the status timeline of a ticket with the time it
+// spent in each status
+func (h *TicketHandler) GetTicketTimeline(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
 	}
 
-	if err := h.db.Create(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket history"})
+	timeline, err := h.ticketService.Timeline(c.Request.Context(), ticket, time.Now())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "error.ticket_timeline_fetch_failed")
 		return
 	}
 
-	c.JSON(http.StatusOK, ticket)
+	c.JSON(http.StatusOK, timeline)
 }
 
-func (h *TicketHandler) GetTicketHistory(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	ticketID, err := uuid.Parse(c.Param("id"))
+// TimeInStatusReport reports how long the tickets opened in a period, from
+// and to (30 days up to now by default), optionally of one queue_id, spent in
+// each status
+func (h *TicketHandler) TimeInStatusReport(c *gin.Context) {
+	from, to, queueID, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.ticketService.TimeInStatus(c.Request.Context(), from, to, queueID, time.Now())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		respondServiceError(c, err)
 		return
 	}
 
-	// Verify ticket exists and belongs to user
-	var ticket models.Ticket
-	if err := h.db.Where("id = ? AND user_id = ?", ticketID, userID).First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *TicketHandler) GetTicketHistory(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
 	var history []models.TicketHistory
-	if err := h.db.Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticket history"})
+	if err := h.db.Where("ticket_id = ?", ticket.ID).Order("created_at asc").Find(&history).Error; err != nil {
+		respondError(c, http.StatusInternalServerError, "error.ticket_history_fetch_failed")
 		return
 	}
 
 	c.JSON(http.StatusOK, history)
 }
 
-// Helper function to generate ticket numbers
-func generateTicketNumber() string {
-	// TODO: Implement proper ticket number generation
-	return "TKT-" + uuid.New().String()[:8]
-}
-
 // CancelTicketRequest represents the request body for cancelling a ticket
 type CancelTicketRequest struct {
-	Reason string `json:"reason" binding:"required"`
+	QuoteToken uuid.UUID `json:"quote_token" binding:"required"` // from the refund quote the customer accepted
+	Reason     string    `json:"reason" binding:"required"`
 }
 
-// CancelTicket handles ticket cancellation requests
+// QuoteCancellation quotes the refund for cancelling the booking of a support ticket.
+// The returned quote token must be sent back to CancelTicket before it expires.
+func (h *TicketHandler) QuoteCancellation(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	selection, ok := bindRefundSelection(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.ticketService.QuoteCancellation(c.Request.Context(), ticket.Number, selection, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quoteResponse(quote))
+}
+
+// CancelTicket cancels the booking of a support ticket and opens a refund request for it
 func (h *TicketHandler) CancelTicket(c *gin.Context) {
-	ticketNumber := c.Param("ticketNumber")
-	if ticketNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticket number is required"})
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
 	var req CancelTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	// Get ticket from database
-	var ticket models.Ticket
-	if err := h.db.Where("ticket_number = ?", ticketNumber).First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+	refundRequest, err := h.ticketService.CancelTicket(c.Request.Context(), ticket.Number, req.QuoteToken, req.Reason, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
-	// Update ticket status to cancelled
-	if err := h.db.Model(&ticket).Updates(map[string]interface{}{
-		"status":              "cancelled",
-		"cancellation_reason": req.Reason,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel ticket"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "message.ticket_cancellation_submitted"),
+		"refund_request": refundRequest,
+	})
+}
+
+// ListRefundRequests lists the refund requests of the booking of a support ticket, newest first
+func (h *TicketHandler) ListRefundRequests(c *gin.Context) {
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
-	// Create ticket history entry
-	history := models.TicketHistory{
-		TicketID:    ticket.ID,
-		Action:      "cancelled",
-		Description: req.Reason,
-	}
-	if err := h.db.Create(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record ticket history"})
+	refundRequests, err := h.ticketService.ListRefundRequests(c.Request.Context(), ticket.Number)
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Ticket cancellation request submitted successfully",
+		"refund_requests": refundResponses(refundRequests),
 	})
 }
 
-// GetRefundStatus retrieves the refund status for a ticket
-func (h *TicketHandler) GetRefundStatus(c *gin.Context) {
-	ticketNumber := c.Param("ticketNumber")
-	if ticketNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticket number is required"})
+// AssignTicketRequest represents the request body for assigning a ticket.
+// Set queue_id to move the ticket to another queue, and agent_id to assign it,
+// auto to let its queue pick an agent, or unassign to return it to its queue.
+type AssignTicketRequest struct {
+	QueueID  *uuid.UUID `json:"queue_id"`
+	AgentID  *uuid.UUID `json:"agent_id"`
+	Auto     bool       `json:"auto"`
+	Unassign bool       `json:"unassign"`
+	Note     string     `json:"note"`
+}
+
+// AssignTicket moves a ticket between queues and agents
+func (h *TicketHandler) AssignTicket(c *gin.Context) {
+	var req AssignTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
 		return
 	}
 
-	// Get ticket from database
-	var ticket models.Ticket
-	if err := h.db.Where("ticket_number = ?", ticketNumber).First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+	ticket, ok := h.ticketParam(c)
+	if !ok {
 		return
 	}
 
-	// Get refund request from database
-	var refundRequest models.RefundRequest
-	if err := h.db.Where("ticket_id = ?", ticket.ID).First(&refundRequest).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No refund request found for this ticket"})
+	ticket, err := h.ticketService.AssignTicket(c.Request.Context(), ticket.ID, services.Assignment{
+		QueueID:  req.QueueID,
+		AgentID:  req.AgentID,
+		Auto:     req.Auto,
+		Unassign: req.Unassign,
+		Note:     req.Note,
+	}, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"refund_request": refundRequest,
-	})
+	c.JSON(http.StatusOK, ticket)
 }
 
-// UpdateRefundStatusRequest represents the request body for updating refund status
+// TagTicketRequest represents the request body for changing the tags of a ticket
+type TagTicketRequest struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// TagTicket adds and removes tags of a ticket
+func (h *TicketHandler) TagTicket(c *gin.Context) {
+	var req TagTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.ticketService.TagTicket(c.Request.Context(), ticket.ID, req.Add, req.Remove, actorFromContext(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// UpdateRefundStatusRequest represents the request body for moving a refund to a new status.
+// Rejections and failures need a reason.
 type UpdateRefundStatusRequest struct {
-	Status      string `json:"status" binding:"required"`
-	ProcessedBy string `json:"processed_by" binding:"required"`
+	Status string `json:"status" binding:"required,oneof=approved rejected processing processed failed"`
+	Reason string `json:"reason"`
 }
 
-// UpdateRefundStatus updates the refund status for a ticket
+// UpdateRefundStatus moves a refund request of the booking of a support ticket to a new status
 func (h *TicketHandler) UpdateRefundStatus(c *gin.Context) {
-	ticketNumber := c.Param("ticketNumber")
-	if ticketNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticket number is required"})
+	refundRequestID, ok := refundRequestParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRefundStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	refundRequest, err := h.ticketService.UpdateRefundStatus(c.Request.Context(), ticket.Number, refundRequestID, req.Status, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "message.refund_status_updated"),
+		"refund_request": refundRequest,
+	})
+}
+
+// UpdateLatestRefundStatus moves the latest refund request of the booking of a
+// support ticket to a new status. The processed_by field older clients send is
+// ignored; the authenticated user is recorded.
+func (h *TicketHandler) UpdateLatestRefundStatus(c *gin.Context) {
 	var req UpdateRefundStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	err := h.ticketService.UpdateRefundStatus(c.Request.Context(), ticketNumber, req.Status, req.ProcessedBy)
+	ticket, ok := h.ticketParam(c)
+	if !ok {
+		return
+	}
+
+	refundRequest, err := h.ticketService.UpdateLatestRefundStatus(c.Request.Context(), ticket.Number, req.Status, actorFromContext(c), req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Refund status updated successfully",
+		"message":        translate(c, "message.refund_status_updated"),
+		"refund_request": refundRequest,
 	})
 }
+
+// ticketParam loads the ticket in the :id parameter; see findTicketParam
+func (h *TicketHandler) ticketParam(c *gin.Context) (*models.Ticket, bool) {
+	return findTicketParam(c, h.ticketService)
+}
+
+// findTicketParam loads the ticket in the :id parameter, an ID or a ticket
+// number. Customers only find their own tickets, staff find all.
+func findTicketParam(c *gin.Context, ticketService *services.TicketService) (*models.Ticket, bool) {
+	ticket, err := ticketService.FindTicket(c.Request.Context(), c.Param("id"), actorFromContext(c))
+	if errors.Is(err, services.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "error.ticket_not_found")
+		return nil, false
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return nil, false
+	}
+	return ticket, true
+}
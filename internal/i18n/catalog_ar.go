@@ -0,0 +1,80 @@
+package i18n
+
+// arabic covers customer-facing texts; staff-only messages fall back to English
+var arabic = map[string]string{
+	// Authentication
+	"error.auth_header_required":     "ترويسة Authorization مطلوبة",
+	"error.auth_header_invalid":      "صيغة ترويسة Authorization غير صالحة",
+	"error.invalid_token":            "الرمز غير صالح",
+	"error.invalid_token_claims":     "بيانات الرمز غير صالحة",
+	"error.insufficient_permissions": "ليست لديك صلاحية كافية",
+	"error.unauthorized":             "غير مصرح",
+	"error.invalid_credentials":      "بيانات الدخول غير صحيحة",
+	"error.user_not_found":           "المستخدم غير موجود",
+
+	// Generic
+	"error.invalid_request": "الطلب غير صالح",
+	"error.not_found":       "العنصر المطلوب غير موجود",
+	"error.internal":        "حدث خطأ، يرجى المحاولة لاحقاً",
+	"error.conflict":        "الطلب يتعارض مع الحالة الحالية",
+
+	// Chat
+	"error.invalid_session_id":       "معرّف المحادثة غير صالح",
+	"error.session_not_found":        "المحادثة غير موجودة",
+	"error.message_process_failed":   "تعذرت معالجة الرسالة",
+	"error.message_save_failed":      "تعذر حفظ الرسالة",
+	"error.response_generate_failed": "تعذر إنشاء الرد",
+	"error.unsupported_language":     "اللغة غير مدعومة",
+
+	// Tickets
+	"error.ticket_not_found":       "التذكرة غير موجودة",
+	"error.ticket_number_required": "رقم التذكرة مطلوب",
+	"error.ticket_cancel_failed":   "تعذر إلغاء التذكرة",
+	"error.refund_not_found":       "لا يوجد طلب استرداد لهذه التذكرة",
+
+	// Bookings
+	"error.invalid_booking_id": "معرّف الحجز غير صالح",
+	"error.booking_not_found":  "الحجز غير موجود",
+
+	// Knowledge base
+	"error.article_not_found": "المقال غير موجود",
+	"error.query_required":    "عبارة البحث مطلوبة",
+
+	// Confirmations
+	"message.ticket_cancellation_submitted":  "تم تقديم طلب إلغاء التذكرة بنجاح",
+	"message.booking_cancellation_submitted": "تم إلغاء الحجز وتقديم طلب الاسترداد",
+	"message.session_escalated":              "تم تحويل المحادثة إلى موظف الدعم",
+	"message.language_updated":               "تم تغيير لغة المحادثة",
+
+	// Bot replies
+	"bot.ticket_lookup":       "يمكنني مساعدتك في العثور على تذكرتك. من فضلك أرسل رقم التذكرة أو رمز الحجز.",
+	"bot.ticket_cancellation": "يمكنني مساعدتك في إلغاء تذكرتك. من فضلك أرسل رقم التذكرة.",
+	"bot.refund_inquiry":      "يمكنني التحقق من حالة الاسترداد. من فضلك أرسل رقم التذكرة.",
+	"bot.baggage_policy":      "يمكنني مساعدتك بمعلومات الأمتعة المسموح بها. مع أي شركة طيران تسافر؟",
+	"bot.check_in":            "يمكنني مساعدتك في إجراءات تسجيل الوصول. مع أي شركة طيران تسافر؟",
+	"bot.agent_request":       "جارٍ تحويلك إلى موظف الدعم. يرجى البقاء في هذه المحادثة.",
+	"bot.ticket_reply":        "رد الدعم على التذكرة {ticket_number}:\n{reply}",
+	"bot.agent_unavailable":   "موظفو الدعم غير متاحين حالياً وسيعودون في {date} الساعة {time}. اترك سؤالك هنا وسيرد عليك أحد الموظفين حينها.",
+	"bot.fallback":            "لم أفهم طلبك. هل يمكنك إعادة صياغة سؤالك؟",
+
+	"bot.article_answer": "{title}\n{excerpt}\n\nللمزيد: {url}",
+
+	"bot.invalid_intent.refund_not_cancelled": "عذراً، التذكرة {ticket_number} لم تُلغَ، لذلك لا يوجد استرداد للتحقق منه.",
+	"bot.invalid_intent.already_cancelled":    "عذراً، التذكرة {ticket_number} ملغاة بالفعل.",
+
+	// Suggestion chips
+	"suggestion.ticket_lookup":       "البحث عن تذكرتي",
+	"suggestion.ticket_cancellation": "إلغاء تذكرتي",
+	"suggestion.refund_inquiry":      "حالة الاسترداد",
+	"suggestion.baggage_policy":      "الأمتعة المسموح بها",
+	"suggestion.check_in":            "تسجيل الوصول عبر الإنترنت",
+	"suggestion.agent_request":       "التحدث مع موظف",
+
+	// Labeling queue
+	"message.labeling_queue.zero":  "لا توجد رسائل بانتظار المراجعة",
+	"message.labeling_queue.one":   "رسالة واحدة بانتظار المراجعة",
+	"message.labeling_queue.two":   "رسالتان بانتظار المراجعة",
+	"message.labeling_queue.few":   "{count} رسائل بانتظار المراجعة",
+	"message.labeling_queue.many":  "{count} رسالة بانتظار المراجعة",
+	"message.labeling_queue.other": "{count} رسالة بانتظار المراجعة",
+}
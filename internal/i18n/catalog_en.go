@@ -0,0 +1,149 @@
+package i18n
+
+var english = map[string]string{
+	// Authentication
+	"error.auth_header_required":     "Authorization header is required",
+	"error.auth_header_invalid":      "Invalid authorization header format",
+	"error.invalid_token":            "Invalid token",
+	"error.invalid_token_claims":     "Invalid token claims",
+	"error.insufficient_permissions": "Insufficient permissions",
+	"error.unauthorized":             "Unauthorized",
+	"error.invalid_credentials":      "Invalid credentials",
+	"error.user_not_found":           "User not found",
+	"error.token_generation_failed":  "Failed to generate token",
+	"error.password_hash_failed":     "Failed to hash password",
+	"error.user_create_failed":       "Failed to create user",
+
+	// Generic
+	"error.invalid_request": "Invalid request",
+	"error.not_found":       "The requested resource was not found",
+	"error.internal":        "Something went wrong, please try again later",
+	"error.conflict":        "The request conflicts with the current state of the resource",
+
+	// Chat
+	"error.invalid_session_id":       "Invalid session ID",
+	"error.session_not_found":        "Session not found",
+	"error.session_create_failed":    "Failed to create session",
+	"error.sessions_fetch_failed":    "Failed to fetch sessions",
+	"error.messages_fetch_failed":    "Failed to fetch messages",
+	"error.chat_history_failed":      "Failed to get chat history",
+	"error.message_process_failed":   "Failed to process message",
+	"error.message_save_failed":      "Failed to save message",
+	"error.response_generate_failed": "Failed to generate response",
+	"error.response_save_failed":     "Failed to save bot response",
+	"error.escalate_failed":          "Failed to escalate session",
+	"error.unsupported_language":     "Unsupported language",
+	"error.language_update_failed":   "Failed to update session language",
+
+	// Tickets
+	"error.invalid_ticket_id":            "Invalid ticket ID",
+	"error.ticket_not_found":             "Ticket not found",
+	"error.ticket_number_required":       "Ticket number is required",
+	"error.ticket_create_failed":         "Failed to create ticket",
+	"error.ticket_history_create_failed": "Failed to create ticket history",
+	"error.tickets_fetch_failed":         "Failed to fetch tickets",
+	"error.ticket_history_fetch_failed":  "Failed to fetch ticket history",
+	"error.ticket_timeline_fetch_failed": "Failed to fetch ticket timeline",
+	"error.ticket_status_update_failed":  "Failed to update ticket status",
+	"error.ticket_cancel_failed":         "Failed to cancel ticket",
+	"error.refund_not_found":             "No refund request found for this ticket",
+	"error.invalid_sort_order":           "Invalid sort order",
+	"error.invalid_date":                 "Invalid date",
+	"error.invalid_user_id":              "Invalid user ID",
+
+	// Queues
+	"error.invalid_queue_id":    "Invalid queue ID",
+	"error.queues_fetch_failed": "Failed to fetch queues",
+
+	// SLA policies
+	"error.invalid_sla_policy_id":     "Invalid SLA policy ID",
+	"error.sla_policies_fetch_failed": "Failed to fetch SLA policies",
+
+	// Calendars
+	"error.invalid_calendar_id":    "Invalid calendar ID",
+	"error.invalid_holiday_id":     "Invalid holiday ID",
+	"error.invalid_minutes":        "Invalid number of minutes",
+	"error.calendars_fetch_failed": "Failed to fetch calendars",
+
+	// Comments
+	"error.invalid_comment_id":    "Invalid comment ID",
+	"error.invalid_attachment_id": "Invalid attachment ID",
+	"error.comments_fetch_failed": "Failed to fetch comments",
+	"error.file_required":         "A file is required",
+	"error.file_too_large":        "The file is too large",
+
+	// Bulk jobs
+	"error.invalid_job_id":          "Invalid bulk job ID",
+	"error.invalid_job_item_status": "Invalid result status",
+	"error.bulk_jobs_fetch_failed":  "Failed to fetch bulk jobs",
+
+	// Bookings
+	"error.invalid_booking_id":    "Invalid booking ID",
+	"error.invalid_refund_id":     "Invalid refund request ID",
+	"error.booking_not_found":     "Booking not found",
+	"error.bookings_fetch_failed": "Failed to fetch bookings",
+
+	// Refund policies
+	"error.invalid_refund_policy_id":     "Invalid refund policy ID",
+	"error.refund_policies_fetch_failed": "Failed to fetch refund policies",
+
+	// Payments
+	"error.invalid_payment_signature": "Invalid payment callback signature",
+
+	// Labeling
+	"error.invalid_task_id":             "Invalid task ID",
+	"error.invalid_limit":               "Invalid limit",
+	"error.invalid_offset":              "Invalid offset",
+	"error.intent_required":             "Intent is required",
+	"error.labeling_tasks_fetch_failed": "Failed to fetch labeling tasks",
+	"error.labels_export_failed":        "Failed to export labels",
+
+	// Knowledge base
+	"error.invalid_article_id":    "Invalid article ID",
+	"error.article_not_found":     "Article not found",
+	"error.articles_fetch_failed": "Failed to fetch articles",
+	"error.query_required":        "Search query is required",
+
+	// Confirmations
+	"message.labeling_task_skipped":          "Labeling task skipped",
+	"message.ticket_cancellation_submitted":  "Ticket cancellation request submitted successfully",
+	"message.booking_cancellation_submitted": "Booking cancelled and refund requested",
+	"message.refund_status_updated":          "Refund status updated successfully",
+	"message.session_escalated":              "Session escalated to human support",
+	"message.language_updated":               "Session language updated",
+	"message.article_deleted":                "Article deleted",
+	"message.refund_policy_deleted":          "Refund policy deleted",
+	"message.queue_member_added":             "Agent added to the queue",
+	"message.queue_member_removed":           "Agent removed from the queue",
+	"message.sla_policy_deleted":             "SLA policy deleted",
+	"message.calendar_deleted":               "Calendar deleted",
+	"message.holiday_removed":                "Holiday removed",
+
+	// Bot replies
+	"bot.ticket_lookup":       "I can help you find your ticket. Could you please provide your ticket number or booking reference?",
+	"bot.ticket_cancellation": "I can help you cancel your ticket. Could you please provide your ticket number?",
+	"bot.refund_inquiry":      "I can help you check your refund status. Could you please provide your ticket number?",
+	"bot.baggage_policy":      "I can help you with baggage policy information. Which airline are you flying with?",
+	"bot.check_in":            "I can help you with check-in. Which airline are you flying with?",
+	"bot.agent_request":       "I'm connecting you with a support agent. Please stay in this chat.",
+	"bot.ticket_reply":        "Reply from support on ticket {ticket_number}:\n{reply}",
+	"bot.agent_unavailable":   "Our support agents are away right now and will be back on {date} at {time}. Leave your question here and an agent will get back to you then.",
+	"bot.fallback":            "I'm not sure I understand. Could you please rephrase your question?",
+
+	"bot.article_answer": "{title}\n{excerpt}\n\nRead more: {url}",
+
+	"bot.invalid_intent.refund_not_cancelled": "Sorry, ticket {ticket_number} has not been cancelled, so there is no refund to check.",
+	"bot.invalid_intent.already_cancelled":    "Sorry, ticket {ticket_number} is already cancelled.",
+
+	// Suggestion chips
+	"suggestion.ticket_lookup":       "Find my ticket",
+	"suggestion.ticket_cancellation": "Cancel my ticket",
+	"suggestion.refund_inquiry":      "Check refund status",
+	"suggestion.baggage_policy":      "Baggage allowance",
+	"suggestion.check_in":            "Online check-in",
+	"suggestion.agent_request":       "Talk to an agent",
+
+	// Labeling queue
+	"message.labeling_queue.one":   "{count} message is waiting for review",
+	"message.labeling_queue.other": "{count} messages are waiting for review",
+}
@@ -0,0 +1,149 @@
+package i18n
+
+var persian = map[string]string{
+	// Authentication
+	"error.auth_header_required":     "هدر Authorization الزامی است",
+	"error.auth_header_invalid":      "قالب هدر Authorization نامعتبر است",
+	"error.invalid_token":            "توکن نامعتبر است",
+	"error.invalid_token_claims":     "اطلاعات توکن نامعتبر است",
+	"error.insufficient_permissions": "دسترسی کافی ندارید",
+	"error.unauthorized":             "احراز هویت نشده‌اید",
+	"error.invalid_credentials":      "نام کاربری یا رمز عبور اشتباه است",
+	"error.user_not_found":           "کاربر پیدا نشد",
+	"error.token_generation_failed":  "ساخت توکن با خطا مواجه شد",
+	"error.password_hash_failed":     "پردازش رمز عبور با خطا مواجه شد",
+	"error.user_create_failed":       "ایجاد کاربر با خطا مواجه شد",
+
+	// Generic
+	"error.invalid_request": "درخواست نامعتبر است",
+	"error.not_found":       "مورد درخواستی پیدا نشد",
+	"error.internal":        "خطایی رخ داد، لطفاً بعداً دوباره تلاش کنید",
+	"error.conflict":        "درخواست با وضعیت فعلی مورد تداخل دارد",
+
+	// Chat
+	"error.invalid_session_id":       "شناسه گفتگو نامعتبر است",
+	"error.session_not_found":        "گفتگو پیدا نشد",
+	"error.session_create_failed":    "ایجاد گفتگو با خطا مواجه شد",
+	"error.sessions_fetch_failed":    "دریافت گفتگوها با خطا مواجه شد",
+	"error.messages_fetch_failed":    "دریافت پیام‌ها با خطا مواجه شد",
+	"error.chat_history_failed":      "دریافت تاریخچه گفتگو با خطا مواجه شد",
+	"error.message_process_failed":   "پردازش پیام با خطا مواجه شد",
+	"error.message_save_failed":      "ذخیره پیام با خطا مواجه شد",
+	"error.response_generate_failed": "تولید پاسخ با خطا مواجه شد",
+	"error.response_save_failed":     "ذخیره پاسخ با خطا مواجه شد",
+	"error.escalate_failed":          "ارجاع گفتگو به پشتیبان با خطا مواجه شد",
+	"error.unsupported_language":     "این زبان پشتیبانی نمی‌شود",
+	"error.language_update_failed":   "تغییر زبان گفتگو با خطا مواجه شد",
+
+	// Tickets
+	"error.invalid_ticket_id":            "شناسه تیکت نامعتبر است",
+	"error.ticket_not_found":             "بلیط پیدا نشد",
+	"error.ticket_number_required":       "شماره بلیط الزامی است",
+	"error.ticket_create_failed":         "ایجاد تیکت با خطا مواجه شد",
+	"error.ticket_history_create_failed": "ثبت تاریخچه تیکت با خطا مواجه شد",
+	"error.tickets_fetch_failed":         "دریافت تیکت‌ها با خطا مواجه شد",
+	"error.ticket_history_fetch_failed":  "دریافت تاریخچه تیکت با خطا مواجه شد",
+	"error.ticket_timeline_fetch_failed": "دریافت روند وضعیت تیکت با خطا مواجه شد",
+	"error.ticket_status_update_failed":  "تغییر وضعیت تیکت با خطا مواجه شد",
+	"error.ticket_cancel_failed":         "لغو بلیط با خطا مواجه شد",
+	"error.refund_not_found":             "درخواست استردادی برای این بلیط ثبت نشده است",
+	"error.invalid_sort_order":           "ترتیب مرتب‌سازی نامعتبر است",
+	"error.invalid_date":                 "تاریخ نامعتبر است",
+	"error.invalid_user_id":              "شناسه کاربر نامعتبر است",
+
+	// Queues
+	"error.invalid_queue_id":    "شناسه صف نامعتبر است",
+	"error.queues_fetch_failed": "دریافت صف‌ها با خطا مواجه شد",
+
+	// SLA policies
+	"error.invalid_sla_policy_id":     "شناسه سیاست SLA نامعتبر است",
+	"error.sla_policies_fetch_failed": "دریافت سیاست‌های SLA با خطا مواجه شد",
+
+	// Calendars
+	"error.invalid_calendar_id":    "شناسه تقویم نامعتبر است",
+	"error.invalid_holiday_id":     "شناسه تعطیلی نامعتبر است",
+	"error.invalid_minutes":        "تعداد دقیقه نامعتبر است",
+	"error.calendars_fetch_failed": "دریافت تقویم‌ها با خطا مواجه شد",
+
+	// Comments
+	"error.invalid_comment_id":    "شناسه نظر نامعتبر است",
+	"error.invalid_attachment_id": "شناسه پیوست نامعتبر است",
+	"error.comments_fetch_failed": "دریافت نظرها با خطا مواجه شد",
+	"error.file_required":         "ارسال فایل الزامی است",
+	"error.file_too_large":        "حجم فایل بیش از حد مجاز است",
+
+	// Bulk jobs
+	"error.invalid_job_id":          "شناسه عملیات گروهی نامعتبر است",
+	"error.invalid_job_item_status": "وضعیت نتیجه نامعتبر است",
+	"error.bulk_jobs_fetch_failed":  "دریافت عملیات‌های گروهی با خطا مواجه شد",
+
+	// Bookings
+	"error.invalid_booking_id":    "شناسه رزرو نامعتبر است",
+	"error.invalid_refund_id":     "شناسه درخواست استرداد نامعتبر است",
+	"error.booking_not_found":     "رزرو پیدا نشد",
+	"error.bookings_fetch_failed": "دریافت رزروها با خطا مواجه شد",
+
+	// Refund policies
+	"error.invalid_refund_policy_id":     "شناسه سیاست استرداد نامعتبر است",
+	"error.refund_policies_fetch_failed": "دریافت سیاست‌های استرداد با خطا مواجه شد",
+
+	// Payments
+	"error.invalid_payment_signature": "امضای پیام درگاه پرداخت نامعتبر است",
+
+	// Labeling
+	"error.invalid_task_id":             "شناسه مورد بازبینی نامعتبر است",
+	"error.invalid_limit":               "مقدار limit نامعتبر است",
+	"error.invalid_offset":              "مقدار offset نامعتبر است",
+	"error.intent_required":             "تعیین نیت کاربر الزامی است",
+	"error.labeling_tasks_fetch_failed": "دریافت صف بازبینی با خطا مواجه شد",
+	"error.labels_export_failed":        "خروجی گرفتن از برچسب‌ها با خطا مواجه شد",
+
+	// Knowledge base
+	"error.invalid_article_id":    "شناسه مقاله نامعتبر است",
+	"error.article_not_found":     "مقاله پیدا نشد",
+	"error.articles_fetch_failed": "دریافت مقاله‌ها با خطا مواجه شد",
+	"error.query_required":        "عبارت جستجو الزامی است",
+
+	// Confirmations
+	"message.labeling_task_skipped":          "مورد بازبینی رد شد",
+	"message.ticket_cancellation_submitted":  "درخواست لغو بلیط با موفقیت ثبت شد",
+	"message.booking_cancellation_submitted": "رزرو لغو شد و درخواست استرداد ثبت شد",
+	"message.refund_status_updated":          "وضعیت استرداد با موفقیت به‌روز شد",
+	"message.session_escalated":              "گفتگو به پشتیبان انسانی ارجاع شد",
+	"message.language_updated":               "زبان گفتگو تغییر کرد",
+	"message.article_deleted":                "مقاله حذف شد",
+	"message.refund_policy_deleted":          "سیاست استرداد حذف شد",
+	"message.queue_member_added":             "کارشناس به صف اضافه شد",
+	"message.queue_member_removed":           "کارشناس از صف حذف شد",
+	"message.sla_policy_deleted":             "سیاست SLA حذف شد",
+	"message.calendar_deleted":               "تقویم حذف شد",
+	"message.holiday_removed":                "تعطیلی حذف شد",
+
+	// Bot replies
+	"bot.ticket_lookup":       "می‌توانم در پیدا کردن بلیطتان کمک کنم. لطفاً شماره بلیط یا کد رزرو را بفرمایید.",
+	"bot.ticket_cancellation": "می‌توانم در لغو بلیطتان کمک کنم. لطفاً شماره بلیط را بفرمایید.",
+	"bot.refund_inquiry":      "می‌توانم وضعیت استرداد را بررسی کنم. لطفاً شماره بلیط را بفرمایید.",
+	"bot.baggage_policy":      "می‌توانم درباره بار مجاز راهنمایی کنم. با کدام ایرلاین سفر می‌کنید؟",
+	"bot.check_in":            "می‌توانم درباره پذیرش پرواز راهنمایی کنم. با کدام ایرلاین سفر می‌کنید؟",
+	"bot.agent_request":       "در حال اتصال شما به کارشناس پشتیبانی هستم. لطفاً در همین گفتگو بمانید.",
+	"bot.ticket_reply":        "پاسخ پشتیبانی به تیکت {ticket_number}:\n{reply}",
+	"bot.agent_unavailable":   "کارشناسان پشتیبانی در حال حاضر در دسترس نیستند و از {date} ساعت {time} پاسخگو خواهند بود. سؤال خود را همین‌جا بنویسید تا کارشناس در اولین فرصت پاسخ دهد.",
+	"bot.fallback":            "متوجه منظورتان نشدم. لطفاً سؤالتان را به شکل دیگری بپرسید.",
+
+	"bot.article_answer": "{title}\n{excerpt}\n\nاطلاعات بیشتر: {url}",
+
+	"bot.invalid_intent.refund_not_cancelled": "متأسفم، بلیط {ticket_number} لغو نشده است، بنابراین استردادی برای بررسی وجود ندارد.",
+	"bot.invalid_intent.already_cancelled":    "متأسفم، بلیط {ticket_number} قبلاً لغو شده است.",
+
+	// Suggestion chips
+	"suggestion.ticket_lookup":       "پیدا کردن بلیط",
+	"suggestion.ticket_cancellation": "لغو بلیط",
+	"suggestion.refund_inquiry":      "وضعیت استرداد",
+	"suggestion.baggage_policy":      "بار مجاز",
+	"suggestion.check_in":            "پذیرش آنلاین",
+	"suggestion.agent_request":       "گفتگو با کارشناس",
+
+	// Labeling queue
+	"message.labeling_queue.one":   "{count} پیام در انتظار بازبینی است",
+	"message.labeling_queue.other": "{count} پیام در انتظار بازبینی است",
+}
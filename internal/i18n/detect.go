@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+// persianLetters do not exist in Arabic
+var persianLetters = map[rune]bool{'پ': true, 'چ': true, 'ژ': true, 'گ': true, 'ک': true, 'ی': true}
+
+// arabicLetters are Arabic spellings that Persian text does not use
+var arabicLetters = map[rune]bool{'ة': true, 'ي': true, 'ك': true, 'ى': true, 'إ': true, 'أ': true}
+
+// DetectLanguage guesses the language of a message from its script. Arabic-script
+// text is Persian unless it only uses Arabic-specific letters. It returns an empty
+// string when the text has too few letters to tell, e.g. a bare ticket number.
+func DetectLanguage(text string) string {
+	var latin, arabicScript, persianOnly, arabicOnly int
+	for _, r := range text {
+		switch {
+		case r >= 0x0600 && r <= 0x06FF || r >= 0xFB50 && r <= 0xFEFF:
+			arabicScript++
+			if persianLetters[r] {
+				persianOnly++
+			}
+			if arabicLetters[r] {
+				arabicOnly++
+			}
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+
+	if arabicScript+latin < 2 {
+		return ""
+	}
+	if arabicScript >= latin {
+		if arabicOnly > persianOnly {
+			return Arabic
+		}
+		return Persian
+	}
+
+	// Codes such as TKT-1a2b3c4d or a PNR are not words of any language
+	if !hasLatinWord(text) {
+		return ""
+	}
+	return English
+}
+
+// hasLatinWord reports whether text contains a Latin word: two or more letters,
+// no digits, and not written in capitals like codes are
+func hasLatinWord(text string) bool {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, field := range fields {
+		letters, lower := 0, false
+		for _, r := range field {
+			if r >= unicode.MaxASCII || !unicode.IsLetter(r) {
+				letters = 0
+				break
+			}
+			letters++
+			lower = lower || unicode.IsLower(r)
+		}
+		if letters >= 2 && lower {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+// Package i18n holds the message catalog for bot replies and API errors and
+// detects the language customers write in.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported languages
+const (
+	English = "en"
+	Persian = "fa"
+	Arabic  = "ar"
+)
+
+// DefaultLanguage is used when no supported language can be determined
+const DefaultLanguage = English
+
+// names are the English names of the supported languages
+var names = map[string]string{
+	English: "English",
+	Persian: "Persian",
+	Arabic:  "Arabic",
+}
+
+// Params are the values interpolated into {name} placeholders of a message
+type Params map[string]interface{}
+
+// catalogs holds the messages of each language. Plural messages are stored
+// under "<key>.<form>" where form is zero, one, two, few, many or other.
+var catalogs = map[string]map[string]string{
+	English: english,
+	Persian: persian,
+	Arabic:  arabic,
+}
+
+// IsSupported reports whether lang has a catalog
+func IsSupported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Languages returns the supported language codes
+func Languages() []string {
+	return []string{English, Persian, Arabic}
+}
+
+// Name returns the English name of lang, or lang itself if it is not supported
+func Name(lang string) string {
+	if name, ok := names[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// T returns the message for key in lang with params interpolated. Missing
+// translations fall back to English, and unknown keys are returned as is.
+func T(lang, key string, params Params) string {
+	return interpolate(lookup(lang, key), params)
+}
+
+// Tn returns the plural form of the message for key that matches count. The
+// count is also available to the message as {count}.
+func Tn(lang, key string, count int, params Params) string {
+	if !IsSupported(lang) {
+		lang = DefaultLanguage
+	}
+
+	withCount := Params{"count": count}
+	for k, v := range params {
+		withCount[k] = v
+	}
+
+	form := pluralForm(lang, count)
+	message, ok := catalogs[lang][key+"."+form]
+	if !ok {
+		message = lookup(lang, key+".other")
+	}
+	return interpolate(message, withCount)
+}
+
+func lookup(lang, key string) string {
+	if message, ok := catalogs[lang][key]; ok {
+		return message
+	}
+	if message, ok := catalogs[DefaultLanguage][key]; ok {
+		return message
+	}
+	return key
+}
+
+func interpolate(message string, params Params) string {
+	if len(params) == 0 || !strings.Contains(message, "{") {
+		return message
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// pluralForm returns the CLDR plural category of count in lang
+func pluralForm(lang string, count int) string {
+	n := count
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case Arabic:
+		switch {
+		case n == 0:
+			return "zero"
+		case n == 1:
+			return "one"
+		case n == 2:
+			return "two"
+		case n%100 >= 3 && n%100 <= 10:
+			return "few"
+		case n%100 >= 11:
+			return "many"
+		default:
+			return "other"
+		}
+	case Persian:
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// MatchAcceptLanguage returns the first supported language in an
+// Accept-Language header, honouring quality values, or fallback if none match
+func MatchAcceptLanguage(header, fallback string) string {
+	best, bestQuality := fallback, 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if !IsSupported(tag) {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				fmt.Sscanf(param[2:], "%g", &quality)
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = tag, quality
+		}
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+// Package llmtest provides a local mock of the chat completions API for
+// exercising the LLM integration without calling a real provider.
+package llmtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"callcenter/internal/llm"
+)
+
+// Server is a mock chat completions server that replies with scripted messages
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	replies  []llm.Message
+	requests []llm.ChatCompletionRequest
+}
+
+// NewServer starts a mock server that returns the given replies in order.
+// Once the script is exhausted the last reply is repeated.
+func NewServer(replies ...llm.Message) *Server {
+	s := &Server{replies: replies}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Requests returns the completion requests received so far
+func (s *Server) Requests() []llm.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]llm.ChatCompletionRequest(nil), s.requests...)
+}
+
+// TextReply builds an assistant reply with plain content
+func TextReply(content string) llm.Message {
+	return llm.Message{Role: llm.RoleAssistant, Content: content}
+}
+
+// ToolCallReply builds an assistant reply requesting a single tool call
+func ToolCallReply(id, name, arguments string) llm.Message {
+	return llm.Message{
+		Role: llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{{
+			ID:       id,
+			Type:     "function",
+			Function: llm.FunctionCall{Name: name, Arguments: arguments},
+		}},
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req llm.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	n := len(s.requests)
+	s.requests = append(s.requests, req)
+	var reply llm.Message
+	switch {
+	case len(s.replies) == 0:
+		reply = TextReply("")
+	case n < len(s.replies):
+		reply = s.replies[n]
+	default:
+		reply = s.replies[len(s.replies)-1]
+	}
+	s.mu.Unlock()
+
+	finishReason := "stop"
+	if len(reply.ToolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(llm.ChatCompletionResponse{
+		ID:      "chatcmpl-mock",
+		Model:   req.Model,
+		Choices: []llm.Choice{{Message: reply, FinishReason: finishReason}},
+	})
+}
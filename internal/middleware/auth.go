@@ -6,22 +6,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "error.auth_header_required")
 			return
 		}
 
 		// Extract token from Bearer header
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "error.auth_header_invalid")
 			return
 		}
 
@@ -31,22 +30,58 @@ func AuthMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "error.invalid_token")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "error.invalid_token_claims")
 			return
 		}
 
+		sub, _ := claims["sub"].(string)
+		userID, err := uuid.Parse(sub)
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, "error.invalid_token_claims")
+			return
+		}
+		role, _ := claims["role"].(string)
+
 		// Set user ID and role in context
-		c.Set("userID", claims["sub"])
-		c.Set("userRole", claims["role"])
+		c.Set("userID", userID)
+		c.Set("userRole", role)
 
 		c.Next()
 	}
 }
+
+// RequireRole aborts the request unless the authenticated user has one of the given roles.
+// It must be registered after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := CurrentUserRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		abortWithError(c, http.StatusForbidden, "error.insufficient_permissions")
+	}
+}
+
+// CurrentUserID returns the authenticated user's ID set by AuthMiddleware
+func CurrentUserID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get("userID")
+	if !exists {
+		return uuid.Nil, false
+	}
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
+
+// CurrentUserRole returns the authenticated user's role set by AuthMiddleware
+func CurrentUserRole(c *gin.Context) string {
+	return c.GetString("userRole")
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"callcenter/internal/i18n"
+)
+
+// Language resolves the request language from the Accept-Language header,
+// falling back to defaultLang, and stores it in the context
+func Language(defaultLang string) gin.HandlerFunc {
+	if !i18n.IsSupported(defaultLang) {
+		defaultLang = i18n.DefaultLanguage
+	}
+	return func(c *gin.Context) {
+		c.Set("lang", i18n.MatchAcceptLanguage(c.GetHeader("Accept-Language"), defaultLang))
+		c.Next()
+	}
+}
+
+// CurrentLanguage returns the request language set by Language. Routes
+// mounted without the middleware still honour Accept-Language.
+func CurrentLanguage(c *gin.Context) string {
+	if lang := c.GetString("lang"); lang != "" {
+		return lang
+	}
+	return i18n.MatchAcceptLanguage(c.GetHeader("Accept-Language"), i18n.DefaultLanguage)
+}
+
+// abortWithError aborts the request with a localized error message
+func abortWithError(c *gin.Context, status int, key string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": i18n.T(CurrentLanguage(c), key, nil)})
+}
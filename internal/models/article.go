@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Knowledge base article topics
+const (
+	ArticleTopicBaggage      = "baggage"
+	ArticleTopicCancellation = "cancellation"
+	ArticleTopicCheckIn      = "check_in"
+	ArticleTopicRefund       = "refund"
+	ArticleTopicGeneral      = "general"
+)
+
+// ArticleTopics lists the valid article topics
+var ArticleTopics = []string{
+	ArticleTopicBaggage,
+	ArticleTopicCancellation,
+	ArticleTopicCheckIn,
+	ArticleTopicRefund,
+	ArticleTopicGeneral,
+}
+
+// Article is a knowledge base entry the bot can answer FAQs from. Articles with
+// no airline apply to every airline.
+type Article struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
+	Slug      string    `gorm:"not null;uniqueIndex"`
+	Airline   string    `gorm:"size:2;index"` // IATA code
+	Topic     string    `gorm:"not null;index"`
+	Language  string    `gorm:"size:8;not null;default:'fa'"`
+	Title     string    `gorm:"not null"`
+	Summary   string    // short answer quoted by the bot
+	Body      string    `gorm:"not null"`
+	URL       string    // external page; defaults to the help center page of the slug
+	Published bool      `gorm:"not null;default:true;index"`
+	// SearchText is the normalized title, summary and body that the full-text
+	// index is built from, so Persian spelling variants match each other
+	SearchText string `gorm:"not null" json:"-"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
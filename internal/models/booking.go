@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/money"
+)
+
+// Booking statuses
+const (
+	BookingStatusActive    = "active"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusFlown     = "flown"
+)
+
+// Ticket types of a booking
+const (
+	TicketTypeCharter    = "charter"
+	TicketTypeSystematic = "systematic"
+)
+
+// Passenger types
+const (
+	PassengerTypeAdult  = "adult"
+	PassengerTypeChild  = "child"
+	PassengerTypeInfant = "infant"
+)
+
+// Booking is a flight reservation identified by the airline's PNR. Its fares
+// are the sum of the fares of its passengers; all amounts are in minor units
+// of Currency. A booking stays active while any passenger still holds a seat
+// on any segment; RefundAmount adds up the refunds of its cancellations.
+type Booking struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null;index"` // customer who booked
+	PNR               string    `gorm:"size:6;not null;index"`
+	Airline           string    `gorm:"size:2;not null"` // IATA code of the validating carrier
+	TicketType        string    `gorm:"not null"`        // charter, systematic
+	FareClass         string    `gorm:"size:2"`          // booking class, e.g. Y or M
+	Status            string    `gorm:"not null;default:'active';index"`
+	ContactPhone      string    `gorm:"index"`
+	ContactEmail      string
+	BaseFare          money.Amount `gorm:"not null"`
+	Taxes             money.Amount `gorm:"not null"`
+	TotalFare         money.Amount `gorm:"not null"`
+	Currency          string       `gorm:"size:3;not null"` // ISO 4217 code
+	RefundStatus      string
+	RefundAmount      money.Amount
+	RefundProcessedAt *time.Time
+	CancelledAt       *time.Time
+	Passengers        []Passenger `gorm:"foreignKey:BookingID"`
+	Segments          []Segment   `gorm:"foreignKey:BookingID"`
+	User              User        `gorm:"foreignKey:UserID"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+// Passenger is a traveller on a booking with the e-ticket issued to them
+type Passenger struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key"`
+	BookingID      uuid.UUID    `gorm:"type:uuid;not null;index"`
+	FirstName      string       `gorm:"not null"`
+	LastName       string       `gorm:"not null"`
+	Type           string       `gorm:"not null;default:'adult'"` // adult, child, infant
+	DocumentNumber string       // national ID or passport number
+	TicketNumber   string       `gorm:"index"` // 13-digit e-ticket number
+	BaseFare       money.Amount `gorm:"not null"`
+	Taxes          money.Amount `gorm:"not null"`
+	Status         string       `gorm:"not null;default:'active'"` // active, cancelled once all their coupons are
+	CancelledAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Segment is one flight of a booking's itinerary
+type Segment struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key"`
+	BookingID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Sequence     int       `gorm:"not null"`
+	Airline      string    `gorm:"size:2;not null"`
+	FlightNumber string    `gorm:"not null"`
+	Origin       string    `gorm:"size:3;not null"` // IATA airport code
+	Destination  string    `gorm:"size:3;not null"`
+	DepartureAt  time.Time `gorm:"not null;index"`
+	ArrivalAt    time.Time
+	CabinClass   string
+	Status       string `gorm:"not null;default:'active'"` // active, cancelled once every passenger's coupon is
+	CancelledAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bulk job actions
+const (
+	BulkActionStatus  = "status"  // move the tickets to a status
+	BulkActionAssign  = "assign"  // move the tickets between queues and agents
+	BulkActionTag     = "tag"     // add and remove tags
+	BulkActionComment = "comment" // add a reply or an internal note
+	BulkActionCancel  = "cancel"  // cancel the booking of the tickets and refund it
+)
+
+// Bulk job statuses
+const (
+	BulkJobPending         = "pending"
+	BulkJobRunning         = "running"
+	BulkJobCompleted       = "completed"        // every ticket succeeded
+	BulkJobPartiallyFailed = "partially_failed" // some tickets failed
+	BulkJobFailed          = "failed"           // every ticket failed
+)
+
+// Bulk job item statuses
+const (
+	BulkItemPending   = "pending"
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+)
+
+// BulkJob applies one action to every ticket a search matched when the job
+// was created. Jobs run in the background with the permissions of the
+// supervisor who created them, and keep the result of each ticket.
+type BulkJob struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
+	Action      string    `gorm:"not null"`                         // status, assign, tag, comment, cancel
+	Params      string    `gorm:"not null"`                         // JSON parameters of the action
+	Search      string    `gorm:"not null"`                         // JSON ticket search the tickets were matched with
+	Status      string    `gorm:"not null;default:'pending';index"` // pending, running, completed, partially_failed, failed
+	Total       int       `gorm:"not null"`
+	Succeeded   int       `gorm:"not null;default:0"`
+	Failed      int       `gorm:"not null;default:0"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatorRole string    `gorm:"not null"`
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	Items       []BulkJobItem `gorm:"foreignKey:JobID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time // also marks the progress of a running job
+}
+
+// BulkJobItem is a ticket of a bulk job and the result of the action on it
+type BulkJobItem struct {
+	JobID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TicketID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TicketNumber string    `gorm:"not null"`
+	Position     int       `gorm:"not null"`                   // order the tickets are processed in
+	Status       string    `gorm:"not null;default:'pending'"` // pending, succeeded, failed
+	Error        string    // why the action failed on the ticket
+	ProcessedAt  *time.Time
+}
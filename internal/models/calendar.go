@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BusinessCalendar is a working week in a time zone with its holidays. Due
+// times of the tickets of a queue count business time on the queue's calendar,
+// or on the default calendar.
+type BusinessCalendar struct {
+	ID        uuid.UUID         `gorm:"type:uuid;primary_key"`
+	Name      string            `gorm:"uniqueIndex;not null"`
+	TimeZone  string            `gorm:"not null"` // IANA name, e.g. Asia/Tehran
+	IsDefault bool              `gorm:"not null;default:false"`
+	Hours     []WorkingHours    `gorm:"foreignKey:CalendarID"`
+	Holidays  []CalendarHoliday `gorm:"foreignKey:CalendarID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WorkingHours is a stretch of working time on a day of the week, in the
+// calendar's time zone
+type WorkingHours struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	CalendarID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Weekday    int       `gorm:"not null"` // 0 is Sunday, 6 is Saturday
+	Opens      string    `gorm:"not null"` // e.g. 08:00
+	Closes     string    `gorm:"not null"` // e.g. 16:00, or 24:00 for midnight
+}
+
+// CalendarHoliday is a day off on a calendar, dated in the Gregorian or the
+// Jalali calendar. Without a year it recurs every year.
+type CalendarHoliday struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	CalendarID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"not null"`
+	System     string    `gorm:"not null;default:'gregorian'"` // gregorian or jalali
+	Year       int       `gorm:"not null"`                     // 0 for every year
+	Month      int       `gorm:"not null"`
+	Day        int       `gorm:"not null"`
+}
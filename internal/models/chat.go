@@ -8,28 +8,33 @@ import (
 )
 
 type ChatSession struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key"`
-	UserID       uuid.UUID `gorm:"type:uuid;not null"`
-	Platform     string    `gorm:"not null"`
-	Status       string    `gorm:"not null;default:'active'"`
-	LastActivity time.Time
-	Messages     []ChatMessage `gorm:"foreignKey:SessionID"`
-	User         User          `gorm:"foreignKey:UserID"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	ID       uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null"`
+	Platform string    `gorm:"not null"`
+	Status   string    `gorm:"not null;default:'active'"`
+	// Language is the language the bot replies in. It follows the customer's
+	// messages until they pick one explicitly, which pins it.
+	Language       string `gorm:"size:8"`
+	LanguagePinned bool   `gorm:"not null;default:false"`
+	LastActivity   time.Time
+	Messages       []ChatMessage `gorm:"foreignKey:SessionID"`
+	User           User          `gorm:"foreignKey:UserID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
 }
 
 type ChatMessage struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
-	SessionID uuid.UUID `gorm:"type:uuid;not null"`
-	Content   string    `gorm:"not null"`
-	Role      string    `gorm:"not null"` // 'user' or 'assistant'
-	Intent    string
-	Entities  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
+	SessionID   uuid.UUID `gorm:"type:uuid;not null"`
+	Content     string    `gorm:"not null"`
+	Role        string    `gorm:"not null"` // 'user', 'assistant' or 'agent' for replies by staff
+	Intent      string
+	IntentScore float64
+	Entities    string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
 
 type ChatLog struct {
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment visibilities
+const (
+	CommentPublic   = "public"   // a reply the customer sees and is sent
+	CommentInternal = "internal" // a note for staff only
+)
+
+// TicketComment is a message in the conversation on a ticket. Public comments
+// by staff are replies sent to the customer on the chat platform they last
+// used; customers only ever write and see public comments.
+type TicketComment struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key"`
+	TicketID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	AuthorID      uuid.UUID  `gorm:"type:uuid;not null"`
+	Visibility    string     `gorm:"not null;default:'internal'"` // public, internal
+	Body          string     `gorm:"not null"`
+	Channel       string     // platform a reply was sent on, e.g. web; empty when it was not sent
+	ChatMessageID *uuid.UUID `gorm:"type:uuid"` // chat message the reply was sent as
+	EditedAt      *time.Time
+	Mentions      []CommentMention    `gorm:"foreignKey:CommentID"`
+	Edits         []CommentEdit       `gorm:"foreignKey:CommentID"`
+	Attachments   []CommentAttachment `gorm:"foreignKey:CommentID"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// CommentMention is an agent called into a ticket by a comment
+type CommentMention struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+// CommentEdit keeps the body a comment had before an edit
+type CommentEdit struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
+	CommentID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Body      string    `gorm:"not null"`
+	EditorID  uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+}
+
+// CommentAttachment is a file attached to a comment. The content is only read
+// when the file is downloaded.
+type CommentAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
+	CommentID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	FileName    string    `gorm:"not null"`
+	ContentType string    `gorm:"not null"`
+	Size        int64     `gorm:"not null"`
+	Data        []byte    `gorm:"type:bytea;not null" json:"-"`
+	UploaderID  uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time
+}
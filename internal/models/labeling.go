@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Labeling task reasons
+const (
+	LabelingReasonLowConfidence = "low_confidence"
+	LabelingReasonEscalated     = "escalated"
+)
+
+// Labeling task statuses
+const (
+	LabelingStatusPending  = "pending"
+	LabelingStatusLabelled = "labelled"
+	LabelingStatusSkipped  = "skipped"
+)
+
+// LabelingTask queues a user message for an agent to review the detected intent
+type LabelingTask struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key"`
+	MessageID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	SessionID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	Text              string    `gorm:"not null"`
+	PredictedIntent   string
+	PredictedScore    float64
+	PredictedEntities string
+	Reason            string `gorm:"not null"` // low_confidence or escalated
+	Status            string `gorm:"not null;default:'pending';index"`
+	CorrectedIntent   string
+	CorrectedEntities string
+	LabelledBy        *uuid.UUID `gorm:"type:uuid"`
+	LabelledAt        *time.Time
+	Message           ChatMessage `gorm:"foreignKey:MessageID"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
@@ -3,19 +3,96 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"callcenter/internal/money"
+)
+
+// Refund request statuses
+const (
+	RefundStatusPending    = "pending"
+	RefundStatusApproved   = "approved"
+	RefundStatusRejected   = "rejected"
+	RefundStatusProcessing = "processing"
+	RefundStatusProcessed  = "processed"
+	RefundStatusFailed     = "failed"
 )
 
-// RefundRequest represents a ticket refund request
+// RefundRequest represents the refund of a cancelled booking
 type RefundRequest struct {
 	gorm.Model
-	TicketNumber string  `gorm:"not null"`
-	RequestedBy  string  `gorm:"not null"` // user ID or system
-	Reason       string  `gorm:"type:text"`
-	Status       string  `gorm:"not null"` // pending, approved, rejected, processed
-	Amount       float64 `gorm:"not null"`
-	Currency     string  `gorm:"not null"`
-	ProcessedAt  time.Time
-	ProcessedBy  string // agent ID or system
-	Notes        string `gorm:"type:text"`
+	BookingID       uuid.UUID    `gorm:"type:uuid;not null;index"`
+	TicketID        *uuid.UUID   `gorm:"type:uuid;index"` // support case the cancellation came from
+	QuoteID         *uuid.UUID   `gorm:"type:uuid"`       // quote the customer accepted
+	RequestedBy     string       `gorm:"not null"`        // user ID or system
+	Reason          string       `gorm:"type:text"`
+	Status          string       `gorm:"not null;index"` // pending, approved, rejected, processing, processed, failed
+	Amount          money.Amount `gorm:"not null"`
+	Currency        string       `gorm:"not null"`
+	ApprovedAt      *time.Time
+	RejectedAt      *time.Time
+	RejectionReason string `gorm:"type:text"`
+	ProcessingAt    *time.Time
+	ProcessedAt     *time.Time
+	FailedAt        *time.Time
+	ProcessedBy     string               // user ID of the last actor, or system
+	PayoutKey       string               // idempotency key of the current payout attempt
+	PayoutID        string               `gorm:"index"` // gateway payout of the current attempt
+	Notes           string               `gorm:"type:text"`
+	Items           []RefundLineItem     `gorm:"foreignKey:RefundRequestID"`
+	History         []RefundStatusChange `gorm:"foreignKey:RefundRequestID"`
+}
+
+// RefundQuote is a refund breakdown shown to a customer before they cancel. Its
+// ID is the quote token; cancelling with an unexpired quote refunds exactly its
+// amount, and each quote can be used once.
+type RefundQuote struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key"`
+	BookingID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	PolicyName     string
+	BaseFare       money.Amount `gorm:"not null"`
+	Taxes          money.Amount `gorm:"not null"`
+	PenaltyPercent float64      `gorm:"not null"`
+	Penalty        money.Amount `gorm:"not null"`
+	Fee            money.Amount `gorm:"not null"`
+	TaxesRefunded  money.Amount `gorm:"not null"`
+	Amount         money.Amount `gorm:"not null"`
+	Currency       string       `gorm:"size:3;not null"`
+	RequestedBy    string       `gorm:"not null"` // user ID or system
+	ExpiresAt      time.Time    `gorm:"not null"`
+	UsedAt         *time.Time
+	Items          []RefundLineItem `gorm:"foreignKey:QuoteID"`
+	CreatedAt      time.Time
+}
+
+// RefundLineItem is the refund of one coupon, one passenger on one segment, of
+// a quote. Once the quote is accepted the item belongs to the refund request
+// too, and the coupon is cancelled.
+type RefundLineItem struct {
+	ID              uuid.UUID    `gorm:"type:uuid;primary_key"`
+	QuoteID         uuid.UUID    `gorm:"type:uuid;not null;index"`
+	RefundRequestID *uint        `gorm:"index"`
+	BookingID       uuid.UUID    `gorm:"type:uuid;not null;index"`
+	PassengerID     uuid.UUID    `gorm:"type:uuid;not null"`
+	SegmentID       uuid.UUID    `gorm:"type:uuid;not null"`
+	BaseFare        money.Amount `gorm:"not null"`
+	Taxes           money.Amount `gorm:"not null"`
+	Penalty         money.Amount `gorm:"not null"`
+	Fee             money.Amount `gorm:"not null"`
+	TaxesRefunded   money.Amount `gorm:"not null"`
+	Amount          money.Amount `gorm:"not null"`
+	CreatedAt       time.Time
+}
+
+// RefundStatusChange records one transition of a refund request
+type RefundStatusChange struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key"`
+	RefundRequestID uint       `gorm:"not null;index"`
+	FromStatus      string     // empty for the creation of the request
+	ToStatus        string     `gorm:"not null"`
+	ActorID         *uuid.UUID `gorm:"type:uuid"` // empty for automated changes
+	ActorRole       string     `gorm:"not null"`
+	Reason          string     `gorm:"type:text"`
+	CreatedAt       time.Time
 }
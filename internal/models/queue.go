@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Assignment strategies of a queue
+const (
+	AssignRoundRobin  = "round_robin"  // members take turns
+	AssignLeastLoaded = "least_loaded" // the member with the fewest open tickets
+	AssignSkills      = "skills"       // the member with the most matching skills, then the least loaded
+)
+
+// Queue groups the support tickets one team handles, such as refunds, changes
+// or complaints. New tickets are routed to the queue of their type and, when
+// AutoAssign is set, assigned to one of its members by the queue's strategy.
+type Queue struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key"`
+	Name           string    `gorm:"uniqueIndex;not null"`
+	Description    string
+	Strategy       string     `gorm:"not null;default:'round_robin'"` // round_robin, least_loaded, skills
+	AutoAssign     bool       `gorm:"not null;default:false"`
+	LastAssigneeID *uuid.UUID `gorm:"type:uuid"` // member who got the last round-robin ticket
+	CalendarID     *uuid.UUID `gorm:"type:uuid"` // business hours of the queue; nil for the default calendar
+	Members        []QueueMember
+	Routes         []QueueRoute
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// QueueMember is an agent who works the tickets of a queue
+type QueueMember struct {
+	QueueID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	User      User      `gorm:"foreignKey:UserID"`
+	CreatedAt time.Time
+}
+
+// QueueRoute sends new tickets of a type to a queue. A type is routed to at
+// most one queue; tickets of unrouted types wait unqueued.
+type QueueRoute struct {
+	TicketType string    `gorm:"primaryKey"`
+	QueueID    uuid.UUID `gorm:"type:uuid;not null;index"`
+}
+
+// AgentSkill is something an agent is qualified for, matched against the
+// ticket type and the airline of the booking, e.g. refund or W5
+type AgentSkill struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Skill  string    `gorm:"primaryKey"`
+}
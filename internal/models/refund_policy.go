@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/money"
+)
+
+// RefundPolicy defines how much of a booking is refunded when it is cancelled.
+// A policy applies to the bookings matching its airline, fare class and ticket
+// type; empty fields match anything, and the most specific policy wins.
+type RefundPolicy struct {
+	ID                   uuid.UUID           `gorm:"type:uuid;primary_key"`
+	Name                 string              `gorm:"not null"`
+	Airline              string              `gorm:"size:2;index"` // IATA code
+	FareClass            string              `gorm:"size:2"`
+	TicketType           string              // charter, systematic
+	NonRefundable        bool                `gorm:"not null"` // the base fare is never refunded
+	TaxesNonRefundable   bool                `gorm:"not null"` // taxes are kept along with the penalty
+	FixedFee             money.Amount        `gorm:"not null"` // charged per passenger, in minor units of the booking currency
+	NoShowPenaltyPercent float64             `gorm:"not null"` // share of the base fare kept once the flight has departed
+	Tiers                []RefundPenaltyTier `gorm:"foreignKey:PolicyID;constraint:OnDelete:CASCADE"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            gorm.DeletedAt `gorm:"index"`
+}
+
+// RefundPenaltyTier is the penalty of a policy for cancellations made at least
+// MinHoursBeforeDeparture hours before the first flight
+type RefundPenaltyTier struct {
+	ID                      uuid.UUID `gorm:"type:uuid;primary_key"`
+	PolicyID                uuid.UUID `gorm:"type:uuid;not null;index"`
+	MinHoursBeforeDeparture int       `gorm:"not null"`
+	PenaltyPercent          float64   `gorm:"not null"` // share of the base fare kept by the airline
+}
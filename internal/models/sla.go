@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SLAPolicy sets how soon tickets of a priority get a first response and are
+// resolved, in minutes of business time. A policy of a queue takes precedence
+// over the policy of the same priority for all queues.
+type SLAPolicy struct {
+	ID                   uuid.UUID  `gorm:"type:uuid;primary_key"`
+	Name                 string     `gorm:"not null"`
+	Priority             string     `gorm:"not null;index"`  // low, medium, high
+	QueueID              *uuid.UUID `gorm:"type:uuid;index"` // nil for all queues
+	FirstResponseMinutes int        `gorm:"not null"`
+	ResolutionMinutes    int        `gorm:"not null"`
+	WarningPercent       int        `gorm:"not null;default:80"` // share of a target after which its breach is upcoming
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// TicketSLA tracks a ticket against the targets of its SLA policy. While the
+// ticket waits on the customer the clock is paused, and the due times of the
+// targets not yet met move out by the business time it waited.
+type TicketSLA struct {
+	TicketID                uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PolicyID                uuid.UUID `gorm:"type:uuid;not null;index"`
+	FirstResponseDueAt      time.Time `gorm:"not null;index"`
+	FirstResponseWarnAt     time.Time `gorm:"not null"`
+	FirstRespondedAt        *time.Time
+	FirstResponseWarnedAt   *time.Time
+	FirstResponseBreachedAt *time.Time
+	ResolutionDueAt         time.Time `gorm:"not null;index"`
+	ResolutionWarnAt        time.Time `gorm:"not null"`
+	ResolvedAt              *time.Time
+	ResolutionWarnedAt      *time.Time
+	ResolutionBreachedAt    *time.Time
+	PausedAt                *time.Time // waiting on the customer since
+	EscalatedAt             *time.Time // supervisors were alerted to a breach
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
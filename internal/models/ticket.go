@@ -1,38 +1,84 @@
 package models
 
 import (
-	"time"
-
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Support ticket statuses
+const (
+	TicketStatusOpen            = "open"
+	TicketStatusInProgress      = "in_progress"
+	TicketStatusWaitingCustomer = "waiting_customer"
+	TicketStatusResolved        = "resolved"
+	TicketStatusClosed          = "closed"
+)
+
+// Reason codes of ticket status changes
+const (
+	StatusReasonCreated         = "created"          // the ticket was opened
+	StatusReasonWorking         = "working"          // staff picked the ticket up
+	StatusReasonAwaitingInfo    = "awaiting_info"    // the customer was asked for information
+	StatusReasonCustomerReplied = "customer_replied" // the customer answered
+	StatusReasonSolved          = "solved"           // the customer's problem was solved
+	StatusReasonNoResponse      = "no_response"      // the customer stopped answering
+	StatusReasonDuplicate       = "duplicate"        // another ticket covers the same problem
+	StatusReasonReopened        = "reopened"         // a resolved or closed ticket needs work again
+	StatusReasonOther           = "other"
+)
+
+// Support ticket types
+const (
+	TicketCategoryGeneral      = "general"
+	TicketCategoryCancellation = "cancellation"
+	TicketCategoryRefund       = "refund"
+	TicketCategoryChange       = "change"
+	TicketCategoryComplaint    = "complaint"
+	TicketCategoryBaggage      = "baggage"
+)
+
+// Ticket is a support case opened by a customer, optionally about one of their bookings
 type Ticket struct {
 	gorm.Model
-	ID              uuid.UUID `gorm:"type:uuid;primary_key"`
-	UserID          uuid.UUID `gorm:"type:uuid;not null"`
-	Number          string    `gorm:"uniqueIndex;not null"`
-	Status          string    `gorm:"not null;default:'open'"`
-	Subject         string    `gorm:"not null"`
-	Description     string    `gorm:"not null"`
-	Priority        string    `gorm:"not null;default:'medium'"`
-	TicketType      string    `gorm:"not null"` // charter, systematic
-	Price           float64   `gorm:"not null"`
-	Currency        string    `gorm:"not null"`
-	RefundStatus    string
-	RefundAmount    float64
-	RefundProcessed time.Time
-	User            User `gorm:"foreignKey:UserID"`
-	History         []TicketHistory
+	ID           uuid.UUID   `gorm:"type:uuid;primary_key"`
+	UserID       uuid.UUID   `gorm:"type:uuid;not null"`
+	BookingID    *uuid.UUID  `gorm:"type:uuid;index"`
+	Number       string      `gorm:"uniqueIndex;not null"`
+	Status       string      `gorm:"not null;default:'open';index"`
+	Subject      string      `gorm:"not null"`
+	Description  string      `gorm:"not null"`
+	Priority     string      `gorm:"not null;default:'medium';index"`
+	Type         string      `gorm:"not null;default:'general';index"` // general, cancellation, refund, change, complaint, baggage
+	QueueID      *uuid.UUID  `gorm:"type:uuid;index"`                  // queue the ticket waits in
+	AssigneeID   *uuid.UUID  `gorm:"type:uuid;index"`                  // agent handling the ticket
+	MergedIntoID *uuid.UUID  `gorm:"type:uuid;index"`                  // ticket this duplicate was merged into
+	User         User        `gorm:"foreignKey:UserID"`
+	Assignee     *User       `gorm:"foreignKey:AssigneeID"`
+	Booking      *Booking    `gorm:"foreignKey:BookingID"`
+	SLA          *TicketSLA  `gorm:"foreignKey:TicketID"`
+	Tags         []TicketTag `gorm:"foreignKey:TicketID"`
+	History      []TicketHistory
 }
 
+// TicketTag is a label staff put on a ticket to group tickets, e.g. the flight
+// a wave of tickets is about. Tags are stored lowercase.
+type TicketTag struct {
+	TicketID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Tag      string    `gorm:"primaryKey;index"`
+}
+
+// TicketStatus is a change in the status timeline of a ticket. The timeline
+// starts with the status the ticket was opened in, which has no FromStatus.
 type TicketStatus struct {
 	gorm.Model
 	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
-	TicketID    uuid.UUID `gorm:"type:uuid;not null"`
-	Status      string    `gorm:"not null"`
+	TicketID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	FromStatus  string    `gorm:"not null;default:''"`
+	Status      string    `gorm:"not null"`                 // status the ticket moved to
+	Reason      string    `gorm:"not null;default:'other'"` // reason code, see StatusReasonCreated
 	Description string
-	Ticket      Ticket `gorm:"foreignKey:TicketID"`
+	ActorID     *uuid.UUID `gorm:"type:uuid"` // nil for changes by the system
+	Ticket      Ticket     `gorm:"foreignKey:TicketID"`
 }
 
 type TicketHistory struct {
@@ -5,6 +5,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser       = "user"
+	RoleAgent      = "agent"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+// RoleSystem is the role of automated actors such as payment callbacks; no user has it
+const RoleSystem = "system"
+
 type User struct {
 	gorm.Model
 	ID       uuid.UUID `gorm:"type:uuid;primary_key"`
@@ -13,3 +24,13 @@ type User struct {
 	Role     string    `gorm:"not null;default:'user'"`
 	Name     string
 }
+
+// IsStaff reports whether the role belongs to call center staff rather than a customer
+func IsStaff(role string) bool {
+	switch role {
+	case RoleAgent, RoleSupervisor, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,158 @@
+// Package money represents sums of money exactly, as integer amounts in the
+// minor units of their currency, and rounds and formats them per currency.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownCurrency is returned for currency codes without rounding rules
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Amount is a sum of money in the minor units of its currency: rials for IRR,
+// cents for USD
+type Amount int64
+
+// Currency describes how amounts of a currency are stored, rounded and shown
+type Currency struct {
+	Code     string // ISO 4217 code
+	Exponent int    // digits of the minor unit: 2 for cents, 0 when amounts are whole
+	Rounding Amount // smallest amount charged or refunded, in minor units
+}
+
+// Toman is the unit Iranian prices are usually quoted in. It is not an ISO
+// currency: amounts given in tomans are converted to rials (IRR) on the way in
+// and can be shown in tomans on the way out.
+const Toman = "IRT"
+
+// rialsPerToman is the value of a toman in rials
+const rialsPerToman = 10
+
+// currencies lists the supported currencies. ISO 4217 gives the rial two
+// decimals, but no fraction of a rial is in use and payments settle in whole
+// tomans, so IRR amounts are whole rials rounded to 10.
+var currencies = map[string]Currency{
+	"IRR": {Code: "IRR", Exponent: 0, Rounding: rialsPerToman},
+	"USD": {Code: "USD", Exponent: 2, Rounding: 1},
+	"EUR": {Code: "EUR", Exponent: 2, Rounding: 1},
+	"AED": {Code: "AED", Exponent: 2, Rounding: 1},
+	"TRY": {Code: "TRY", Exponent: 2, Rounding: 1},
+	"GBP": {Code: "GBP", Exponent: 2, Rounding: 1},
+}
+
+// Lookup returns the rules of a currency
+func Lookup(code string) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+// MustLookup is like Lookup but falls back to whole units rounded to 1 for
+// unknown codes, so amounts stored before a currency was supported still format
+func MustLookup(code string) Currency {
+	if currency, err := Lookup(code); err == nil {
+		return currency
+	}
+	return Currency{Code: code, Rounding: 1}
+}
+
+// ParseCurrency resolves the currency amounts are given in. Tomans resolve to
+// IRR; scale is what amounts given in code must be multiplied by to be in minor
+// units of the returned currency.
+func ParseCurrency(code string) (currency Currency, scale Amount, err error) {
+	if code == Toman {
+		return currencies["IRR"], rialsPerToman, nil
+	}
+	currency, err = Lookup(code)
+	if err != nil {
+		return Currency{}, 0, err
+	}
+	return currency, 1, nil
+}
+
+// Round rounds an amount half away from zero to the currency's rounding unit
+func (c Currency) Round(amount Amount) Amount {
+	unit := c.Rounding
+	if unit <= 1 {
+		return amount
+	}
+	half := unit / 2
+	if amount < 0 {
+		return -((-amount + half) / unit * unit)
+	}
+	return (amount + half) / unit * unit
+}
+
+// Percent returns percent percent of an amount, rounded for the currency.
+// Percentages are applied in hundredths of a percent so the result does not
+// depend on floating point error.
+func (c Currency) Percent(amount Amount, percent float64) Amount {
+	basisPoints := int64(math.Round(percent * 100))
+	product := int64(amount) * basisPoints
+	share := product / 10000
+	if remainder := product % 10000; remainder*2 >= 10000 {
+		share++
+	} else if remainder*2 <= -10000 {
+		share--
+	}
+	return c.Round(Amount(share))
+}
+
+// Min returns the smaller of two amounts
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Format shows an amount in the major unit of its currency with thousands
+// separators, e.g. "1,250.50 USD" or "12,500,000 IRR"
+func Format(amount Amount, code string) string {
+	return formatUnits(amount, MustLookup(code).Exponent) + " " + code
+}
+
+// Display is Format for customers: rial amounts are shown in tomans, the unit
+// Iranian customers think in
+func Display(amount Amount, code string) string {
+	if code == "IRR" {
+		tomans := amount / rialsPerToman
+		if amount%rialsPerToman == 0 {
+			return formatUnits(tomans, 0) + " " + Toman
+		}
+		return formatUnits(amount, 1) + " " + Toman
+	}
+	return Format(amount, code)
+}
+
+// formatUnits writes an amount of minor units with exponent decimals
+func formatUnits(amount Amount, exponent int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(int64(amount), 10)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if exponent > 0 {
+		return sign + grouped.String() + "." + fraction
+	}
+	return sign + grouped.String()
+}
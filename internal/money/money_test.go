@@ -0,0 +1,135 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		code      string
+		want      string
+		wantScale Amount
+		wantErr   error
+	}{
+		{code: "IRT", want: "IRR", wantScale: 10},
+		{code: "IRR", want: "IRR", wantScale: 1},
+		{code: "USD", want: "USD", wantScale: 1},
+		{code: "irt", wantErr: ErrUnknownCurrency},
+		{code: "XYZ", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			currency, scale, err := ParseCurrency(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCurrency(%q) error = %v, want %v", tt.code, err, tt.wantErr)
+			}
+			if currency.Code != tt.want || scale != tt.wantScale {
+				t.Errorf("ParseCurrency(%q) = %s, %d; want %s, %d", tt.code, currency.Code, scale, tt.want, tt.wantScale)
+			}
+		})
+	}
+
+	// 125,000 tomans are stored as 1,250,000 rials
+	currency, scale, _ := ParseCurrency(Toman)
+	if got := currency.Round(125000 * scale); got != 1250000 {
+		t.Errorf("125,000 IRT = %d IRR, want 1250000", got)
+	}
+}
+
+func TestRound(t *testing.T) {
+	irr, usd := MustLookup("IRR"), MustLookup("USD")
+	tests := []struct {
+		name     string
+		currency Currency
+		amount   Amount
+		want     Amount
+	}{
+		{"IRR whole toman", irr, 1250, 1250},
+		{"IRR down", irr, 1254, 1250},
+		{"IRR half up", irr, 1255, 1260},
+		{"IRR up", irr, 1259, 1260},
+		{"IRR below half a toman", irr, 4, 0},
+		{"IRR negative half away from zero", irr, -1255, -1260},
+		{"IRR negative down", irr, -1254, -1250},
+		{"USD cents unchanged", usd, 12345, 12345},
+		{"unknown currency unchanged", MustLookup("XYZ"), 12345, 12345},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.currency.Round(tt.amount); got != tt.want {
+				t.Errorf("Round(%d) = %d, want %d", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	irr, usd := MustLookup("IRR"), MustLookup("USD")
+	tests := []struct {
+		name     string
+		currency Currency
+		amount   Amount
+		percent  float64
+		want     Amount
+	}{
+		{"IRR whole percent", irr, 1000000, 30, 300000},
+		{"IRR rounded to a toman", irr, 1234567, 12.5, 154320},
+		{"IRR half a toman rounds up", irr, 1050, 50, 530},
+		{"USD cents half up", usd, 999, 33.33, 333},
+		{"USD hundredths of a percent", usd, 10000, 0.01, 1},
+		{"USD negative", usd, -999, 50, -500},
+		{"nothing", irr, 1000000, 0, 0},
+		{"everything", irr, 1234560, 100, 1234560},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.currency.Percent(tt.amount, tt.percent); got != tt.want {
+				t.Errorf("Percent(%d, %v) = %d, want %d", tt.amount, tt.percent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		code   string
+		want   string
+	}{
+		{125050, "USD", "1,250.50 USD"},
+		{5, "USD", "0.05 USD"},
+		{-125050, "USD", "-1,250.50 USD"},
+		{12500000, "IRR", "12,500,000 IRR"},
+		{0, "IRR", "0 IRR"},
+		{1234, "XYZ", "1,234 XYZ"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.amount, tt.code); got != tt.want {
+			t.Errorf("Format(%d, %s) = %q, want %q", tt.amount, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		code   string
+		want   string
+	}{
+		{12500000, "IRR", "1,250,000 IRT"},
+		{1255, "IRR", "125.5 IRT"},
+		{-12500000, "IRR", "-1,250,000 IRT"},
+		{125050, "USD", "1,250.50 USD"},
+	}
+
+	for _, tt := range tests {
+		if got := Display(tt.amount, tt.code); got != tt.want {
+			t.Errorf("Display(%d, %s) = %q, want %q", tt.amount, tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package nlpeval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Baseline stores the scores a change must not fall below
+type Baseline struct {
+	Accuracy       float64            `json:"accuracy"`
+	MacroF1        float64            `json:"macro_f1"`
+	EntityAccuracy float64            `json:"entity_accuracy"`
+	IntentF1       map[string]float64 `json:"intent_f1"`
+}
+
+// Regression describes a score that dropped below the baseline
+type Regression struct {
+	Metric   string
+	Baseline float64
+	Actual   float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s dropped from %.3f to %.3f", r.Metric, r.Baseline, r.Actual)
+}
+
+// BaselineFromReport captures the scores of a report as a new baseline
+func BaselineFromReport(report *Report) *Baseline {
+	baseline := &Baseline{
+		Accuracy:       report.Accuracy,
+		MacroF1:        report.MacroF1,
+		EntityAccuracy: report.EntityAccuracy,
+		IntentF1:       make(map[string]float64),
+	}
+	for name, metrics := range report.Intents {
+		baseline.IntentF1[name] = metrics.F1
+	}
+	return baseline
+}
+
+// LoadBaseline reads a baseline from a JSON file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline as indented JSON
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Compare returns every score in the report that is lower than the baseline
+// by more than tolerance
+func (b *Baseline) Compare(report *Report, tolerance float64) []Regression {
+	var regressions []Regression
+	check := func(metric string, baseline, actual float64) {
+		if actual < baseline-tolerance {
+			regressions = append(regressions, Regression{Metric: metric, Baseline: baseline, Actual: actual})
+		}
+	}
+
+	check("accuracy", b.Accuracy, report.Accuracy)
+	check("macro_f1", b.MacroF1, report.MacroF1)
+	check("entity_accuracy", b.EntityAccuracy, report.EntityAccuracy)
+
+	intents := make([]string, 0, len(b.IntentF1))
+	for name := range b.IntentF1 {
+		intents = append(intents, name)
+	}
+	sort.Strings(intents)
+	for _, name := range intents {
+		check("f1["+name+"]", b.IntentF1[name], report.Intents[name].F1)
+	}
+
+	return regressions
+}
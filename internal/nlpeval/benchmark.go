@@ -0,0 +1,60 @@
+package nlpeval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"callcenter/internal/services"
+)
+
+// BenchmarkResult summarizes how long intent detection takes per message
+type BenchmarkResult struct {
+	Messages int
+	Total    time.Duration
+	Mean     time.Duration
+	P50      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+func (r BenchmarkResult) String() string {
+	perSecond := 0.0
+	if r.Total > 0 {
+		perSecond = float64(r.Messages) / r.Total.Seconds()
+	}
+	return fmt.Sprintf("%d messages in %s: mean %s, p50 %s, p99 %s, max %s (%.0f messages/s)",
+		r.Messages, r.Total, r.Mean, r.P50, r.P99, r.Max, perSecond)
+}
+
+// Benchmark runs every example through DetectIntent rounds times and reports latency
+func Benchmark(ctx context.Context, nlp services.NLPService, examples []Example, rounds int) (BenchmarkResult, error) {
+	if len(examples) == 0 || rounds < 1 {
+		return BenchmarkResult{}, fmt.Errorf("nothing to benchmark")
+	}
+
+	durations := make([]time.Duration, 0, len(examples)*rounds)
+	var total time.Duration
+	for round := 0; round < rounds; round++ {
+		for _, example := range examples {
+			start := time.Now()
+			if _, err := nlp.DetectIntent(ctx, example.Text); err != nil {
+				return BenchmarkResult{}, fmt.Errorf("failed to detect intent for %q: %w", example.Text, err)
+			}
+			elapsed := time.Since(start)
+			durations = append(durations, elapsed)
+			total += elapsed
+		}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return BenchmarkResult{
+		Messages: len(durations),
+		Total:    total,
+		Mean:     total / time.Duration(len(durations)),
+		P50:      durations[len(durations)/2],
+		P99:      durations[len(durations)*99/100],
+		Max:      durations[len(durations)-1],
+	}, nil
+}
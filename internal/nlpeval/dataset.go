@@ -0,0 +1,72 @@
+// Package nlpeval measures intent detection and entity extraction quality of an
+// NLPService against a labelled dataset.
+package nlpeval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Example is one labelled message of the evaluation dataset.
+// Datasets are stored as JSON Lines, one example per line.
+type Example struct {
+	Text     string            `json:"text"`
+	Intent   string            `json:"intent"`
+	Entities map[string]string `json:"entities,omitempty"`
+}
+
+// ReadDataset parses a JSON Lines dataset. Blank lines and lines starting with # are ignored.
+func ReadDataset(r io.Reader) ([]Example, error) {
+	var examples []Example
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var example Example
+		if err := json.Unmarshal([]byte(text), &example); err != nil {
+			return nil, fmt.Errorf("invalid example on line %d: %w", line, err)
+		}
+		if example.Text == "" || example.Intent == "" {
+			return nil, fmt.Errorf("invalid example on line %d: text and intent are required", line)
+		}
+		examples = append(examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	return examples, nil
+}
+
+// LoadDataset reads a JSON Lines dataset from a file
+func LoadDataset(path string) ([]Example, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+	return ReadDataset(f)
+}
+
+// WriteExample appends one example to a JSON Lines stream
+func WriteExample(w io.Writer, example Example) error {
+	data, err := json.Marshal(example)
+	if err != nil {
+		return fmt.Errorf("failed to marshal example: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write example: %w", err)
+	}
+	return nil
+}
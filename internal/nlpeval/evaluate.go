@@ -0,0 +1,213 @@
+package nlpeval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"callcenter/internal/services"
+)
+
+// IntentMetrics holds the classification scores of a single intent
+type IntentMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"`
+}
+
+// EntityMetrics holds the extraction scores of a single entity type
+type EntityMetrics struct {
+	Expected  int     `json:"expected"`
+	Extracted int     `json:"extracted"`
+	Correct   int     `json:"correct"`
+	Accuracy  float64 `json:"accuracy"`
+}
+
+// Report is the result of evaluating an NLPService against a dataset
+type Report struct {
+	Examples       int                       `json:"examples"`
+	Accuracy       float64                   `json:"accuracy"`
+	MacroF1        float64                   `json:"macro_f1"`
+	EntityAccuracy float64                   `json:"entity_accuracy"`
+	Intents        map[string]IntentMetrics  `json:"intents"`
+	Entities       map[string]EntityMetrics  `json:"entities"`
+	Confusion      map[string]map[string]int `json:"confusion"` // expected -> predicted -> count
+	Errors         []Misclassification       `json:"errors,omitempty"`
+}
+
+// Misclassification records an example whose intent was predicted wrongly
+type Misclassification struct {
+	Text      string `json:"text"`
+	Expected  string `json:"expected"`
+	Predicted string `json:"predicted"`
+}
+
+// Evaluate runs every example through the service and scores the predictions
+func Evaluate(ctx context.Context, nlp services.NLPService, examples []Example) (*Report, error) {
+	report := &Report{
+		Examples:  len(examples),
+		Intents:   make(map[string]IntentMetrics),
+		Entities:  make(map[string]EntityMetrics),
+		Confusion: make(map[string]map[string]int),
+	}
+
+	correct := 0
+	entityExpected, entityCorrect := 0, 0
+	for _, example := range examples {
+		intent, err := nlp.DetectIntent(ctx, example.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect intent for %q: %w", example.Text, err)
+		}
+
+		if report.Confusion[example.Intent] == nil {
+			report.Confusion[example.Intent] = make(map[string]int)
+		}
+		report.Confusion[example.Intent][intent.Name]++
+
+		if intent.Name == example.Intent {
+			correct++
+		} else {
+			report.Errors = append(report.Errors, Misclassification{
+				Text:      example.Text,
+				Expected:  example.Intent,
+				Predicted: intent.Name,
+			})
+		}
+
+		for name, expected := range example.Entities {
+			metrics := report.Entities[name]
+			metrics.Expected++
+			entityExpected++
+			if value, ok := intent.Entities[name]; ok {
+				metrics.Extracted++
+				if fmt.Sprint(value) == expected {
+					metrics.Correct++
+					entityCorrect++
+				}
+			}
+			report.Entities[name] = metrics
+		}
+	}
+
+	report.Accuracy = ratio(correct, len(examples))
+	report.EntityAccuracy = ratio(entityCorrect, entityExpected)
+	for name, metrics := range report.Entities {
+		metrics.Accuracy = ratio(metrics.Correct, metrics.Expected)
+		report.Entities[name] = metrics
+	}
+
+	labels := report.Labels()
+	var f1Sum float64
+	for _, label := range labels {
+		truePositives := report.Confusion[label][label]
+		predicted, actual := 0, 0
+		for expected, row := range report.Confusion {
+			predicted += row[label]
+			if expected == label {
+				for _, count := range row {
+					actual += count
+				}
+			}
+		}
+
+		metrics := IntentMetrics{
+			Precision: ratio(truePositives, predicted),
+			Recall:    ratio(truePositives, actual),
+			Support:   actual,
+		}
+		if metrics.Precision+metrics.Recall > 0 {
+			metrics.F1 = 2 * metrics.Precision * metrics.Recall / (metrics.Precision + metrics.Recall)
+		}
+		report.Intents[label] = metrics
+		f1Sum += metrics.F1
+	}
+	if len(labels) > 0 {
+		report.MacroF1 = f1Sum / float64(len(labels))
+	}
+
+	return report, nil
+}
+
+// Labels returns every intent seen as expected or predicted, sorted by name
+func (r *Report) Labels() []string {
+	seen := make(map[string]bool)
+	for expected, row := range r.Confusion {
+		seen[expected] = true
+		for predicted := range row {
+			seen[predicted] = true
+		}
+	}
+
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Print writes a human-readable summary, per-intent scores and the confusion matrix
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Examples:        %d\n", r.Examples)
+	fmt.Fprintf(w, "Accuracy:        %.3f\n", r.Accuracy)
+	fmt.Fprintf(w, "Macro F1:        %.3f\n", r.MacroF1)
+	fmt.Fprintf(w, "Entity accuracy: %.3f\n\n", r.EntityAccuracy)
+
+	labels := r.Labels()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "intent\tprecision\trecall\tf1\tsupport\t")
+	for _, label := range labels {
+		m := r.Intents[label]
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%d\t\n", label, m.Precision, m.Recall, m.F1, m.Support)
+	}
+	tw.Flush()
+
+	if len(r.Entities) > 0 {
+		fmt.Fprintln(w)
+		names := make([]string, 0, len(r.Entities))
+		for name := range r.Entities {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintln(tw, "entity\texpected\textracted\tcorrect\taccuracy\t")
+		for _, name := range names {
+			m := r.Entities[name]
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.3f\t\n", name, m.Expected, m.Extracted, m.Correct, m.Accuracy)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w, "\nConfusion matrix (rows: expected, columns: predicted)")
+	header := []string{""}
+	for i := range labels {
+		header = append(header, fmt.Sprintf("[%d]", i))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for i, expected := range labels {
+		row := []string{fmt.Sprintf("[%d] %s", i, expected)}
+		for _, predicted := range labels {
+			row = append(row, fmt.Sprint(r.Confusion[expected][predicted]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	tw.Flush()
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w, "\nMisclassified examples")
+		for _, e := range r.Errors {
+			fmt.Fprintf(w, "  %-20s -> %-20s %q\n", e.Expected, e.Predicted, e.Text)
+		}
+	}
+}
+
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
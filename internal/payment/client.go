@@ -0,0 +1,87 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to the payment service provider's payout API
+type Client struct {
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+}
+
+// NewClient creates a new payout API client
+func NewClient(apiKey, baseURL string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// CreatePayout starts a payout. Retrying with the same idempotency key is safe.
+func (c *Client) CreatePayout(ctx context.Context, payoutRequest PayoutRequest) (*Payout, error) {
+	body, err := json.Marshal(payoutRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payout request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/payouts", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payout request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", payoutRequest.IdempotencyKey)
+
+	var payout Payout
+	if err := c.do(req, &payout); err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+// GetPayout returns the current status of a payout
+func (c *Client) GetPayout(ctx context.Context, id string) (*Payout, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/payouts/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payout request: %w", err)
+	}
+
+	var payout Payout
+	if err := c.do(req, &payout); err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call payout API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("payout API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode payout response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package payment pays refunds back to customers through the payment service
+// provider's payout API.
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"callcenter/internal/money"
+)
+
+// Payout statuses reported by the gateway
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ErrNotFound is returned when the gateway does not know a payout
+var ErrNotFound = errors.New("payout not found")
+
+// PayoutRequest asks the gateway to pay an amount back to a customer
+type PayoutRequest struct {
+	// IdempotencyKey identifies one payout attempt of a refund request. Sending
+	// the same key again returns the payout already created for it instead of
+	// paying twice.
+	IdempotencyKey string       `json:"-"`
+	Reference      string       `json:"reference"` // our refund request
+	CustomerID     string       `json:"customer_id"`
+	Amount         money.Amount `json:"amount"` // in minor units of the currency
+	Currency       string       `json:"currency"`
+	Description    string       `json:"description"`
+	CallbackURL    string       `json:"callback_url,omitempty"`
+}
+
+// Payout is the gateway's record of a payout
+type Payout struct {
+	ID            string       `json:"id"`
+	Reference     string       `json:"reference"`
+	Status        string       `json:"status"` // pending, succeeded, failed
+	FailureReason string       `json:"failure_reason,omitempty"`
+	Amount        money.Amount `json:"amount"`
+	Currency      string       `json:"currency"`
+}
+
+// Final reports whether the payout will not change status anymore
+func (p *Payout) Final() bool {
+	return p.Status == StatusSucceeded || p.Status == StatusFailed
+}
+
+// Gateway creates payouts and reports their status
+type Gateway interface {
+	CreatePayout(ctx context.Context, req PayoutRequest) (*Payout, error)
+	GetPayout(ctx context.Context, id string) (*Payout, error)
+}
+
+// SignatureHeader carries the signature of callback bodies
+const SignatureHeader = "X-Signature"
+
+// Sign returns the hex HMAC-SHA256 of a callback body under the webhook secret
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the gateway's signature of body
+func VerifySignature(body []byte, signature, secret string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(body, secret)), []byte(signature))
+}
@@ -0,0 +1,142 @@
+// Package paymenttest provides an in-memory payment gateway for exercising the
+// refund payout flow without a payment service provider.
+package paymenttest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/payment"
+)
+
+// Gateway is a fake payment gateway. Payouts stay pending until they are
+// settled or failed, unless AutoSettle is set.
+type Gateway struct {
+	// AutoSettle makes new payouts succeed immediately
+	AutoSettle bool
+
+	mu       sync.Mutex
+	payouts  map[string]*payment.Payout
+	byKey    map[string]string // idempotency key to payout ID
+	requests []payment.PayoutRequest
+}
+
+// NewGateway creates an empty fake gateway
+func NewGateway() *Gateway {
+	return &Gateway{
+		payouts: make(map[string]*payment.Payout),
+		byKey:   make(map[string]string),
+	}
+}
+
+// CreatePayout records a payout, or returns the one created earlier with the same idempotency key
+func (g *Gateway) CreatePayout(ctx context.Context, req payment.PayoutRequest) (*payment.Payout, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.requests = append(g.requests, req)
+	if id, ok := g.byKey[req.IdempotencyKey]; ok {
+		payout := *g.payouts[id]
+		return &payout, nil
+	}
+
+	// IDs are unique across gateways, as a provider's are, so payouts stored
+	// by an earlier run never match a new one
+	payout := &payment.Payout{
+		ID:        "po_" + uuid.NewString(),
+		Reference: req.Reference,
+		Status:    payment.StatusPending,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+	}
+	if g.AutoSettle {
+		payout.Status = payment.StatusSucceeded
+	}
+	g.payouts[payout.ID] = payout
+	if req.IdempotencyKey != "" {
+		g.byKey[req.IdempotencyKey] = payout.ID
+	}
+
+	copied := *payout
+	return &copied, nil
+}
+
+// GetPayout returns a payout by ID
+func (g *Gateway) GetPayout(ctx context.Context, id string) (*payment.Payout, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payout, ok := g.payouts[id]
+	if !ok {
+		return nil, payment.ErrNotFound
+	}
+	copied := *payout
+	return &copied, nil
+}
+
+// Settle marks a payout as succeeded
+func (g *Gateway) Settle(id string) error {
+	return g.finish(id, payment.StatusSucceeded, "")
+}
+
+// Fail marks a payout as failed with a reason
+func (g *Gateway) Fail(id, reason string) error {
+	return g.finish(id, payment.StatusFailed, reason)
+}
+
+func (g *Gateway) finish(id, status, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payout, ok := g.payouts[id]
+	if !ok {
+		return payment.ErrNotFound
+	}
+	payout.Status = status
+	payout.FailureReason = reason
+	return nil
+}
+
+// Requests returns the payout requests received so far, retries included
+func (g *Gateway) Requests() []payment.PayoutRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]payment.PayoutRequest(nil), g.requests...)
+}
+
+// NewServer serves the gateway over the payout API so payment.Client can be
+// pointed at it
+func NewServer(g *Gateway) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/payouts":
+			var req payment.PayoutRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+			payout, _ := g.CreatePayout(r.Context(), req)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(payout)
+
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/payouts/"):
+			payout, err := g.GetPayout(r.Context(), strings.TrimPrefix(r.URL.Path, "/payouts/"))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(payout)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
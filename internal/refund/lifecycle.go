@@ -0,0 +1,73 @@
+package refund
+
+import (
+	"errors"
+	"fmt"
+
+	"callcenter/internal/models"
+)
+
+// Errors returned by CheckTransition
+var (
+	ErrIllegalTransition = errors.New("illegal refund transition")
+	ErrNotPermitted      = errors.New("refund transition not permitted for role")
+	ErrReasonRequired    = errors.New("refund transition requires a reason")
+)
+
+// Transition is an allowed change of a refund request's status
+type Transition struct {
+	From           string
+	To             string
+	Roles          []string // roles allowed to make the change
+	ReasonRequired bool
+}
+
+// Transitions is the refund lifecycle: a pending refund is approved or rejected
+// by a supervisor, an approved refund is paid out, and a failed payout can be
+// retried. Rejected and processed refunds are final.
+var Transitions = []Transition{
+	{From: models.RefundStatusPending, To: models.RefundStatusApproved, Roles: []string{models.RoleSupervisor, models.RoleAdmin}},
+	{From: models.RefundStatusPending, To: models.RefundStatusRejected, Roles: []string{models.RoleSupervisor, models.RoleAdmin}, ReasonRequired: true},
+	{From: models.RefundStatusApproved, To: models.RefundStatusProcessing, Roles: []string{models.RoleSupervisor, models.RoleAdmin, models.RoleSystem}},
+	{From: models.RefundStatusProcessing, To: models.RefundStatusProcessed, Roles: []string{models.RoleAdmin, models.RoleSystem}},
+	{From: models.RefundStatusProcessing, To: models.RefundStatusFailed, Roles: []string{models.RoleAdmin, models.RoleSystem}, ReasonRequired: true},
+	{From: models.RefundStatusFailed, To: models.RefundStatusProcessing, Roles: []string{models.RoleSupervisor, models.RoleAdmin, models.RoleSystem}},
+}
+
+// CheckTransition reports whether an actor with the given role may move a
+// refund request from one status to another
+func CheckTransition(from, to, role, reason string) error {
+	for _, transition := range Transitions {
+		if transition.From != from || transition.To != to {
+			continue
+		}
+		if !contains(transition.Roles, role) {
+			return fmt.Errorf("%w: %s cannot move a refund from %s to %s", ErrNotPermitted, role, from, to)
+		}
+		if transition.ReasonRequired && reason == "" {
+			return fmt.Errorf("%w: moving a refund to %s", ErrReasonRequired, to)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s to %s", ErrIllegalTransition, from, to)
+}
+
+// NextStatuses lists the statuses a refund request can move to from status
+func NextStatuses(status string) []string {
+	var next []string
+	for _, transition := range Transitions {
+		if transition.From == status {
+			next = append(next, transition.To)
+		}
+	}
+	return next
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
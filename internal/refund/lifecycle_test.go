@@ -0,0 +1,37 @@
+package refund
+
+import (
+	"errors"
+	"testing"
+
+	"callcenter/internal/models"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to, role, reason string
+		want                   error // nil when the change is allowed
+	}{
+		{from: models.RefundStatusPending, to: models.RefundStatusApproved, role: models.RoleSupervisor},
+		{from: models.RefundStatusPending, to: models.RefundStatusApproved, role: models.RoleAgent, want: ErrNotPermitted},
+		{from: models.RefundStatusPending, to: models.RefundStatusRejected, role: models.RoleAdmin, reason: "Fare is non-refundable"},
+		{from: models.RefundStatusPending, to: models.RefundStatusRejected, role: models.RoleAdmin, want: ErrReasonRequired},
+		{from: models.RefundStatusPending, to: models.RefundStatusProcessed, role: models.RoleAdmin, want: ErrIllegalTransition},
+		{from: models.RefundStatusApproved, to: models.RefundStatusProcessing, role: models.RoleSystem},
+		{from: models.RefundStatusProcessing, to: models.RefundStatusProcessed, role: models.RoleSystem},
+		{from: models.RefundStatusProcessing, to: models.RefundStatusProcessed, role: models.RoleSupervisor, want: ErrNotPermitted},
+		{from: models.RefundStatusProcessing, to: models.RefundStatusFailed, role: models.RoleSystem, reason: "Card expired"},
+		{from: models.RefundStatusFailed, to: models.RefundStatusProcessing, role: models.RoleSupervisor},
+		{from: models.RefundStatusRejected, to: models.RefundStatusProcessed, role: models.RoleAdmin, want: ErrIllegalTransition},
+		{from: models.RefundStatusProcessed, to: models.RefundStatusFailed, role: models.RoleSystem, reason: "Chargeback", want: ErrIllegalTransition},
+		{from: models.RefundStatusRejected, to: models.RefundStatusPending, role: models.RoleAdmin, want: ErrIllegalTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to+" by "+tt.role, func(t *testing.T) {
+			if err := CheckTransition(tt.from, tt.to, tt.role, tt.reason); !errors.Is(err, tt.want) {
+				t.Errorf("CheckTransition = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+// Package refund computes how much of a cancelled booking is paid back under
+// the refund policies of the airlines.
+package refund
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+)
+
+// ErrNoItinerary is returned when a booking has no flight to measure the notice period against
+var ErrNoItinerary = errors.New("booking has no segments")
+
+// DefaultPolicies apply when no stored policy matches a booking: charter fares
+// lose half of their base fare and systematic fares a fifth, at any notice.
+var DefaultPolicies = []models.RefundPolicy{
+	{
+		Name:                 "Default charter",
+		TicketType:           models.TicketTypeCharter,
+		NoShowPenaltyPercent: 100,
+		Tiers:                []models.RefundPenaltyTier{{MinHoursBeforeDeparture: 0, PenaltyPercent: 50}},
+	},
+	{
+		Name:                 "Default systematic",
+		TicketType:           models.TicketTypeSystematic,
+		NoShowPenaltyPercent: 100,
+		Tiers:                []models.RefundPenaltyTier{{MinHoursBeforeDeparture: 0, PenaltyPercent: 20}},
+	},
+}
+
+// ErrNoCoupons is returned when a refund is computed for no coupon at all
+var ErrNoCoupons = errors.New("no coupons to refund")
+
+// ErrUnknownCoupon is returned for a coupon whose passenger or segment is not on the booking
+var ErrUnknownCoupon = errors.New("coupon is not on the booking")
+
+// ErrDuplicateCoupon is returned when the same coupon is listed more than once
+var ErrDuplicateCoupon = errors.New("coupon is listed more than once")
+
+// Coupon is one passenger's seat on one segment, the unit refunds are computed for
+type Coupon struct {
+	PassengerID uuid.UUID
+	SegmentID   uuid.UUID
+}
+
+// Line is the refund of one coupon
+type Line struct {
+	PassengerID   uuid.UUID    `json:"passenger_id"`
+	SegmentID     uuid.UUID    `json:"segment_id"`
+	BaseFare      money.Amount `json:"base_fare"`
+	Taxes         money.Amount `json:"taxes"`
+	Penalty       money.Amount `json:"penalty"`
+	Fee           money.Amount `json:"fee"`
+	TaxesRefunded money.Amount `json:"taxes_refunded"`
+	Amount        money.Amount `json:"amount"`
+}
+
+// Quote is the refund breakdown of a booking, or some of its coupons, under a
+// policy. Its amounts are the sums of its lines.
+type Quote struct {
+	PolicyID             *uuid.UUID   `json:"policy_id,omitempty"`
+	PolicyName           string       `json:"policy_name"`
+	HoursBeforeDeparture float64      `json:"hours_before_departure"`
+	NoShow               bool         `json:"no_show"`
+	NonRefundable        bool         `json:"non_refundable"`
+	BaseFare             money.Amount `json:"base_fare"`
+	Taxes                money.Amount `json:"taxes"`
+	PenaltyPercent       float64      `json:"penalty_percent"`
+	Penalty              money.Amount `json:"penalty"`
+	Fee                  money.Amount `json:"fee"`
+	TaxesRefunded        money.Amount `json:"taxes_refunded"`
+	Amount               money.Amount `json:"amount"`
+	Currency             string       `json:"currency"`
+	Lines                []Line       `json:"lines"`
+}
+
+// Select returns the policy among candidates that matches the booking most
+// specifically, falling back to DefaultPolicies. An airline match outweighs a
+// fare class match, which outweighs a ticket type match. It returns nil when
+// nothing matches.
+func Select(candidates []models.RefundPolicy, booking *models.Booking) *models.RefundPolicy {
+	if policy := mostSpecific(candidates, booking); policy != nil {
+		return policy
+	}
+	return mostSpecific(DefaultPolicies, booking)
+}
+
+func mostSpecific(candidates []models.RefundPolicy, booking *models.Booking) *models.RefundPolicy {
+	var best *models.RefundPolicy
+	bestScore := -1
+	for i := range candidates {
+		policy := &candidates[i]
+		score, ok := specificity(policy, booking)
+		if ok && score > bestScore {
+			best, bestScore = policy, score
+		}
+	}
+	return best
+}
+
+// specificity scores how precisely a policy targets a booking; ok is false when
+// the policy does not apply to it at all
+func specificity(policy *models.RefundPolicy, booking *models.Booking) (score int, ok bool) {
+	fields := []struct {
+		policy, booking string
+		weight          int
+	}{
+		{policy.Airline, booking.Airline, 4},
+		{policy.FareClass, booking.FareClass, 2},
+		{policy.TicketType, booking.TicketType, 1},
+	}
+	for _, field := range fields {
+		switch field.policy {
+		case "":
+		case field.booking:
+			score += field.weight
+		default:
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// Compute applies a policy to a whole booking cancelled at the given time
+func Compute(policy *models.RefundPolicy, booking *models.Booking, at time.Time) (*Quote, error) {
+	if IsLegacy(booking) {
+		return computeLegacy(policy, booking), nil
+	}
+	return ComputeCoupons(policy, booking, AllCoupons(booking), at)
+}
+
+// IsLegacy reports whether a booking was migrated from a support ticket, which
+// kept its fares but no passengers or flights. Such bookings have no coupons and
+// are only cancelled whole.
+func IsLegacy(booking *models.Booking) bool {
+	return len(booking.Passengers) == 0 && len(booking.Segments) == 0
+}
+
+// computeLegacy applies a policy to a whole legacy booking. Without a flight the
+// notice period is unknown, so the cancellation is never a no-show and the tier
+// with the shortest notice applies. The fixed fee is charged once.
+func computeLegacy(policy *models.RefundPolicy, booking *models.Booking) *Quote {
+	quote := &Quote{
+		PolicyName:    policy.Name,
+		NonRefundable: policy.NonRefundable,
+		BaseFare:      booking.BaseFare,
+		Taxes:         booking.Taxes,
+		Currency:      booking.Currency,
+	}
+	if policy.ID != uuid.Nil {
+		id := policy.ID
+		quote.PolicyID = &id
+	}
+
+	quote.PenaltyPercent = 100
+	if !policy.NonRefundable {
+		quote.PenaltyPercent = shortestNoticePenalty(policy.Tiers)
+	}
+	quote.Penalty = money.MustLookup(booking.Currency).Percent(booking.BaseFare, quote.PenaltyPercent)
+	if !policy.TaxesNonRefundable {
+		quote.TaxesRefunded = booking.Taxes
+	}
+	refundable := quote.BaseFare - quote.Penalty + quote.TaxesRefunded
+	quote.Fee = money.Min(policy.FixedFee, refundable)
+	quote.Amount = refundable - quote.Fee
+	return quote
+}
+
+// AllCoupons lists every coupon of a booking: each passenger on each segment
+func AllCoupons(booking *models.Booking) []Coupon {
+	return SelectCoupons(booking, nil, nil)
+}
+
+// SelectCoupons lists the coupons of the given passengers on the given segments;
+// an empty list stands for all passengers or all segments of the booking
+func SelectCoupons(booking *models.Booking, passengerIDs, segmentIDs []uuid.UUID) []Coupon {
+	if len(passengerIDs) == 0 {
+		for _, passenger := range booking.Passengers {
+			passengerIDs = append(passengerIDs, passenger.ID)
+		}
+	}
+	if len(segmentIDs) == 0 {
+		for _, segment := range booking.Segments {
+			segmentIDs = append(segmentIDs, segment.ID)
+		}
+	}
+
+	var coupons []Coupon
+	for _, passengerID := range passengerIDs {
+		for _, segmentID := range segmentIDs {
+			coupons = append(coupons, Coupon{PassengerID: passengerID, SegmentID: segmentID})
+		}
+	}
+	return coupons
+}
+
+// WithoutCancelled returns the coupons that are not among the cancelled ones, in order
+func WithoutCancelled(coupons []Coupon, cancelled map[Coupon]bool) []Coupon {
+	open := []Coupon{}
+	for _, coupon := range coupons {
+		if !cancelled[coupon] {
+			open = append(open, coupon)
+		}
+	}
+	return open
+}
+
+// ComputeCoupons applies a policy to some coupons of a booking cancelled at the
+// given time.
+//
+// A passenger's fares are split evenly over the segments of the booking, the
+// remainder going to the last one. The penalty is a share of the base fare of
+// each coupon: the tier with the largest notice the cancellation still meets,
+// measured against the earliest departure among the coupons; the no-show penalty
+// once that flight has departed; all of it for non-refundable fares; and all of
+// it when the notice is shorter than every tier. Taxes are returned unless the
+// policy keeps them. The fixed fee is charged once per passenger, on their
+// coupons in flight order, and never makes a refund negative. Penalties are
+// rounded for the booking currency per coupon. Each coupon may be listed once.
+func ComputeCoupons(policy *models.RefundPolicy, booking *models.Booking, coupons []Coupon, at time.Time) (*Quote, error) {
+	if len(booking.Segments) == 0 {
+		return nil, ErrNoItinerary
+	}
+	if len(coupons) == 0 {
+		return nil, ErrNoCoupons
+	}
+
+	passengers := make(map[uuid.UUID]*models.Passenger)
+	for i := range booking.Passengers {
+		passengers[booking.Passengers[i].ID] = &booking.Passengers[i]
+	}
+	segments := inFlightOrder(booking.Segments)
+	position := make(map[uuid.UUID]int)
+	for i, segment := range segments {
+		position[segment.ID] = i
+	}
+
+	var departure time.Time
+	listed := make(map[Coupon]bool, len(coupons))
+	for _, coupon := range coupons {
+		i, ok := position[coupon.SegmentID]
+		if _, known := passengers[coupon.PassengerID]; !ok || !known {
+			return nil, ErrUnknownCoupon
+		}
+		if listed[coupon] {
+			return nil, ErrDuplicateCoupon
+		}
+		listed[coupon] = true
+		if departure.IsZero() || segments[i].DepartureAt.Before(departure) {
+			departure = segments[i].DepartureAt
+		}
+	}
+	hours := departure.Sub(at).Hours()
+
+	quote := &Quote{
+		PolicyName:           policy.Name,
+		HoursBeforeDeparture: roundTo(hours, 2),
+		NoShow:               hours <= 0,
+		NonRefundable:        policy.NonRefundable,
+		Currency:             booking.Currency,
+	}
+	if policy.ID != uuid.Nil {
+		id := policy.ID
+		quote.PolicyID = &id
+	}
+
+	switch {
+	case policy.NonRefundable:
+		quote.PenaltyPercent = 100
+	case quote.NoShow:
+		quote.PenaltyPercent = policy.NoShowPenaltyPercent
+	default:
+		quote.PenaltyPercent = tierPenalty(policy.Tiers, hours)
+	}
+
+	// Lines are built in flight order so each passenger's fee falls on their earliest coupons
+	sorted := append([]Coupon(nil), coupons...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return position[sorted[i].SegmentID] < position[sorted[j].SegmentID]
+	})
+
+	currency := money.MustLookup(booking.Currency)
+	feeDue := make(map[uuid.UUID]money.Amount)
+	for _, coupon := range sorted {
+		passenger := passengers[coupon.PassengerID]
+		if _, seen := feeDue[passenger.ID]; !seen {
+			feeDue[passenger.ID] = policy.FixedFee
+		}
+
+		last := position[coupon.SegmentID] == len(segments)-1
+		line := Line{
+			PassengerID: coupon.PassengerID,
+			SegmentID:   coupon.SegmentID,
+			BaseFare:    share(passenger.BaseFare, len(segments), last),
+			Taxes:       share(passenger.Taxes, len(segments), last),
+		}
+		line.Penalty = currency.Percent(line.BaseFare, quote.PenaltyPercent)
+		if !policy.TaxesNonRefundable {
+			line.TaxesRefunded = line.Taxes
+		}
+		refundable := line.BaseFare - line.Penalty + line.TaxesRefunded
+		line.Fee = money.Min(feeDue[passenger.ID], refundable)
+		feeDue[passenger.ID] -= line.Fee
+		line.Amount = refundable - line.Fee
+
+		quote.BaseFare += line.BaseFare
+		quote.Taxes += line.Taxes
+		quote.Penalty += line.Penalty
+		quote.Fee += line.Fee
+		quote.TaxesRefunded += line.TaxesRefunded
+		quote.Amount += line.Amount
+		quote.Lines = append(quote.Lines, line)
+	}
+	return quote, nil
+}
+
+// share is the part of a passenger's fare that falls on one of n segments
+func share(fare money.Amount, n int, last bool) money.Amount {
+	part := fare / money.Amount(n)
+	if last {
+		part += fare % money.Amount(n)
+	}
+	return part
+}
+
+// inFlightOrder returns the segments sorted by departure
+func inFlightOrder(segments []models.Segment) []models.Segment {
+	sorted := append([]models.Segment(nil), segments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DepartureAt.Before(sorted[j].DepartureAt)
+	})
+	return sorted
+}
+
+// tierPenalty returns the penalty of the tier with the largest notice that the
+// cancellation meets, or 100 when it meets none
+func tierPenalty(tiers []models.RefundPenaltyTier, hours float64) float64 {
+	sorted := make([]models.RefundPenaltyTier, len(tiers))
+	copy(sorted, tiers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MinHoursBeforeDeparture > sorted[j].MinHoursBeforeDeparture
+	})
+	for _, tier := range sorted {
+		if hours >= float64(tier.MinHoursBeforeDeparture) {
+			return tier.PenaltyPercent
+		}
+	}
+	return 100
+}
+
+// shortestNoticePenalty returns the penalty of the tier with the shortest
+// notice, or 100 without tiers
+func shortestNoticePenalty(tiers []models.RefundPenaltyTier) float64 {
+	penalty := 100.0
+	shortest := -1
+	for _, tier := range tiers {
+		if shortest < 0 || tier.MinHoursBeforeDeparture < shortest {
+			penalty, shortest = tier.PenaltyPercent, tier.MinHoursBeforeDeparture
+		}
+	}
+	return penalty
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
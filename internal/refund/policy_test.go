@@ -0,0 +1,571 @@
+package refund
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+)
+
+// caseTime is when the cancellations in the tests are made
+var caseTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// sampleBooking builds an IRR booking departing the given number of hours after
+// caseTime, with passengers paying 20,000,000 base fare and 2,000,000 taxes each
+func sampleBooking(ticketType, airline, fareClass string, passengers int, hoursBeforeDeparture float64) models.Booking {
+	booking := models.Booking{
+		PNR:        "ABC123",
+		Airline:    airline,
+		FareClass:  fareClass,
+		TicketType: ticketType,
+		Currency:   "IRR",
+	}
+	for i := 0; i < passengers; i++ {
+		booking.Passengers = append(booking.Passengers, models.Passenger{ID: caseID(1, i), BaseFare: 20000000, Taxes: 2000000})
+		booking.BaseFare += 20000000
+		booking.Taxes += 2000000
+	}
+	booking.TotalFare = booking.BaseFare + booking.Taxes
+	departure := caseTime.Add(time.Duration(hoursBeforeDeparture * float64(time.Hour)))
+	booking.Segments = []models.Segment{
+		{ID: caseID(2, 0), Sequence: 1, Airline: airline, Origin: "THR", Destination: "MHD", DepartureAt: departure},
+		{ID: caseID(2, 1), Sequence: 2, Airline: airline, Origin: "MHD", Destination: "THR", DepartureAt: departure.Add(72 * time.Hour)},
+	}
+	return booking
+}
+
+// caseID is a fixed ID for the i-th passenger (kind 1) or segment (kind 2) of a sample booking
+func caseID(kind, i int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-0000-%04d-%012d", kind, i))
+}
+
+// tieredPolicy keeps 10% a week ahead, 30% three days ahead and 50% up to three
+// hours before departure, plus a 500,000 fee per passenger
+var tieredPolicy = models.RefundPolicy{
+	Name:                 "IR economy",
+	Airline:              "IR",
+	TicketType:           models.TicketTypeSystematic,
+	FixedFee:             500000,
+	NoShowPenaltyPercent: 100,
+	Tiers: []models.RefundPenaltyTier{
+		{MinHoursBeforeDeparture: 72, PenaltyPercent: 30},
+		{MinHoursBeforeDeparture: 168, PenaltyPercent: 10},
+		{MinHoursBeforeDeparture: 3, PenaltyPercent: 50},
+	},
+}
+
+func withChanges(policy models.RefundPolicy, change func(*models.RefundPolicy)) models.RefundPolicy {
+	change(&policy)
+	return policy
+}
+
+// withFare gives every passenger of a booking the same fares in a currency
+func withFare(booking models.Booking, currency string, baseFare, taxes money.Amount) models.Booking {
+	booking.Currency = currency
+	booking.BaseFare, booking.Taxes = 0, 0
+	for i := range booking.Passengers {
+		booking.Passengers[i].BaseFare = baseFare
+		booking.Passengers[i].Taxes = taxes
+		booking.BaseFare += baseFare
+		booking.Taxes += taxes
+	}
+	booking.TotalFare = booking.BaseFare + booking.Taxes
+	return booking
+}
+
+func reversedSegments(booking models.Booking) models.Booking {
+	segments := booking.Segments
+	booking.Segments = []models.Segment{segments[1], segments[0]}
+	return booking
+}
+
+func TestTierPenalty(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers []models.RefundPenaltyTier
+		hours float64
+		want  float64
+	}{
+		{"far ahead", tieredPolicy.Tiers, 1000, 10},
+		{"on the week boundary", tieredPolicy.Tiers, 168, 10},
+		{"just under the week boundary", tieredPolicy.Tiers, 167.99, 30},
+		{"on the three day boundary", tieredPolicy.Tiers, 72, 30},
+		{"just under the three day boundary", tieredPolicy.Tiers, 71.99, 50},
+		{"on the last boundary", tieredPolicy.Tiers, 3, 50},
+		{"just under the last boundary", tieredPolicy.Tiers, 2.99, 100},
+		{"no tiers", nil, 1000, 100},
+		{"a tier from departure", []models.RefundPenaltyTier{{MinHoursBeforeDeparture: 0, PenaltyPercent: 40}}, 0.01, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tierPenalty(tt.tiers, tt.hours); got != tt.want {
+				t.Errorf("tierPenalty(%v) = %v, want %v", tt.hours, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeCoupons(t *testing.T) {
+	fixedFeeOnly := withChanges(tieredPolicy, func(p *models.RefundPolicy) {
+		p.Tiers = []models.RefundPenaltyTier{{MinHoursBeforeDeparture: 0, PenaltyPercent: 0}}
+	})
+	percentOnly := withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.FixedFee = 0 })
+	outbound := Coupon{PassengerID: caseID(1, 0), SegmentID: caseID(2, 0)}
+	inbound := Coupon{PassengerID: caseID(1, 0), SegmentID: caseID(2, 1)}
+
+	tests := []struct {
+		name    string
+		policy  models.RefundPolicy
+		booking models.Booking
+		coupons []Coupon // nil for the whole booking
+		want    Quote
+	}{
+		{
+			name:    "a week ahead keeps the lowest tier",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    Quote{PenaltyPercent: 10, Penalty: 2000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 19500000},
+		},
+		{
+			name:    "the tier boundary is inclusive",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 72),
+			want:    Quote{PenaltyPercent: 30, Penalty: 6000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 15500000},
+		},
+		{
+			name:    "just under a boundary falls to the next tier",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 71.5),
+			want:    Quote{PenaltyPercent: 50, Penalty: 10000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 11500000},
+		},
+		{
+			name:    "notice shorter than every tier keeps the base fare",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 2),
+			want:    Quote{PenaltyPercent: 100, Penalty: 20000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 1500000},
+		},
+		{
+			name:    "the fee is charged per passenger",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 3, 240),
+			want:    Quote{PenaltyPercent: 10, Penalty: 6000000, Fee: 1500000, TaxesRefunded: 6000000, Amount: 58500000},
+		},
+		{
+			name:    "a departed flight is a no-show",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, -1),
+			want:    Quote{NoShow: true, PenaltyPercent: 100, Penalty: 20000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 1500000},
+		},
+		{
+			name:    "cancelling at departure time is a no-show",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NoShowPenaltyPercent = 80 }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 0),
+			want:    Quote{NoShow: true, PenaltyPercent: 80, Penalty: 16000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 5500000},
+		},
+		{
+			name:    "no-show with non-refundable taxes refunds nothing and charges no fee",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.TaxesNonRefundable = true }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, -24),
+			want:    Quote{NoShow: true, PenaltyPercent: 100, Penalty: 20000000, Fee: 0, TaxesRefunded: 0, Amount: 0},
+		},
+		{
+			name:    "non-refundable fares return only taxes",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NonRefundable = true }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    Quote{NonRefundable: true, PenaltyPercent: 100, Penalty: 20000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 1500000},
+		},
+		{
+			name:    "a fee larger than the refund is capped",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.FixedFee = 30000000 }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    Quote{PenaltyPercent: 10, Penalty: 2000000, Fee: 20000000, TaxesRefunded: 2000000, Amount: 0},
+		},
+		{
+			name:    "notice is measured against the earliest segment",
+			policy:  tieredPolicy,
+			booking: reversedSegments(sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 100)),
+			want:    Quote{PenaltyPercent: 30, Penalty: 6000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 15500000},
+		},
+		{
+			name:    "default charter policy keeps half of the base fare",
+			policy:  DefaultPolicies[0],
+			booking: sampleBooking(models.TicketTypeCharter, "W5", "", 1, 240),
+			want:    Quote{PenaltyPercent: 50, Penalty: 10000000, TaxesRefunded: 2000000, Amount: 12000000},
+		},
+		{
+			name:    "default systematic policy keeps a fifth of the base fare",
+			policy:  DefaultPolicies[1],
+			booking: sampleBooking(models.TicketTypeSystematic, "W5", "M", 2, 240),
+			want:    Quote{PenaltyPercent: 20, Penalty: 8000000, TaxesRefunded: 4000000, Amount: 36000000},
+		},
+		{
+			name:    "rial penalties are rounded to whole tomans per coupon",
+			policy:  DefaultPolicies[1],
+			booking: withFare(sampleBooking(models.TicketTypeSystematic, "W5", "M", 1, 240), "IRR", 12345670, 1000000),
+			want:    Quote{PenaltyPercent: 20, Penalty: 2469140, TaxesRefunded: 1000000, Amount: 10876530},
+		},
+		{
+			name:    "cent penalties are rounded half away from zero",
+			policy:  DefaultPolicies[0],
+			booking: withFare(sampleBooking(models.TicketTypeCharter, "TK", "", 1, 240), "USD", 12345, 1000),
+			want:    Quote{PenaltyPercent: 50, Penalty: 6173, TaxesRefunded: 1000, Amount: 7172},
+		},
+		{
+			name:    "cancelling one of three passengers refunds only their coupons",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 3, 240),
+			coupons: []Coupon{{PassengerID: caseID(1, 1), SegmentID: caseID(2, 0)}, {PassengerID: caseID(1, 1), SegmentID: caseID(2, 1)}},
+			want:    Quote{PenaltyPercent: 10, Penalty: 2000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 19500000},
+		},
+		{
+			name:    "cancelling the return leg measures the notice against it",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 24),
+			coupons: []Coupon{{PassengerID: caseID(1, 0), SegmentID: caseID(2, 1)}},
+			want:    Quote{PenaltyPercent: 30, Penalty: 3000000, Fee: 500000, TaxesRefunded: 1000000, Amount: 7500000},
+		},
+		{
+			name:    "just over the week boundary",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 168.01),
+			want:    Quote{PenaltyPercent: 10, Penalty: 2000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 19500000},
+		},
+		{
+			name:    "just under the week boundary",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 167.99),
+			want:    Quote{PenaltyPercent: 30, Penalty: 6000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 15500000},
+		},
+		{
+			name:    "on the last boundary",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 3),
+			want:    Quote{PenaltyPercent: 50, Penalty: 10000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 11500000},
+		},
+		{
+			name:    "a minute before departure",
+			policy:  tieredPolicy,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 1.0/60),
+			want:    Quote{PenaltyPercent: 100, Penalty: 20000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 1500000},
+		},
+		{
+			name:    "no-show uses its own penalty",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NoShowPenaltyPercent = 60 }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, -1.0/60),
+			want:    Quote{NoShow: true, PenaltyPercent: 60, Penalty: 12000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 9500000},
+		},
+		{
+			name:    "non-refundable beats a lower no-show penalty",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NonRefundable = true; p.NoShowPenaltyPercent = 50 }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, -24),
+			want:    Quote{NoShow: true, NonRefundable: true, PenaltyPercent: 100, Penalty: 20000000, Fee: 500000, TaxesRefunded: 2000000, Amount: 1500000},
+		},
+		{
+			name:    "non-refundable fare with non-refundable taxes",
+			policy:  withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NonRefundable = true; p.TaxesNonRefundable = true }),
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    Quote{NonRefundable: true, PenaltyPercent: 100, Penalty: 20000000, Amount: 0},
+		},
+		{
+			name:    "fixed fee only",
+			policy:  fixedFeeOnly,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 2, 240),
+			want:    Quote{PenaltyPercent: 0, Fee: 1000000, TaxesRefunded: 4000000, Amount: 43000000},
+		},
+		{
+			name:    "percentage only",
+			policy:  percentOnly,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 2, 240),
+			want:    Quote{PenaltyPercent: 10, Penalty: 4000000, TaxesRefunded: 4000000, Amount: 40000000},
+		},
+		{
+			name:    "fixed fee is charged once on the first of a passenger's coupons",
+			policy:  fixedFeeOnly,
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			coupons: []Coupon{inbound},
+			want:    Quote{Fee: 500000, TaxesRefunded: 1000000, Amount: 10500000},
+		},
+		{
+			name:    "IRR penalty rounds half up to a toman",
+			policy:  percentOnly,
+			booking: withFare(sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240), "IRR", 1000100, 0),
+			coupons: []Coupon{outbound},
+			want:    Quote{PenaltyPercent: 10, Penalty: 50010, Amount: 450040},
+		},
+		{
+			name:    "IRR penalty rounds down below half a toman",
+			policy:  percentOnly,
+			booking: withFare(sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240), "IRR", 1000080, 0),
+			coupons: []Coupon{outbound},
+			want:    Quote{PenaltyPercent: 10, Penalty: 50000, Amount: 450040},
+		},
+		{
+			name:    "odd IRR fares put the remainder on the last segment",
+			policy:  percentOnly,
+			booking: withFare(sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240), "IRR", 1000010, 0),
+			coupons: []Coupon{inbound},
+			want:    Quote{PenaltyPercent: 10, Penalty: 50000, Amount: 450005},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupons := tt.coupons
+			if coupons == nil {
+				coupons = AllCoupons(&tt.booking)
+			}
+			got, err := ComputeCoupons(&tt.policy, &tt.booking, coupons, caseTime)
+			if err != nil {
+				t.Fatalf("ComputeCoupons: %v", err)
+			}
+			if got.NoShow != tt.want.NoShow || got.NonRefundable != tt.want.NonRefundable {
+				t.Errorf("no_show, non_refundable = %v, %v, want %v, %v", got.NoShow, got.NonRefundable, tt.want.NoShow, tt.want.NonRefundable)
+			}
+			if got.PenaltyPercent != tt.want.PenaltyPercent {
+				t.Errorf("penalty_percent = %v, want %v", got.PenaltyPercent, tt.want.PenaltyPercent)
+			}
+			for _, field := range []struct {
+				name      string
+				got, want money.Amount
+			}{
+				{"penalty", got.Penalty, tt.want.Penalty},
+				{"fee", got.Fee, tt.want.Fee},
+				{"taxes_refunded", got.TaxesRefunded, tt.want.TaxesRefunded},
+				{"amount", got.Amount, tt.want.Amount},
+			} {
+				if field.got != field.want {
+					t.Errorf("%s = %d, want %d", field.name, field.got, field.want)
+				}
+			}
+			if len(got.Lines) != len(coupons) {
+				t.Errorf("got %d lines, want one per coupon (%d)", len(got.Lines), len(coupons))
+			}
+		})
+	}
+}
+
+func TestComputeLegacy(t *testing.T) {
+	legacy := models.Booking{TicketType: models.TicketTypeSystematic, BaseFare: 10000000, Taxes: 1000000, TotalFare: 11000000, Currency: "IRR"}
+
+	tests := []struct {
+		name   string
+		policy models.RefundPolicy
+		want   Quote
+	}{
+		{
+			name:   "the default keeps its only tier",
+			policy: DefaultPolicies[1],
+			want:   Quote{PenaltyPercent: 20, Penalty: 2000000, TaxesRefunded: 1000000, Amount: 9000000},
+		},
+		{
+			name:   "tiers fall back to the shortest notice",
+			policy: tieredPolicy,
+			want:   Quote{PenaltyPercent: 50, Penalty: 5000000, Fee: 500000, TaxesRefunded: 1000000, Amount: 5500000},
+		},
+		{
+			name:   "no tiers keep the base fare",
+			policy: withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.Tiers = nil }),
+			want:   Quote{PenaltyPercent: 100, Penalty: 10000000, Fee: 500000, TaxesRefunded: 1000000, Amount: 500000},
+		},
+		{
+			name:   "non-refundable with non-refundable taxes",
+			policy: withChanges(tieredPolicy, func(p *models.RefundPolicy) { p.NonRefundable = true; p.TaxesNonRefundable = true }),
+			want:   Quote{NonRefundable: true, PenaltyPercent: 100, Penalty: 10000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(&tt.policy, &legacy, caseTime)
+			if err != nil {
+				t.Fatalf("Compute: %v", err)
+			}
+			if got.NoShow || got.NonRefundable != tt.want.NonRefundable || got.PenaltyPercent != tt.want.PenaltyPercent {
+				t.Errorf("no_show, non_refundable, penalty_percent = %v, %v, %v, want false, %v, %v",
+					got.NoShow, got.NonRefundable, got.PenaltyPercent, tt.want.NonRefundable, tt.want.PenaltyPercent)
+			}
+			if got.BaseFare != legacy.BaseFare || got.Taxes != legacy.Taxes {
+				t.Errorf("fares = %d + %d, want the booking's %d + %d", got.BaseFare, got.Taxes, legacy.BaseFare, legacy.Taxes)
+			}
+			if got.Penalty != tt.want.Penalty || got.Fee != tt.want.Fee || got.TaxesRefunded != tt.want.TaxesRefunded || got.Amount != tt.want.Amount {
+				t.Errorf("penalty, fee, taxes_refunded, amount = %d, %d, %d, %d, want %d, %d, %d, %d",
+					got.Penalty, got.Fee, got.TaxesRefunded, got.Amount, tt.want.Penalty, tt.want.Fee, tt.want.TaxesRefunded, tt.want.Amount)
+			}
+			if len(got.Lines) != 0 {
+				t.Errorf("got %d lines, want none for a booking without coupons", len(got.Lines))
+			}
+		})
+	}
+}
+
+func TestComputeCouponsErrors(t *testing.T) {
+	booking := sampleBooking(models.TicketTypeSystematic, "IR", "Y", 2, 240)
+	outbound := Coupon{PassengerID: caseID(1, 0), SegmentID: caseID(2, 0)}
+
+	tests := []struct {
+		name    string
+		booking models.Booking
+		coupons []Coupon
+		want    error
+	}{
+		{"no coupons", booking, []Coupon{}, ErrNoCoupons},
+		{"duplicate coupon", booking, []Coupon{outbound, {PassengerID: caseID(1, 1), SegmentID: caseID(2, 0)}, outbound}, ErrDuplicateCoupon},
+		{"unknown passenger", booking, []Coupon{{PassengerID: caseID(1, 9), SegmentID: caseID(2, 0)}}, ErrUnknownCoupon},
+		{"unknown segment", booking, []Coupon{{PassengerID: caseID(1, 0), SegmentID: caseID(2, 9)}}, ErrUnknownCoupon},
+		{"no segments", models.Booking{Currency: "IRR", Passengers: booking.Passengers}, []Coupon{outbound}, ErrNoItinerary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ComputeCoupons(&tieredPolicy, &tt.booking, tt.coupons, caseTime)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutCancelled(t *testing.T) {
+	booking := sampleBooking(models.TicketTypeSystematic, "IR", "Y", 2, 240)
+	all := AllCoupons(&booking)
+
+	tests := []struct {
+		name      string
+		cancelled []Coupon
+		want      int
+	}{
+		{"nothing cancelled", nil, 4},
+		{"one coupon cancelled", all[:1], 3},
+		{"a passenger cancelled", []Coupon{all[0], all[1]}, 2},
+		{"everything cancelled", all, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancelled := make(map[Coupon]bool)
+			for _, coupon := range tt.cancelled {
+				cancelled[coupon] = true
+			}
+			open := WithoutCancelled(all, cancelled)
+			if len(open) != tt.want {
+				t.Fatalf("got %d open coupons, want %d", len(open), tt.want)
+			}
+			for _, coupon := range open {
+				if cancelled[coupon] {
+					t.Errorf("cancelled coupon %v is still open", coupon)
+				}
+			}
+			if len(open) > 0 {
+				if _, err := ComputeCoupons(&tieredPolicy, &booking, open, caseTime); err != nil {
+					t.Errorf("ComputeCoupons of the open coupons: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	booking := sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240)
+	tests := []struct {
+		name       string
+		candidates []models.RefundPolicy
+		booking    models.Booking
+		want       string
+	}{
+		{
+			name:       "fare class beats ticket type",
+			candidates: []models.RefundPolicy{{Name: "systematic", TicketType: models.TicketTypeSystematic}, {Name: "Y", FareClass: "Y"}},
+			booking:    booking,
+			want:       "Y",
+		},
+		{
+			name:       "all three fields beat airline and fare class",
+			candidates: []models.RefundPolicy{{Name: "IR Y", Airline: "IR", FareClass: "Y"}, {Name: "IR Y systematic", Airline: "IR", FareClass: "Y", TicketType: models.TicketTypeSystematic}},
+			booking:    booking,
+			want:       "IR Y systematic",
+		},
+		{
+			name:       "a catch-all policy beats the defaults",
+			candidates: []models.RefundPolicy{{Name: "Everything"}},
+			booking:    booking,
+			want:       "Everything",
+		},
+		{
+			name:       "the first of equally specific policies wins",
+			candidates: []models.RefundPolicy{{Name: "first", Airline: "IR"}, {Name: "second", Airline: "IR"}},
+			booking:    booking,
+			want:       "first",
+		},
+		{
+			name:       "a fare class mismatch excludes the policy",
+			candidates: []models.RefundPolicy{{Name: "IR M", Airline: "IR", FareClass: "M"}},
+			booking:    booking,
+			want:       "Default systematic",
+		},
+		{
+			name:    "charter bookings fall back to the charter default",
+			booking: sampleBooking(models.TicketTypeCharter, "W5", "", 1, 240),
+			want:    "Default charter",
+		},
+		{
+			name: "airline and fare class beat airline only",
+			candidates: []models.RefundPolicy{
+				{Name: "IR", Airline: "IR"},
+				{Name: "IR Y", Airline: "IR", FareClass: "Y"},
+			},
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    "IR Y",
+		},
+		{
+			name: "airline beats fare class and ticket type",
+			candidates: []models.RefundPolicy{
+				{Name: "Y systematic", FareClass: "Y", TicketType: models.TicketTypeSystematic},
+				{Name: "IR", Airline: "IR"},
+			},
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    "IR",
+		},
+		{
+			name: "policies of other airlines do not apply",
+			candidates: []models.RefundPolicy{
+				{Name: "W5", Airline: "W5"},
+			},
+			booking: sampleBooking(models.TicketTypeCharter, "IR", "", 1, 240),
+			want:    "Default charter",
+		},
+		{
+			name: "a policy for another ticket type does not apply",
+			candidates: []models.RefundPolicy{
+				{Name: "IR charter", Airline: "IR", TicketType: models.TicketTypeCharter},
+				{Name: "Any airline", TicketType: models.TicketTypeSystematic},
+			},
+			booking: sampleBooking(models.TicketTypeSystematic, "IR", "Y", 1, 240),
+			want:    "Any airline",
+		},
+		{
+			name:       "unknown ticket types match no default",
+			candidates: nil,
+			booking:    sampleBooking("group", "IR", "Y", 1, 240),
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Select(tt.candidates, &tt.booking)
+			name := ""
+			if got != nil {
+				name = got.Name
+			}
+			if name != tt.want {
+				t.Errorf("Select = %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
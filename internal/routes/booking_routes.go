@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupBookingRoutes(r *gin.Engine, db *gorm.DB) {
+	bookingService := services.NewBookingService(db)
+	bookingHandler := handlers.NewBookingHandler(bookingService)
+
+	bookings := r.Group("/api/v1/bookings")
+	bookings.Use(middleware.AuthMiddleware())
+	{
+		bookings.GET("", bookingHandler.ListBookings)
+		bookings.GET("/:id", bookingHandler.GetBooking)
+		bookings.POST("/:id/refund-quote", bookingHandler.QuoteRefund)
+		bookings.POST("/:id/cancel", bookingHandler.CancelBooking)
+		bookings.GET("/:id/refunds", bookingHandler.ListRefundRequests)
+		bookings.GET("/:id/refunds/:refundId", bookingHandler.GetRefundRequest)
+	}
+
+	staff := bookings.Group("", middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		staff.POST("", bookingHandler.CreateBooking)
+		staff.PUT("/:id/refunds/:refundId", bookingHandler.UpdateRefundStatus)
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupCalendarRoutes(r *gin.Engine, db *gorm.DB) {
+	calendarService := services.NewCalendarService(db)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+
+	calendars := r.Group("/api/v1/calendars")
+	calendars.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		calendars.GET("", calendarHandler.ListCalendars)
+		calendars.GET("/:id", calendarHandler.GetCalendar)
+		calendars.GET("/:id/business-time", calendarHandler.BusinessTime)
+	}
+
+	supervisors := calendars.Group("", middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		supervisors.POST("", calendarHandler.CreateCalendar)
+		supervisors.PUT("/:id", calendarHandler.UpdateCalendar)
+		supervisors.DELETE("/:id", calendarHandler.DeleteCalendar)
+		supervisors.POST("/:id/holidays", calendarHandler.AddHoliday)
+		supervisors.DELETE("/:id/holidays/:holidayId", calendarHandler.RemoveHoliday)
+	}
+}
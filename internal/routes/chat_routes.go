@@ -1,7 +1,10 @@
 package routes
 
 import (
+	"callcenter/internal/config"
+	"callcenter/internal/fuzzy"
 	"callcenter/internal/handlers"
+	"callcenter/internal/llm"
 	"callcenter/internal/middleware"
 	"callcenter/internal/services"
 
@@ -9,10 +12,25 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupChatRoutes(r *gin.Engine, db *gorm.DB) {
-	nlpService := services.NewNLPService()
+func SetupChatRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	nlpService := services.NewNLPServiceWithOptions(fuzzy.Options{
+		MaxEditRatio: cfg.NLPFuzzyEditRatio,
+		MinLength:    cfg.NLPFuzzyMinLength,
+		Phonetic:     cfg.NLPFuzzyPhonetic,
+	}, cfg.TicketNumberFormat)
 	chatService := services.NewChatService(db)
-	chatHandler := handlers.NewChatHandler(db, nlpService, chatService)
+
+	// LLM replies are only enabled when an API key is configured
+	var responseService *services.ResponseService
+	if cfg.OpenAIAPIKey != "" {
+		client := llm.NewClient(cfg.OpenAIAPIKey, cfg.OpenAIAPIURL, cfg.OpenAIModel)
+		responseService = services.NewResponseService(client, services.NewTicketService(db, cfg.TicketNumberFormat))
+	}
+
+	labelingService := services.NewLabelingService(db, cfg.NLPReviewThreshold)
+	knowledgeService := services.NewKnowledgeService(db, cfg.HelpCenterURL)
+	calendarService := services.NewCalendarService(db)
+	chatHandler := handlers.NewChatHandler(db, nlpService, chatService, labelingService, knowledgeService, responseService, calendarService)
 
 	chat := r.Group("/api/v1/chat")
 	chat.Use(middleware.AuthMiddleware())
@@ -22,6 +40,8 @@ func SetupChatRoutes(r *gin.Engine, db *gorm.DB) {
 		chat.GET("/sessions/:id", chatHandler.GetSession)
 		chat.POST("/sessions/:id/messages", chatHandler.SendMessage)
 		chat.GET("/sessions/:id/messages", chatHandler.GetMessages)
+		chat.POST("/sessions/:id/escalate", chatHandler.EscalateSession)
+		chat.PUT("/sessions/:id/language", chatHandler.SetSessionLanguage)
 		chat.POST("/message", chatHandler.HandleMessage)
 	}
 }
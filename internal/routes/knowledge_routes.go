@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"callcenter/internal/config"
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupKnowledgeRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	knowledgeService := services.NewKnowledgeService(db, cfg.HelpCenterURL)
+	knowledgeHandler := handlers.NewKnowledgeHandler(knowledgeService)
+
+	knowledge := r.Group("/api/v1/knowledge-base")
+	knowledge.Use(middleware.AuthMiddleware())
+	{
+		knowledge.GET("/articles", knowledgeHandler.ListArticles)
+		knowledge.GET("/articles/:id", knowledgeHandler.GetArticle)
+		knowledge.GET("/search", knowledgeHandler.SearchArticles)
+	}
+
+	admin := knowledge.Group("", middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.POST("/articles", knowledgeHandler.CreateArticle)
+		admin.PUT("/articles/:id", knowledgeHandler.UpdateArticle)
+		admin.DELETE("/articles/:id", knowledgeHandler.DeleteArticle)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"callcenter/internal/config"
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupLabelingRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	labelingService := services.NewLabelingService(db, cfg.NLPReviewThreshold)
+	labelingHandler := handlers.NewLabelingHandler(labelingService)
+
+	labeling := r.Group("/api/v1/labeling")
+	labeling.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		labeling.GET("/tasks", labelingHandler.ListTasks)
+		labeling.PUT("/tasks/:id", labelingHandler.LabelTask)
+		labeling.GET("/export", labelingHandler.ExportLabels)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"callcenter/internal/config"
+	"callcenter/internal/handlers"
+	"callcenter/internal/payment"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupPaymentRoutes registers the payment gateway callback. Refunds are only
+// paid out when a gateway is configured.
+func SetupPaymentRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	if cfg.PaymentGatewayAPIURL == "" {
+		return
+	}
+
+	gateway := payment.NewClient(cfg.PaymentGatewayAPIKey, cfg.PaymentGatewayAPIURL)
+	payoutService := services.NewRefundPayoutService(db, gateway, cfg.PaymentCallbackURL)
+	paymentHandler := handlers.NewPaymentHandler(payoutService, cfg.PaymentWebhookSecret)
+
+	// The gateway authenticates with the callback signature instead of a JWT
+	r.POST("/api/v1/payments/callback", paymentHandler.PayoutCallback)
+}
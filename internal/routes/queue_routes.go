@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupQueueRoutes(r *gin.Engine, db *gorm.DB) {
+	assignmentService := services.NewAssignmentService(db)
+	queueHandler := handlers.NewQueueHandler(assignmentService)
+
+	queues := r.Group("/api/v1/queues")
+	queues.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		queues.GET("", queueHandler.ListQueues)
+		queues.GET("/:id", queueHandler.GetQueue)
+	}
+
+	supervisors := queues.Group("", middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		supervisors.POST("", queueHandler.CreateQueue)
+		supervisors.PUT("/:id", queueHandler.UpdateQueue)
+		supervisors.POST("/:id/members", queueHandler.AddMember)
+		supervisors.DELETE("/:id/members/:userId", queueHandler.RemoveMember)
+	}
+
+	agents := r.Group("/api/v1/agents")
+	agents.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		agents.PUT("/:id/skills", queueHandler.SetAgentSkills)
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupRefundPolicyRoutes(r *gin.Engine, db *gorm.DB) {
+	policyService := services.NewRefundPolicyService(db)
+	bookingService := services.NewBookingService(db)
+	policyHandler := handlers.NewRefundPolicyHandler(policyService, bookingService)
+
+	policies := r.Group("/api/v1/refund-policies")
+	policies.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		policies.GET("", policyHandler.ListPolicies)
+		policies.GET("/:id", policyHandler.GetPolicy)
+		policies.POST("/quote", policyHandler.Quote)
+	}
+
+	admin := policies.Group("", middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.POST("", policyHandler.CreatePolicy)
+		admin.PUT("/:id", policyHandler.UpdatePolicy)
+		admin.DELETE("/:id", policyHandler.DeletePolicy)
+	}
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"callcenter/internal/handlers"
+	"callcenter/internal/middleware"
+	"callcenter/internal/models"
+	"callcenter/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupSLARoutes(r *gin.Engine, db *gorm.DB) {
+	slaService := services.NewSLAService(db)
+	slaHandler := handlers.NewSLAHandler(slaService)
+
+	policies := r.Group("/api/v1/sla-policies")
+	policies.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		policies.GET("", slaHandler.ListPolicies)
+		policies.GET("/:id", slaHandler.GetPolicy)
+	}
+
+	supervisors := policies.Group("", middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		supervisors.POST("", slaHandler.CreatePolicy)
+		supervisors.PUT("/:id", slaHandler.UpdatePolicy)
+		supervisors.DELETE("/:id", slaHandler.DeletePolicy)
+	}
+
+	reports := r.Group("/api/v1/reports")
+	reports.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		reports.GET("/sla", slaHandler.Report)
+	}
+}
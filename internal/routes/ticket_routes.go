@@ -1,25 +1,67 @@
 package routes
 
 import (
+	"callcenter/internal/config"
 	"callcenter/internal/handlers"
 	"callcenter/internal/middleware"
+	"callcenter/internal/models"
 	"callcenter/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupTicketRoutes(r *gin.Engine, db *gorm.DB) {
-	ticketService := services.NewTicketService(db)
+func SetupTicketRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	ticketService := services.NewTicketService(db, cfg.TicketNumberFormat)
 	ticketHandler := handlers.NewTicketHandler(db, ticketService)
+	commentHandler := handlers.NewCommentHandler(ticketService, services.NewCommentService(db))
+	bulkHandler := handlers.NewBulkHandler(services.NewBulkService(db, ticketService))
 
 	tickets := r.Group("/api/v1/tickets")
 	tickets.Use(middleware.AuthMiddleware())
 	{
 		tickets.POST("", ticketHandler.CreateTicket)
 		tickets.GET("", ticketHandler.ListTickets)
+		tickets.GET("/search", ticketHandler.SearchTickets)
+		tickets.GET("/phone/:phone", ticketHandler.GetTicketsByPhone)
 		tickets.GET("/:id", ticketHandler.GetTicket)
 		tickets.PUT("/:id/status", ticketHandler.UpdateTicketStatus)
 		tickets.GET("/:id/history", ticketHandler.GetTicketHistory)
+		tickets.GET("/:id/timeline", ticketHandler.GetTicketTimeline)
+		tickets.GET("/:id/comments", commentHandler.ListComments)
+		tickets.POST("/:id/comments", commentHandler.AddComment)
+		tickets.PUT("/:id/comments/:commentId", commentHandler.EditComment)
+		tickets.POST("/:id/comments/:commentId/attachments", commentHandler.AddAttachment)
+		tickets.GET("/:id/attachments/:attachmentId", commentHandler.DownloadAttachment)
+		tickets.POST("/:id/refund-quote", ticketHandler.QuoteCancellation)
+		tickets.POST("/:id/cancel", ticketHandler.CancelTicket)
+		tickets.GET("/:id/refunds", ticketHandler.ListRefundRequests)
+		tickets.GET("/:id/refund-status", ticketHandler.ListRefundRequests)
+	}
+
+	staff := tickets.Group("", middleware.RequireRole(models.RoleAgent, models.RoleSupervisor, models.RoleAdmin))
+	{
+		staff.GET("/mine", ticketHandler.MyTickets)
+		staff.GET("/unassigned", ticketHandler.UnassignedTickets)
+		staff.PUT("/:id/assignment", ticketHandler.AssignTicket)
+		staff.PUT("/:id/tags", ticketHandler.TagTicket)
+		staff.GET("/:id/duplicates", ticketHandler.GetDuplicates)
+		staff.POST("/:id/merge", ticketHandler.MergeTickets)
+		staff.PUT("/:id/refund-status", ticketHandler.UpdateLatestRefundStatus)
+		staff.PUT("/:id/refunds/:refundId", ticketHandler.UpdateRefundStatus)
+	}
+
+	bulkJobs := r.Group("/api/v1/bulk-jobs")
+	bulkJobs.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		bulkJobs.POST("", bulkHandler.CreateBulkJob)
+		bulkJobs.GET("", bulkHandler.ListBulkJobs)
+		bulkJobs.GET("/:id", bulkHandler.GetBulkJob)
+	}
+
+	reports := r.Group("/api/v1/reports")
+	reports.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleSupervisor, models.RoleAdmin))
+	{
+		reports.GET("/time-in-status", ticketHandler.TimeInStatusReport)
 	}
 }
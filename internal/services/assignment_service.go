@@ -0,0 +1,585 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/models"
+)
+
+// openTicketStatuses are the statuses of tickets that still need work and count
+// towards an agent's load
+var openTicketStatuses = []string{"open", "in_progress", "waiting_customer"}
+
+// AssignmentService routes support tickets to queues and assigns them to agents
+type AssignmentService struct {
+	db  *gorm.DB
+	sla *SLAService
+}
+
+// NewAssignmentService creates a new instance of AssignmentService
+func NewAssignmentService(db *gorm.DB) *AssignmentService {
+	return &AssignmentService{
+		db:  db,
+		sla: NewSLAService(db),
+	}
+}
+
+// QueueLoad is a queue with the number of its open tickets
+type QueueLoad struct {
+	models.Queue
+	OpenTickets       int64 `json:"open_tickets"`
+	UnassignedTickets int64 `json:"unassigned_tickets"`
+}
+
+// ListQueues returns every queue with its members, routes and open ticket counts
+func (s *AssignmentService) ListQueues(ctx context.Context) ([]QueueLoad, error) {
+	var queues []models.Queue
+	if err := s.db.WithContext(ctx).
+		Preload("Members.User").
+		Preload("Routes").
+		Order("name").
+		Find(&queues).Error; err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	var counts []struct {
+		QueueID           uuid.UUID
+		OpenTickets       int64
+		UnassignedTickets int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Ticket{}).
+		Select("queue_id, count(*) AS open_tickets, count(*) FILTER (WHERE assignee_id IS NULL) AS unassigned_tickets").
+		Where("queue_id IS NOT NULL AND status IN ?", openTicketStatuses).
+		Group("queue_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count queued tickets: %w", err)
+	}
+
+	loads := make([]QueueLoad, len(queues))
+	for i := range queues {
+		loads[i].Queue = queues[i]
+		for _, count := range counts {
+			if count.QueueID == queues[i].ID {
+				loads[i].OpenTickets = count.OpenTickets
+				loads[i].UnassignedTickets = count.UnassignedTickets
+			}
+		}
+	}
+	return loads, nil
+}
+
+// GetQueue retrieves a queue with its members and routes
+func (s *AssignmentService) GetQueue(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	var queue models.Queue
+	if err := s.db.WithContext(ctx).
+		Preload("Members.User").
+		Preload("Routes").
+		First(&queue, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("queue %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+	return &queue, nil
+}
+
+// SaveQueue creates or updates a queue and routes new tickets of ticketTypes to
+// it. Types routed to another queue move to this one; the queue's other routes
+// are removed.
+func (s *AssignmentService) SaveQueue(ctx context.Context, queue *models.Queue, ticketTypes []string) error {
+	queue.Name = strings.ToLower(strings.TrimSpace(queue.Name))
+	if queue.Name == "" {
+		return fmt.Errorf("%w: queue name is required", ErrInvalidInput)
+	}
+	switch queue.Strategy {
+	case models.AssignRoundRobin, models.AssignLeastLoaded, models.AssignSkills:
+	default:
+		return fmt.Errorf("%w: unknown assignment strategy %q", ErrInvalidInput, queue.Strategy)
+	}
+	for _, ticketType := range ticketTypes {
+		if !isTicketType(ticketType) {
+			return fmt.Errorf("%w: unknown ticket type %q", ErrInvalidInput, ticketType)
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if queue.CalendarID != nil {
+			if err := tx.First(&models.BusinessCalendar{}, "id = ?", *queue.CalendarID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("calendar %w: %s", ErrNotFound, *queue.CalendarID)
+				}
+				return fmt.Errorf("failed to get calendar: %w", err)
+			}
+		}
+
+		var count int64
+		if err := tx.Model(&models.Queue{}).
+			Where("name = ? AND id <> ?", queue.Name, queue.ID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check queue name: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: queue %s already exists", ErrConflict, queue.Name)
+		}
+
+		if queue.ID == uuid.Nil {
+			queue.ID = uuid.New()
+			if err := tx.Create(queue).Error; err != nil {
+				return fmt.Errorf("failed to create queue: %w", err)
+			}
+		} else {
+			result := tx.Model(queue).Select("name", "description", "strategy", "auto_assign", "calendar_id").Updates(queue)
+			if result.Error != nil {
+				return fmt.Errorf("failed to update queue: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("queue %w: %s", ErrNotFound, queue.ID)
+			}
+		}
+
+		if err := tx.Where("queue_id = ?", queue.ID).Delete(&models.QueueRoute{}).Error; err != nil {
+			return fmt.Errorf("failed to update queue routes: %w", err)
+		}
+		queue.Routes = make([]models.QueueRoute, 0, len(ticketTypes))
+		for _, ticketType := range ticketTypes {
+			queue.Routes = append(queue.Routes, models.QueueRoute{TicketType: ticketType, QueueID: queue.ID})
+		}
+		if len(queue.Routes) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "ticket_type"}},
+				DoUpdates: clause.AssignmentColumns([]string{"queue_id"}),
+			}).Create(&queue.Routes).Error; err != nil {
+				return fmt.Errorf("failed to update queue routes: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// AddMember makes a staff member work the tickets of a queue
+func (s *AssignmentService) AddMember(ctx context.Context, queueID, userID uuid.UUID) error {
+	if _, err := s.GetQueue(ctx, queueID); err != nil {
+		return err
+	}
+	if err := s.requireAgent(s.db.WithContext(ctx), userID); err != nil {
+		return err
+	}
+
+	member := models.QueueMember{QueueID: queueID, UserID: userID}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		return fmt.Errorf("failed to add queue member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember takes an agent off a queue. Tickets already assigned to them stay theirs.
+func (s *AssignmentService) RemoveMember(ctx context.Context, queueID, userID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.QueueMember{}, "queue_id = ? AND user_id = ?", queueID, userID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove queue member: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("queue member %w: %s", ErrNotFound, userID)
+	}
+	return nil
+}
+
+// SetSkills replaces the skills of an agent. Skills are matched case-insensitively.
+func (s *AssignmentService) SetSkills(ctx context.Context, userID uuid.UUID, skills []string) ([]string, error) {
+	normalized := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		skill = strings.ToLower(strings.TrimSpace(skill))
+		if skill != "" && !containsString(normalized, skill) {
+			normalized = append(normalized, skill)
+		}
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.requireAgent(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.AgentSkill{}, "user_id = ?", userID).Error; err != nil {
+			return fmt.Errorf("failed to update skills: %w", err)
+		}
+		for _, skill := range normalized {
+			if err := tx.Create(&models.AgentSkill{UserID: userID, Skill: skill}).Error; err != nil {
+				return fmt.Errorf("failed to update skills: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// Assignment changes who handles a ticket. QueueID moves the ticket to another
+// queue. AgentID assigns it to an agent; Auto instead picks one from the
+// ticket's queue by the queue's strategy; Unassign returns it to its queue.
+type Assignment struct {
+	QueueID  *uuid.UUID
+	AgentID  *uuid.UUID
+	Auto     bool
+	Unassign bool
+	Note     string
+}
+
+// Assign moves a ticket between queues and agents on behalf of a staff member,
+// recording every change in the ticket history. A ticket moved to another
+// queue gets the SLA targets of that queue. Agents may only take a ticket
+// themselves or return their own ticket to its queue; supervisors and admins
+// may make any change.
+func (s *AssignmentService) Assign(ctx context.Context, ticketID uuid.UUID, assignment Assignment, actor Actor) (*models.Ticket, error) {
+	if !models.IsStaff(actor.Role) {
+		return nil, fmt.Errorf("%w: only staff assign tickets", ErrForbidden)
+	}
+	if actor.Role == models.RoleAgent &&
+		(assignment.QueueID != nil || assignment.Auto || (assignment.AgentID != nil && *assignment.AgentID != actor.UserID)) {
+		return nil, fmt.Errorf("%w: agents can only take tickets themselves", ErrForbidden)
+	}
+
+	var ticket models.Ticket
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, "id = ?", ticketID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ticket %w: %s", ErrNotFound, ticketID)
+			}
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+
+		if assignment.QueueID != nil && (ticket.QueueID == nil || *ticket.QueueID != *assignment.QueueID) {
+			var queue models.Queue
+			if err := tx.First(&queue, "id = ?", *assignment.QueueID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("queue %w: %s", ErrNotFound, *assignment.QueueID)
+				}
+				return fmt.Errorf("failed to get queue: %w", err)
+			}
+			if err := s.setQueue(tx, &ticket, &queue, actor, assignment.Note); err != nil {
+				return err
+			}
+			if err := s.sla.apply(tx, &ticket); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case assignment.AgentID != nil:
+			if err := s.requireAgent(tx, *assignment.AgentID); err != nil {
+				return err
+			}
+			return s.setAssignee(tx, &ticket, assignment.AgentID, actor, assignment.Note)
+		case assignment.Auto:
+			if ticket.QueueID == nil {
+				return fmt.Errorf("%w: ticket %s is not in a queue", ErrConflict, ticket.Number)
+			}
+			var queue models.Queue
+			if err := tx.First(&queue, "id = ?", *ticket.QueueID).Error; err != nil {
+				return fmt.Errorf("failed to get queue: %w", err)
+			}
+			agentID, err := s.pickAgent(tx, &queue, &ticket)
+			if err != nil {
+				return err
+			}
+			if agentID == nil {
+				return fmt.Errorf("%w: queue %s has no members", ErrConflict, queue.Name)
+			}
+			return s.setAssignee(tx, &ticket, agentID, actor, fmt.Sprintf("Assigned by %s", queue.Strategy))
+		case assignment.Unassign:
+			if actor.Role == models.RoleAgent && (ticket.AssigneeID == nil || *ticket.AssigneeID != actor.UserID) {
+				return fmt.Errorf("%w: agents can only return their own tickets", ErrForbidden)
+			}
+			return s.setAssignee(tx, &ticket, nil, actor, assignment.Note)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// route puts a new ticket in the queue of its type and, if the queue assigns
+// automatically, assigns it to one of the queue's members
+func (s *AssignmentService) route(tx *gorm.DB, ticket *models.Ticket, actor Actor) error {
+	var queue models.Queue
+	err := tx.Joins("JOIN queue_routes ON queue_routes.queue_id = queues.id").
+		Where("queue_routes.ticket_type = ?", ticket.Type).
+		First(&queue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to route ticket: %w", err)
+	}
+
+	if err := s.setQueue(tx, ticket, &queue, actor, ""); err != nil {
+		return err
+	}
+	if !queue.AutoAssign {
+		return nil
+	}
+	agentID, err := s.pickAgent(tx, &queue, ticket)
+	if err != nil || agentID == nil {
+		return err
+	}
+	return s.setAssignee(tx, ticket, agentID, actor, fmt.Sprintf("Assigned by %s", queue.Strategy))
+}
+
+// setQueue moves a ticket to a queue and records it in the ticket history
+func (s *AssignmentService) setQueue(tx *gorm.DB, ticket *models.Ticket, queue *models.Queue, actor Actor, note string) error {
+	if err := tx.Model(ticket).Update("queue_id", queue.ID).Error; err != nil {
+		return fmt.Errorf("failed to queue ticket: %w", err)
+	}
+	ticket.QueueID = &queue.ID
+	description := fmt.Sprintf("Queued in %s", queue.Name)
+	if note != "" {
+		description += ": " + note
+	}
+	return recordTicketHistory(tx, ticket.ID, "queued", description, actor)
+}
+
+// setAssignee assigns a ticket to an agent, or unassigns it when agentID is
+// nil, and records the change in the ticket history
+func (s *AssignmentService) setAssignee(tx *gorm.DB, ticket *models.Ticket, agentID *uuid.UUID, actor Actor, note string) error {
+	action := "assigned"
+	switch {
+	case agentID == nil && ticket.AssigneeID == nil:
+		return nil
+	case agentID == nil:
+		action = "unassigned"
+	case ticket.AssigneeID != nil && *ticket.AssigneeID == *agentID:
+		return nil
+	case ticket.AssigneeID != nil:
+		action = "reassigned"
+	}
+
+	if err := tx.Model(ticket).Update("assignee_id", agentID).Error; err != nil {
+		return fmt.Errorf("failed to assign ticket: %w", err)
+	}
+	ticket.AssigneeID = agentID
+
+	description := "Returned to the queue"
+	if agentID != nil {
+		var agent models.User
+		if err := tx.First(&agent, "id = ?", *agentID).Error; err != nil {
+			return fmt.Errorf("failed to get agent: %w", err)
+		}
+		description = fmt.Sprintf("Assigned to %s", agentName(&agent))
+	}
+	if note != "" {
+		description += ": " + note
+	}
+	return recordTicketHistory(tx, ticket.ID, action, description, actor)
+}
+
+// pickAgent chooses the member of a queue to assign a ticket to by the queue's
+// strategy. It returns nil when the queue has no members.
+func (s *AssignmentService) pickAgent(tx *gorm.DB, queue *models.Queue, ticket *models.Ticket) (*uuid.UUID, error) {
+	var members []uuid.UUID
+	if err := tx.Model(&models.QueueMember{}).
+		Where("queue_id = ?", queue.ID).
+		Order("created_at, user_id").
+		Pluck("user_id", &members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get queue members: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	switch queue.Strategy {
+	case models.AssignLeastLoaded:
+		return s.leastLoaded(tx, members)
+	case models.AssignSkills:
+		return s.mostSkilled(tx, members, ticket)
+	default:
+		return s.nextInTurn(tx, queue, members)
+	}
+}
+
+// nextInTurn picks the member after the one who got the queue's last ticket.
+// The queue is locked so concurrent tickets get different members.
+func (s *AssignmentService) nextInTurn(tx *gorm.DB, queue *models.Queue, members []uuid.UUID) (*uuid.UUID, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(queue, "id = ?", queue.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock queue: %w", err)
+	}
+
+	next := members[0]
+	if queue.LastAssigneeID != nil {
+		for i, member := range members {
+			if member == *queue.LastAssigneeID {
+				next = members[(i+1)%len(members)]
+				break
+			}
+		}
+	}
+
+	if err := tx.Model(queue).Update("last_assignee_id", next).Error; err != nil {
+		return nil, fmt.Errorf("failed to update queue: %w", err)
+	}
+	return &next, nil
+}
+
+// leastLoaded picks the candidate with the fewest open tickets, the earliest
+// one on ties
+func (s *AssignmentService) leastLoaded(tx *gorm.DB, candidates []uuid.UUID) (*uuid.UUID, error) {
+	loads, err := agentLoads(tx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if loads[candidate] < loads[best] {
+			best = candidate
+		}
+	}
+	return &best, nil
+}
+
+// mostSkilled picks the members with the most skills matching the ticket, the
+// least loaded of them on ties. Without any matching member, all members are
+// considered.
+func (s *AssignmentService) mostSkilled(tx *gorm.DB, members []uuid.UUID, ticket *models.Ticket) (*uuid.UUID, error) {
+	required, err := ticketSkills(tx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []struct {
+		UserID uuid.UUID
+		Count  int
+	}
+	if err := tx.Model(&models.AgentSkill{}).
+		Select("user_id, count(*) AS count").
+		Where("user_id IN ? AND skill IN ?", members, required).
+		Group("user_id").
+		Scan(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to match skills: %w", err)
+	}
+
+	most := 0
+	for _, match := range matches {
+		if match.Count > most {
+			most = match.Count
+		}
+	}
+	if most == 0 {
+		return s.leastLoaded(tx, members)
+	}
+
+	var skilled []uuid.UUID
+	for _, member := range members {
+		for _, match := range matches {
+			if match.UserID == member && match.Count == most {
+				skilled = append(skilled, member)
+			}
+		}
+	}
+	return s.leastLoaded(tx, skilled)
+}
+
+// ticketSkills are the skills a ticket calls for: its type, and the airline
+// and ticket type (charter or systematic) of its booking
+func ticketSkills(tx *gorm.DB, ticket *models.Ticket) ([]string, error) {
+	skills := []string{ticket.Type}
+	if ticket.BookingID == nil {
+		return skills, nil
+	}
+	var booking models.Booking
+	if err := tx.Select("airline", "ticket_type").First(&booking, "id = ?", *ticket.BookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return skills, nil
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	return append(skills, strings.ToLower(booking.Airline), booking.TicketType), nil
+}
+
+// agentLoads counts the open tickets assigned to each agent
+func agentLoads(tx *gorm.DB, agents []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var counts []struct {
+		AssigneeID uuid.UUID
+		Count      int64
+	}
+	if err := tx.Model(&models.Ticket{}).
+		Select("assignee_id, count(*) AS count").
+		Where("assignee_id IN ? AND status IN ?", agents, openTicketStatuses).
+		Group("assignee_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count assigned tickets: %w", err)
+	}
+	loads := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		loads[count.AssigneeID] = count.Count
+	}
+	return loads, nil
+}
+
+// requireAgent checks that a user is call center staff who can be assigned tickets
+func (s *AssignmentService) requireAgent(tx *gorm.DB, userID uuid.UUID) error {
+	var user models.User
+	if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("agent %w: %s", ErrNotFound, userID)
+		}
+		return fmt.Errorf("failed to get agent: %w", err)
+	}
+	if !models.IsStaff(user.Role) {
+		return fmt.Errorf("%w: user %s is not an agent", ErrInvalidInput, userID)
+	}
+	return nil
+}
+
+// recordTicketHistory adds an entry to the history of a ticket. Changes made by
+// the system have no user to attribute them to and are not recorded.
+func recordTicketHistory(tx *gorm.DB, ticketID uuid.UUID, action, description string, actor Actor) error {
+	if actor.UserID == uuid.Nil {
+		return nil
+	}
+	history := models.TicketHistory{
+		ID:          uuid.New(),
+		TicketID:    ticketID,
+		Action:      action,
+		Description: description,
+		UserID:      actor.UserID,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("failed to record ticket history: %w", err)
+	}
+	return nil
+}
+
+// agentName is how an agent is shown in the ticket history
+func agentName(agent *models.User) string {
+	if agent.Name != "" {
+		return agent.Name
+	}
+	return agent.Email
+}
+
+func isTicketType(ticketType string) bool {
+	switch ticketType {
+	case models.TicketCategoryGeneral, models.TicketCategoryCancellation, models.TicketCategoryRefund,
+		models.TicketCategoryChange, models.TicketCategoryComplaint, models.TicketCategoryBaggage:
+		return true
+	default:
+		return false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
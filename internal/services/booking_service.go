@@ -0,0 +1,734 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/events"
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+	"callcenter/internal/refund"
+)
+
+var (
+	pnrPattern         = regexp.MustCompile(`^[A-Z0-9]{6}$`)
+	eticketPattern     = regexp.MustCompile(`^\d{13}$`)
+	airlineCodePattern = regexp.MustCompile(`^[A-Z0-9]{2}$`)
+	airportCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// RefundQuoteTTL is how long a customer has to cancel at a quoted refund amount
+const RefundQuoteTTL = 15 * time.Minute
+
+// BookingService handles flight bookings and their cancellation and refund
+type BookingService struct {
+	db       *gorm.DB
+	policies *RefundPolicyService
+}
+
+// NewBookingService creates a new instance of BookingService
+func NewBookingService(db *gorm.DB) *BookingService {
+	return &BookingService{
+		db:       db,
+		policies: NewRefundPolicyService(db),
+	}
+}
+
+// CreateBooking validates and stores a booking with its passengers and segments.
+// The booking fares are computed from the passenger fares.
+func (s *BookingService) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	if err := validateBooking(booking); err != nil {
+		return err
+	}
+
+	booking.ID = uuid.New()
+	booking.Status = models.BookingStatusActive
+	booking.BaseFare, booking.Taxes = 0, 0
+	for i := range booking.Passengers {
+		passenger := &booking.Passengers[i]
+		passenger.ID = uuid.New()
+		passenger.Status = models.BookingStatusActive
+		if passenger.Type == "" {
+			passenger.Type = models.PassengerTypeAdult
+		}
+		booking.BaseFare += passenger.BaseFare
+		booking.Taxes += passenger.Taxes
+	}
+	booking.TotalFare = booking.BaseFare + booking.Taxes
+	for i := range booking.Segments {
+		booking.Segments[i].ID = uuid.New()
+		booking.Segments[i].Sequence = i + 1
+		booking.Segments[i].Status = models.BookingStatusActive
+	}
+
+	if err := s.db.WithContext(ctx).Create(booking).Error; err != nil {
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+	return nil
+}
+
+// validateBooking checks the codes and itinerary of a new booking and normalizes their case
+func validateBooking(booking *models.Booking) error {
+	booking.PNR = strings.ToUpper(strings.TrimSpace(booking.PNR))
+	booking.Airline = strings.ToUpper(booking.Airline)
+	booking.Currency = strings.ToUpper(booking.Currency)
+
+	switch {
+	case !pnrPattern.MatchString(booking.PNR):
+		return fmt.Errorf("%w: PNR must be 6 letters or digits", ErrInvalidInput)
+	case !airlineCodePattern.MatchString(booking.Airline):
+		return fmt.Errorf("%w: invalid airline code %s", ErrInvalidInput, booking.Airline)
+	case booking.TicketType != models.TicketTypeCharter && booking.TicketType != models.TicketTypeSystematic:
+		return fmt.Errorf("%w: invalid ticket type %s", ErrInvalidInput, booking.TicketType)
+	case len(booking.Passengers) == 0:
+		return fmt.Errorf("%w: a booking needs at least one passenger", ErrInvalidInput)
+	case len(booking.Segments) == 0:
+		return fmt.Errorf("%w: a booking needs at least one segment", ErrInvalidInput)
+	}
+
+	// Fares may be given in tomans; they are stored in rials
+	currency, scale, err := money.ParseCurrency(booking.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	booking.Currency = currency.Code
+
+	for i := range booking.Passengers {
+		passenger := &booking.Passengers[i]
+		if passenger.TicketNumber != "" && !eticketPattern.MatchString(passenger.TicketNumber) {
+			return fmt.Errorf("%w: e-ticket number must be 13 digits", ErrInvalidInput)
+		}
+		if passenger.BaseFare < 0 || passenger.Taxes < 0 {
+			return fmt.Errorf("%w: fares cannot be negative", ErrInvalidInput)
+		}
+		passenger.BaseFare *= scale
+		passenger.Taxes *= scale
+	}
+
+	for i := range booking.Segments {
+		segment := &booking.Segments[i]
+		segment.Airline = strings.ToUpper(segment.Airline)
+		segment.Origin = strings.ToUpper(segment.Origin)
+		segment.Destination = strings.ToUpper(segment.Destination)
+		switch {
+		case !airlineCodePattern.MatchString(segment.Airline):
+			return fmt.Errorf("%w: invalid airline code %s", ErrInvalidInput, segment.Airline)
+		case !airportCodePattern.MatchString(segment.Origin) || !airportCodePattern.MatchString(segment.Destination):
+			return fmt.Errorf("%w: segment airports must be IATA codes", ErrInvalidInput)
+		case segment.DepartureAt.IsZero():
+			return fmt.Errorf("%w: segment departure time is required", ErrInvalidInput)
+		case !segment.ArrivalAt.IsZero() && segment.ArrivalAt.Before(segment.DepartureAt):
+			return fmt.Errorf("%w: segment arrives before it departs", ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// withItinerary preloads the passengers and the segments in flight order
+func withItinerary(db *gorm.DB) *gorm.DB {
+	return db.Preload("Passengers").Preload("Segments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	})
+}
+
+// GetBooking retrieves a booking with its passengers and segments
+func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	if err := withItinerary(s.db.WithContext(ctx)).First(&booking, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	return &booking, nil
+}
+
+// GetBookingByReference retrieves a booking by its PNR or by the e-ticket number of one of its passengers
+func (s *BookingService) GetBookingByReference(ctx context.Context, reference string) (*models.Booking, error) {
+	reference = strings.ToUpper(strings.TrimSpace(reference))
+
+	query := withItinerary(s.db.WithContext(ctx))
+	if eticketPattern.MatchString(reference) {
+		query = query.Where("id IN (?)", s.db.Model(&models.Passenger{}).Select("booking_id").Where("ticket_number = ?", reference))
+	} else {
+		query = query.Where("pnr = ?", reference)
+	}
+
+	var booking models.Booking
+	if err := query.Order("created_at DESC").First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking %w: %s", ErrNotFound, reference)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	return &booking, nil
+}
+
+// GetBookingsByPhone retrieves the bookings made with a contact phone number, newest first
+func (s *BookingService) GetBookingsByPhone(ctx context.Context, phoneNumber string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := withItinerary(s.db.WithContext(ctx)).
+		Where("contact_phone = ?", phoneNumber).
+		Order("created_at DESC").
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+// ListBookings retrieves the bookings of a customer, newest first
+func (s *BookingService) ListBookings(ctx context.Context, userID uuid.UUID) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := withItinerary(s.db.WithContext(ctx)).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+// Cancellation is a request to cancel a booking at the amount of an accepted
+// refund quote
+type Cancellation struct {
+	BookingID uuid.UUID
+	QuoteID   uuid.UUID
+	Reason    string
+	Actor     Actor
+	TicketID  *uuid.UUID // support ticket the cancellation was requested from, if any
+}
+
+// CancelBooking is the single path through which bookings are cancelled, whether
+// from the API, the chat bot or the agent console. It cancels the coupons of an
+// accepted quote, opens a refund request for the quoted amount with a line item
+// per coupon, records the cancellation in the history of the booking's support
+// tickets and publishes a BookingCancelled event once everything is committed.
+// The booking stays active until none of its coupons is left.
+func (s *BookingService) CancelBooking(ctx context.Context, cancellation Cancellation) (*models.RefundRequest, error) {
+	actor := cancellation.Actor
+	var booking models.Booking
+	var refundRequest *models.RefundRequest
+	var partial bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", cancellation.BookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("booking %w: %s", ErrNotFound, cancellation.BookingID)
+			}
+			return fmt.Errorf("failed to get booking: %w", err)
+		}
+
+		// Check if booking can be cancelled
+		if booking.Status != models.BookingStatusActive {
+			return fmt.Errorf("%w: booking cannot be cancelled, current status is %s", ErrConflict, booking.Status)
+		}
+
+		quote, err := acceptQuote(tx, cancellation.QuoteID, booking.ID)
+		if err != nil {
+			return err
+		}
+		refundAmount := quote.Amount
+
+		// Another cancellation may have taken some of the quoted coupons meanwhile
+		cancelled, err := cancelledCoupons(tx, booking.ID)
+		if err != nil {
+			return err
+		}
+		for _, item := range quote.Items {
+			if cancelled[refund.Coupon{PassengerID: item.PassengerID, SegmentID: item.SegmentID}] {
+				return fmt.Errorf("%w: part of refund quote %s was cancelled since, request a new one", ErrConflict, quote.ID)
+			}
+		}
+
+		refundRequest = &models.RefundRequest{
+			BookingID:   booking.ID,
+			TicketID:    cancellation.TicketID,
+			QuoteID:     &quote.ID,
+			RequestedBy: actor.String(),
+			Reason:      cancellation.Reason,
+			Status:      models.RefundStatusPending,
+			Amount:      refundAmount,
+			Currency:    booking.Currency,
+		}
+		if err := tx.Create(refundRequest).Error; err != nil {
+			return fmt.Errorf("failed to create refund request: %w", err)
+		}
+		if err := tx.Model(&models.RefundLineItem{}).Where("quote_id = ?", quote.ID).
+			Update("refund_request_id", refundRequest.ID).Error; err != nil {
+			return fmt.Errorf("failed to attach refund line items: %w", err)
+		}
+		refundRequest.Items = quote.Items
+		for i := range refundRequest.Items {
+			refundRequest.Items[i].RefundRequestID = &refundRequest.ID
+		}
+		if err := recordRefundChange(tx, refundRequest, "", actor, cancellation.Reason); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		fully, err := cancelCoupons(tx, &booking, quote.Items, now)
+		if err != nil {
+			return err
+		}
+		partial = !fully
+
+		updates := map[string]interface{}{
+			"refund_status": refundRequest.Status,
+			"refund_amount": gorm.Expr("refund_amount + ?", refundAmount),
+		}
+		if fully {
+			updates["status"] = models.BookingStatusCancelled
+			updates["cancelled_at"] = now
+		}
+		if err := tx.Model(&booking).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update booking: %w", err)
+		}
+
+		return recordCancellation(tx, booking.ID, cancellation, partial)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events.Publish(ctx, events.New(events.BookingCancelled, booking.ID, map[string]interface{}{
+		"pnr":               booking.PNR,
+		"user_id":           booking.UserID,
+		"ticket_id":         cancellation.TicketID,
+		"refund_request_id": refundRequest.ID,
+		"partial":           partial,
+		"coupons":           len(refundRequest.Items),
+		"refund_amount":     refundRequest.Amount,
+		"currency":          refundRequest.Currency,
+		"reason":            cancellation.Reason,
+		"actor":             actor.String(),
+		"actor_role":        actor.Role,
+	}))
+	return refundRequest, nil
+}
+
+// cancelCoupons marks the passengers and segments left without any open coupon
+// as cancelled, once the coupons of items are cancelled. It reports whether the
+// whole booking is cancelled; items from quotes made before line items existed
+// are empty and cancel everything.
+func cancelCoupons(tx *gorm.DB, booking *models.Booking, items []models.RefundLineItem, now time.Time) (bool, error) {
+	var passengerIDs, segmentIDs []uuid.UUID
+	if err := tx.Model(&models.Passenger{}).Where("booking_id = ?", booking.ID).Pluck("id", &passengerIDs).Error; err != nil {
+		return false, fmt.Errorf("failed to get passengers: %w", err)
+	}
+	if err := tx.Model(&models.Segment{}).Where("booking_id = ?", booking.ID).Pluck("id", &segmentIDs).Error; err != nil {
+		return false, fmt.Errorf("failed to get segments: %w", err)
+	}
+
+	cancelled, err := cancelledCoupons(tx, booking.ID)
+	if err != nil {
+		return false, err
+	}
+	everything := len(items) == 0
+
+	var cancelledPassengers, cancelledSegments []uuid.UUID
+	for _, passengerID := range passengerIDs {
+		open := false
+		for _, segmentID := range segmentIDs {
+			open = open || !cancelled[refund.Coupon{PassengerID: passengerID, SegmentID: segmentID}]
+		}
+		if !open || everything {
+			cancelledPassengers = append(cancelledPassengers, passengerID)
+		}
+	}
+	for _, segmentID := range segmentIDs {
+		open := false
+		for _, passengerID := range passengerIDs {
+			open = open || !cancelled[refund.Coupon{PassengerID: passengerID, SegmentID: segmentID}]
+		}
+		if !open || everything {
+			cancelledSegments = append(cancelledSegments, segmentID)
+		}
+	}
+
+	cancel := map[string]interface{}{"status": models.BookingStatusCancelled, "cancelled_at": now}
+	if len(cancelledPassengers) > 0 {
+		if err := tx.Model(&models.Passenger{}).
+			Where("id IN ? AND status <> ?", cancelledPassengers, models.BookingStatusCancelled).
+			Updates(cancel).Error; err != nil {
+			return false, fmt.Errorf("failed to cancel passengers: %w", err)
+		}
+	}
+	if len(cancelledSegments) > 0 {
+		if err := tx.Model(&models.Segment{}).
+			Where("id IN ? AND status <> ?", cancelledSegments, models.BookingStatusCancelled).
+			Updates(cancel).Error; err != nil {
+			return false, fmt.Errorf("failed to cancel segments: %w", err)
+		}
+	}
+	return len(cancelledPassengers) == len(passengerIDs), nil
+}
+
+// recordCancellation adds a cancelled (or partially_cancelled) entry,
+// attributed to the actor, to the history of every support ticket about the
+// booking. Cancellations by the system have no user to attribute them to and
+// are left to the refund history.
+func recordCancellation(tx *gorm.DB, bookingID uuid.UUID, cancellation Cancellation, partial bool) error {
+	if cancellation.Actor.UserID == uuid.Nil {
+		return nil
+	}
+
+	var ticketIDs []uuid.UUID
+	if err := tx.Model(&models.Ticket{}).Where("booking_id = ?", bookingID).Pluck("id", &ticketIDs).Error; err != nil {
+		return fmt.Errorf("failed to get tickets: %w", err)
+	}
+	if cancellation.TicketID != nil && !containsID(ticketIDs, *cancellation.TicketID) {
+		ticketIDs = append(ticketIDs, *cancellation.TicketID)
+	}
+
+	action := "cancelled"
+	if partial {
+		action = "partially_cancelled"
+	}
+	for _, ticketID := range ticketIDs {
+		history := models.TicketHistory{
+			ID:          uuid.New(),
+			TicketID:    ticketID,
+			Action:      action,
+			Description: cancellation.Reason,
+			UserID:      cancellation.Actor.UserID,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to create ticket history: %w", err)
+		}
+	}
+	return nil
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptQuote marks an unexpired, unused quote of the booking as used
+func acceptQuote(tx *gorm.DB, quoteID, bookingID uuid.UUID) (*models.RefundQuote, error) {
+	var quote models.RefundQuote
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&quote, "id = ? AND booking_id = ?", quoteID, bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: unknown refund quote %s", ErrInvalidInput, quoteID)
+		}
+		return nil, fmt.Errorf("failed to get refund quote: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case quote.UsedAt != nil:
+		return nil, fmt.Errorf("%w: refund quote %s was already used", ErrConflict, quoteID)
+	case now.After(quote.ExpiresAt):
+		return nil, fmt.Errorf("%w: refund quote %s expired, request a new one", ErrConflict, quoteID)
+	}
+
+	quote.UsedAt = &now
+	if err := tx.Model(&quote).Update("used_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to use refund quote: %w", err)
+	}
+	if err := tx.Where("quote_id = ?", quote.ID).Find(&quote.Items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get refund quote items: %w", err)
+	}
+	return &quote, nil
+}
+
+// RefundSelection picks the coupons of a booking to cancel: the given
+// passengers on the given segments. An empty list selects all of them, so the
+// zero value cancels whatever is left of the booking.
+type RefundSelection struct {
+	PassengerIDs []uuid.UUID
+	SegmentIDs   []uuid.UUID
+}
+
+// QuoteRefund computes what cancelling the selected coupons of an active booking
+// now would refund and stores the breakdown, with a line item per coupon, as a
+// quote valid for RefundQuoteTTL. Coupons already cancelled are left out. The
+// booking must include its passengers and segments.
+func (s *BookingService) QuoteRefund(ctx context.Context, booking *models.Booking, selection RefundSelection, actor Actor) (*models.RefundQuote, error) {
+	if booking.Status != models.BookingStatusActive {
+		return nil, fmt.Errorf("%w: booking cannot be cancelled, current status is %s", ErrConflict, booking.Status)
+	}
+
+	coupons, err := s.openCoupons(ctx, booking, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	computed, err := s.policies.Quote(ctx, booking, coupons, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.RefundQuote{
+		ID:             uuid.New(),
+		BookingID:      booking.ID,
+		PolicyName:     computed.PolicyName,
+		BaseFare:       computed.BaseFare,
+		Taxes:          computed.Taxes,
+		PenaltyPercent: computed.PenaltyPercent,
+		Penalty:        computed.Penalty,
+		Fee:            computed.Fee,
+		TaxesRefunded:  computed.TaxesRefunded,
+		Amount:         computed.Amount,
+		Currency:       computed.Currency,
+		RequestedBy:    actor.String(),
+		ExpiresAt:      now.Add(RefundQuoteTTL),
+	}
+	for _, line := range computed.Lines {
+		quote.Items = append(quote.Items, models.RefundLineItem{
+			ID:            uuid.New(),
+			BookingID:     booking.ID,
+			PassengerID:   line.PassengerID,
+			SegmentID:     line.SegmentID,
+			BaseFare:      line.BaseFare,
+			Taxes:         line.Taxes,
+			Penalty:       line.Penalty,
+			Fee:           line.Fee,
+			TaxesRefunded: line.TaxesRefunded,
+			Amount:        line.Amount,
+		})
+	}
+	if err := s.db.WithContext(ctx).Create(quote).Error; err != nil {
+		return nil, fmt.Errorf("failed to save refund quote: %w", err)
+	}
+	return quote, nil
+}
+
+// openCoupons returns the selected coupons of a booking that are not cancelled
+// yet, or nil to quote a legacy booking, which has no coupons, whole
+func (s *BookingService) openCoupons(ctx context.Context, booking *models.Booking, selection RefundSelection) ([]refund.Coupon, error) {
+	if refund.IsLegacy(booking) && len(selection.PassengerIDs) == 0 && len(selection.SegmentIDs) == 0 {
+		return nil, nil
+	}
+
+	var passengerIDs, segmentIDs []uuid.UUID
+	for _, passenger := range booking.Passengers {
+		passengerIDs = append(passengerIDs, passenger.ID)
+	}
+	for _, segment := range booking.Segments {
+		segmentIDs = append(segmentIDs, segment.ID)
+	}
+
+	passengers, err := selectIDs(selection.PassengerIDs, passengerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: passenger %v", ErrInvalidInput, err)
+	}
+	segments, err := selectIDs(selection.SegmentIDs, segmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: segment %v", ErrInvalidInput, err)
+	}
+
+	cancelled, err := cancelledCoupons(s.db.WithContext(ctx), booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	coupons := refund.WithoutCancelled(refund.SelectCoupons(booking, passengers, segments), cancelled)
+	if len(coupons) == 0 {
+		return nil, fmt.Errorf("%w: the selected passengers and segments are already cancelled", ErrConflict)
+	}
+	return coupons, nil
+}
+
+// selectIDs returns the requested IDs without repeats, or all of them when none
+// are requested, and fails for requested IDs that are not among all
+func selectIDs(requested, all []uuid.UUID) ([]uuid.UUID, error) {
+	if len(requested) == 0 {
+		return all, nil
+	}
+	for _, requestedID := range requested {
+		if !containsID(all, requestedID) {
+			return nil, fmt.Errorf("%s is not on the booking", requestedID)
+		}
+	}
+	return uniqueIDs(requested), nil
+}
+
+// cancelledCoupons returns the coupons of a booking cancelled by earlier refund requests
+func cancelledCoupons(db *gorm.DB, bookingID uuid.UUID) (map[refund.Coupon]bool, error) {
+	var items []models.RefundLineItem
+	if err := db.Select("passenger_id", "segment_id").
+		Where("booking_id = ? AND refund_request_id IS NOT NULL", bookingID).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cancelled coupons: %w", err)
+	}
+
+	cancelled := make(map[refund.Coupon]bool, len(items))
+	for _, item := range items {
+		cancelled[refund.Coupon{PassengerID: item.PassengerID, SegmentID: item.SegmentID}] = true
+	}
+	return cancelled, nil
+}
+
+// ListRefundRequests lists the refund requests of a booking, newest first, with
+// their line items and status history
+func (s *BookingService) ListRefundRequests(ctx context.Context, bookingID uuid.UUID) ([]models.RefundRequest, error) {
+	var refundRequests []models.RefundRequest
+	if err := refundDetails(s.db.WithContext(ctx)).
+		Where("booking_id = ?", bookingID).
+		Order("created_at DESC").
+		Find(&refundRequests).Error; err != nil {
+		return nil, fmt.Errorf("failed to get refund requests: %w", err)
+	}
+	return refundRequests, nil
+}
+
+// GetRefundRequest retrieves a refund request of a booking with its line items
+// and status history
+func (s *BookingService) GetRefundRequest(ctx context.Context, bookingID uuid.UUID, refundRequestID uint) (*models.RefundRequest, error) {
+	var refundRequest models.RefundRequest
+	if err := refundDetails(s.db.WithContext(ctx)).
+		Where("booking_id = ?", bookingID).
+		First(&refundRequest, refundRequestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refund request %w: %d", ErrNotFound, refundRequestID)
+		}
+		return nil, fmt.Errorf("failed to get refund request: %w", err)
+	}
+	return &refundRequest, nil
+}
+
+// GetRefundQuote retrieves a refund quote of a booking
+func (s *BookingService) GetRefundQuote(ctx context.Context, bookingID, quoteID uuid.UUID) (*models.RefundQuote, error) {
+	var quote models.RefundQuote
+	if err := s.db.WithContext(ctx).First(&quote, "id = ? AND booking_id = ?", quoteID, bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refund quote %w: %s", ErrNotFound, quoteID)
+		}
+		return nil, fmt.Errorf("failed to get refund quote: %w", err)
+	}
+	return &quote, nil
+}
+
+// refundDetails preloads the line items and status history of refund requests
+func refundDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		})
+}
+
+// UpdateRefundStatus moves a refund request of a booking to a new status and
+// mirrors it on the booking. The change must be allowed by the refund lifecycle
+// for the actor's role, and rejections and failures need a reason.
+func (s *BookingService) UpdateRefundStatus(ctx context.Context, bookingID uuid.UUID, refundRequestID uint, status string, actor Actor, reason string) (*models.RefundRequest, error) {
+	var refundRequest models.RefundRequest
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", bookingID).
+			First(&refundRequest, refundRequestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("refund request %w: %d", ErrNotFound, refundRequestID)
+			}
+			return fmt.Errorf("failed to get refund request: %w", err)
+		}
+		return transitionRefund(tx, &refundRequest, status, actor, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refundRequest, nil
+}
+
+// TransitionRefund moves a refund request to a new status under the same rules
+// as UpdateRefundStatus
+func (s *BookingService) TransitionRefund(ctx context.Context, refundRequestID uint, status string, actor Actor, reason string) (*models.RefundRequest, error) {
+	var refundRequest models.RefundRequest
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refundRequest, refundRequestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("refund request %w: %d", ErrNotFound, refundRequestID)
+			}
+			return fmt.Errorf("failed to get refund request: %w", err)
+		}
+		return transitionRefund(tx, &refundRequest, status, actor, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refundRequest, nil
+}
+
+// transitionRefund checks and applies a status change to a locked refund
+// request, records it and mirrors it on the booking. Every move into processing
+// is a new payout attempt, so the payout of the previous attempt is cleared.
+func transitionRefund(tx *gorm.DB, refundRequest *models.RefundRequest, status string, actor Actor, reason string) error {
+	if err := refund.CheckTransition(refundRequest.Status, status, actor.Role, reason); err != nil {
+		switch {
+		case errors.Is(err, refund.ErrNotPermitted):
+			return fmt.Errorf("%w: %v", ErrForbidden, err)
+		case errors.Is(err, refund.ErrReasonRequired):
+			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		default:
+			return fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+	}
+
+	now := time.Now()
+	from := refundRequest.Status
+	refundRequest.Status = status
+	refundRequest.ProcessedBy = actor.String()
+	switch status {
+	case models.RefundStatusApproved:
+		refundRequest.ApprovedAt = &now
+	case models.RefundStatusRejected:
+		refundRequest.RejectedAt = &now
+		refundRequest.RejectionReason = reason
+	case models.RefundStatusProcessing:
+		refundRequest.ProcessingAt = &now
+		refundRequest.PayoutKey = ""
+		refundRequest.PayoutID = ""
+	case models.RefundStatusProcessed:
+		refundRequest.ProcessedAt = &now
+	case models.RefundStatusFailed:
+		refundRequest.FailedAt = &now
+	}
+	if err := tx.Omit("History").Save(refundRequest).Error; err != nil {
+		return fmt.Errorf("failed to update refund request: %w", err)
+	}
+
+	if err := recordRefundChange(tx, refundRequest, from, actor, reason); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"refund_status": status}
+	if status == models.RefundStatusProcessed {
+		updates["refund_processed_at"] = now
+	}
+	if err := tx.Model(&models.Booking{}).Where("id = ?", refundRequest.BookingID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update booking: %w", err)
+	}
+	return nil
+}
+
+// recordRefundChange adds the refund request's move from a status to its current one to its history
+func recordRefundChange(tx *gorm.DB, refundRequest *models.RefundRequest, from string, actor Actor, reason string) error {
+	change := models.RefundStatusChange{
+		ID:              uuid.New(),
+		RefundRequestID: refundRequest.ID,
+		FromStatus:      from,
+		ToStatus:        refundRequest.Status,
+		ActorRole:       actor.Role,
+		Reason:          reason,
+	}
+	if actor.UserID != uuid.Nil {
+		change.ActorID = &actor.UserID
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to record refund history: %w", err)
+	}
+	return nil
+}
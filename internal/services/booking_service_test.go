@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/events"
+	"callcenter/internal/models"
+)
+
+// recordEvents collects the BookingCancelled events published about a booking
+func recordEvents(t *testing.T, bookingID uuid.UUID) func() []events.Event {
+	t.Helper()
+	var mu sync.Mutex
+	var received []events.Event
+	events.Subscribe(events.BookingCancelled, func(ctx context.Context, event events.Event) {
+		if event.AggregateID != bookingID {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event)
+	})
+	return func() []events.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]events.Event(nil), received...)
+	}
+}
+
+func TestCancelBooking(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	agentUser := createTestUser(t, db, models.RoleAgent)
+	booking := createTestBooking(t, db, customer, 1)
+	ticket := createTestTicket(t, db, customer, booking)
+	published := recordEvents(t, booking.ID)
+	actor := Actor{UserID: agentUser.ID, Role: agentUser.Role}
+
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{}, actor)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	refundRequest, err := bookings.CancelBooking(ctx, Cancellation{
+		BookingID: booking.ID,
+		QuoteID:   quote.ID,
+		Reason:    "Schedule change",
+		Actor:     actor,
+		TicketID:  &ticket.ID,
+	})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+
+	var stored models.RefundRequest
+	if err := db.Preload("Items").Preload("History").First(&stored, refundRequest.ID).Error; err != nil {
+		t.Fatalf("get refund request: %v", err)
+	}
+	switch {
+	case stored.BookingID != booking.ID:
+		t.Errorf("refund booking = %s, want %s", stored.BookingID, booking.ID)
+	case stored.TicketID == nil || *stored.TicketID != ticket.ID:
+		t.Errorf("refund ticket = %v, want %s", stored.TicketID, ticket.ID)
+	case stored.QuoteID == nil || *stored.QuoteID != quote.ID:
+		t.Errorf("refund quote = %v, want %s", stored.QuoteID, quote.ID)
+	case stored.Status != models.RefundStatusPending:
+		t.Errorf("refund status = %s, want pending", stored.Status)
+	case stored.Amount != quote.Amount || stored.Currency != quote.Currency:
+		t.Errorf("refund = %d %s, want the quoted %d %s", stored.Amount, stored.Currency, quote.Amount, quote.Currency)
+	case stored.RequestedBy != agentUser.ID.String():
+		t.Errorf("requested by %q, want the agent", stored.RequestedBy)
+	}
+	if len(stored.Items) != len(quote.Items) || len(stored.Items) != 2 {
+		t.Errorf("refund has %d line items, want the quote's %d (one per segment)", len(stored.Items), len(quote.Items))
+	}
+	var itemsTotal int64
+	for _, item := range stored.Items {
+		itemsTotal += int64(item.Amount)
+		if item.QuoteID != quote.ID {
+			t.Errorf("line item %s is not from the accepted quote", item.ID)
+		}
+	}
+	if itemsTotal != int64(stored.Amount) {
+		t.Errorf("line items add up to %d, want %d", itemsTotal, stored.Amount)
+	}
+	if len(stored.History) != 1 || stored.History[0].ToStatus != models.RefundStatusPending {
+		t.Errorf("refund history = %+v, want the pending status it was opened in", stored.History)
+	}
+
+	var history []models.TicketHistory
+	if err := db.Where("ticket_id = ? AND action = ?", ticket.ID, "cancelled").Find(&history).Error; err != nil {
+		t.Fatalf("get ticket history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("got %d cancellation history rows, want 1", len(history))
+	}
+	if history[0].UserID != agentUser.ID || history[0].Description != "Schedule change" {
+		t.Errorf("history by %s (%q), want the agent with the reason", history[0].UserID, history[0].Description)
+	}
+
+	var cancelled models.Booking
+	if err := withItinerary(db).First(&cancelled, "id = ?", booking.ID).Error; err != nil {
+		t.Fatalf("get booking: %v", err)
+	}
+	if cancelled.Status != models.BookingStatusCancelled || cancelled.CancelledAt == nil {
+		t.Errorf("booking status = %s, want cancelled", cancelled.Status)
+	}
+	if cancelled.RefundAmount != quote.Amount {
+		t.Errorf("booking refund amount = %d, want %d", cancelled.RefundAmount, quote.Amount)
+	}
+	for _, passenger := range cancelled.Passengers {
+		if passenger.Status != models.BookingStatusCancelled {
+			t.Errorf("passenger %s is %s, want cancelled", passenger.ID, passenger.Status)
+		}
+	}
+
+	received := published()
+	if len(received) != 1 {
+		t.Fatalf("got %d cancellation events, want 1", len(received))
+	}
+	data := received[0].Data
+	if data["refund_request_id"] != refundRequest.ID || data["partial"] != false || data["actor"] != agentUser.ID.String() {
+		t.Errorf("event data = %v", data)
+	}
+	if ticketID, _ := data["ticket_id"].(*uuid.UUID); ticketID == nil || *ticketID != ticket.ID {
+		t.Errorf("event ticket = %v, want %s", data["ticket_id"], ticket.ID)
+	}
+}
+
+func TestCancelBookingPartially(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	booking := createTestBooking(t, db, customer, 2)
+	ticket := createTestTicket(t, db, customer, booking)
+	published := recordEvents(t, booking.ID)
+	actor := Actor{UserID: customer.ID, Role: customer.Role}
+
+	leaving := booking.Passengers[1].ID
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{PassengerIDs: []uuid.UUID{leaving, leaving}}, actor)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	if len(quote.Items) != 2 {
+		t.Fatalf("quote has %d line items, want one per segment of the passenger", len(quote.Items))
+	}
+	refundRequest, err := bookings.CancelBooking(ctx, Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Reason: "Not travelling", Actor: actor})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+
+	var history []models.TicketHistory
+	db.Where("ticket_id = ? AND action = ?", ticket.ID, "partially_cancelled").Find(&history)
+	if len(history) != 1 || history[0].UserID != customer.ID {
+		t.Errorf("history = %+v, want one partial cancellation by the customer", history)
+	}
+
+	var stored models.Booking
+	withItinerary(db).First(&stored, "id = ?", booking.ID)
+	if stored.Status != models.BookingStatusActive {
+		t.Errorf("booking status = %s, want active", stored.Status)
+	}
+	for _, passenger := range stored.Passengers {
+		want := models.BookingStatusActive
+		if passenger.ID == leaving {
+			want = models.BookingStatusCancelled
+		}
+		if passenger.Status != want {
+			t.Errorf("passenger %s is %s, want %s", passenger.ID, passenger.Status, want)
+		}
+	}
+
+	received := published()
+	if len(received) != 1 || received[0].Data["partial"] != true || received[0].Data["refund_request_id"] != refundRequest.ID {
+		t.Errorf("events = %+v, want one partial cancellation", received)
+	}
+
+	// The coupons are gone, so quoting them again has nothing left to refund
+	if _, err := bookings.QuoteRefund(ctx, booking, RefundSelection{PassengerIDs: []uuid.UUID{leaving}}, actor); !errors.Is(err, ErrConflict) {
+		t.Errorf("quoting cancelled coupons: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestCancelBookingRejectsQuotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, bookings *BookingService, booking *models.Booking, quote *models.RefundQuote)
+		want  error
+	}{
+		{
+			name: "expired quote",
+			setup: func(t *testing.T, bookings *BookingService, booking *models.Booking, quote *models.RefundQuote) {
+				if err := bookings.db.Model(&models.RefundQuote{}).Where("id = ?", quote.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+					t.Fatalf("expire quote: %v", err)
+				}
+			},
+			want: ErrConflict,
+		},
+		{
+			name: "already used quote",
+			setup: func(t *testing.T, bookings *BookingService, booking *models.Booking, quote *models.RefundQuote) {
+				if err := bookings.db.Model(&models.RefundQuote{}).Where("id = ?", quote.ID).Update("used_at", time.Now()).Error; err != nil {
+					t.Fatalf("use quote: %v", err)
+				}
+			},
+			want: ErrConflict,
+		},
+		{
+			name: "quote of another booking",
+			setup: func(t *testing.T, bookings *BookingService, booking *models.Booking, quote *models.RefundQuote) {
+				other := createTestBooking(t, bookings.db, &models.User{ID: booking.UserID}, 1)
+				if err := bookings.db.Model(&models.RefundQuote{}).Where("id = ?", quote.ID).Update("booking_id", other.ID).Error; err != nil {
+					t.Fatalf("move quote: %v", err)
+				}
+			},
+			want: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			ctx := t.Context()
+			bookings := NewBookingService(db)
+
+			customer := createTestUser(t, db, models.RoleUser)
+			booking := createTestBooking(t, db, customer, 1)
+			published := recordEvents(t, booking.ID)
+			actor := Actor{UserID: customer.ID, Role: customer.Role}
+
+			quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{}, actor)
+			if err != nil {
+				t.Fatalf("QuoteRefund: %v", err)
+			}
+			tt.setup(t, bookings, booking, quote)
+
+			_, err = bookings.CancelBooking(ctx, Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Actor: actor})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+
+			var refunds int64
+			db.Model(&models.RefundRequest{}).Where("booking_id = ?", booking.ID).Count(&refunds)
+			var stored models.Booking
+			db.First(&stored, "id = ?", booking.ID)
+			if refunds != 0 || stored.Status != models.BookingStatusActive {
+				t.Errorf("booking is %s with %d refund requests, want it untouched", stored.Status, refunds)
+			}
+			if len(published()) != 0 {
+				t.Error("a cancellation event was published for a rejected quote")
+			}
+		})
+	}
+}
+
+func TestCancelBookingReusedQuote(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	booking := createTestBooking(t, db, customer, 2)
+	actor := Actor{UserID: customer.ID, Role: customer.Role}
+
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{PassengerIDs: []uuid.UUID{booking.Passengers[0].ID}}, actor)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	cancellation := Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Actor: actor}
+	if _, err := bookings.CancelBooking(ctx, cancellation); err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if _, err := bookings.CancelBooking(ctx, cancellation); !errors.Is(err, ErrConflict) {
+		t.Fatalf("reusing the quote: err = %v, want ErrConflict", err)
+	}
+
+	var refunds int64
+	db.Model(&models.RefundRequest{}).Where("booking_id = ?", booking.ID).Count(&refunds)
+	if refunds != 1 {
+		t.Errorf("got %d refund requests, want 1", refunds)
+	}
+}
+
+func TestCancelBookingBySystem(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	booking := createTestBooking(t, db, customer, 1)
+	ticket := createTestTicket(t, db, customer, booking)
+	system := Actor{Role: models.RoleSystem}
+
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{}, system)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	refundRequest, err := bookings.CancelBooking(ctx, Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Actor: system})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if refundRequest.RequestedBy != models.RoleSystem {
+		t.Errorf("requested by %q, want system", refundRequest.RequestedBy)
+	}
+
+	// History rows need a real user, so system cancellations leave none
+	var history int64
+	db.Model(&models.TicketHistory{}).Where("ticket_id = ?", ticket.ID).Count(&history)
+	if history != 0 {
+		t.Errorf("got %d history rows for a system cancellation, want 0", history)
+	}
+}
+
+// A booking migrated from a support ticket has its fares but no passengers or
+// segments, and is quoted and cancelled whole
+func TestCancelMigratedBooking(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	migrated := &models.Booking{
+		ID:         uuid.New(),
+		UserID:     customer.ID,
+		TicketType: models.TicketTypeSystematic,
+		Status:     models.BookingStatusActive,
+		BaseFare:   10000000,
+		TotalFare:  10000000,
+		Currency:   "IRR",
+	}
+	if err := db.Create(migrated).Error; err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	booking, err := bookings.GetBooking(ctx, migrated.ID)
+	if err != nil {
+		t.Fatalf("GetBooking: %v", err)
+	}
+	actor := Actor{UserID: customer.ID, Role: customer.Role}
+
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{}, actor)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	if quote.BaseFare != booking.BaseFare || quote.Amount <= 0 || quote.Amount > booking.TotalFare || len(quote.Items) != 0 {
+		t.Fatalf("quote of %d from a base fare of %d with %d items, want a refund of the whole fare without items",
+			quote.Amount, quote.BaseFare, len(quote.Items))
+	}
+
+	refundRequest, err := bookings.CancelBooking(ctx, Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Actor: actor})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if refundRequest.Amount != quote.Amount {
+		t.Errorf("refund = %d, want the quoted %d", refundRequest.Amount, quote.Amount)
+	}
+	cancelled, err := bookings.GetBooking(ctx, booking.ID)
+	if err != nil {
+		t.Fatalf("GetBooking: %v", err)
+	}
+	if cancelled.Status != models.BookingStatusCancelled || cancelled.RefundAmount != quote.Amount {
+		t.Errorf("booking %s with refund %d, want cancelled with %d", cancelled.Status, cancelled.RefundAmount, quote.Amount)
+	}
+}
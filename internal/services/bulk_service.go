@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/models"
+)
+
+// MaxBulkTickets is the most tickets one bulk job can change
+const MaxBulkTickets = 5000
+
+// bulkJobStale is how long a running job can go without progress before
+// another worker takes it over, e.g. after the server running it stopped
+const bulkJobStale = 5 * time.Minute
+
+// bulkBatch is how many tickets of a job are read at a time
+const bulkBatch = 100
+
+// BulkParams are the parameters of the action of a bulk job. Each action
+// reads its own fields:
+//   - status: Status, with an optional reason code and Description
+//   - assign: QueueID, AgentID, Auto, Unassign and Note, as in Assignment
+//   - tag: AddTags and RemoveTags
+//   - comment: Body and Visibility, internal by default
+//   - cancel: Reason, cancelling every open coupon of the booking at the
+//     refund quoted when the ticket is processed
+type BulkParams struct {
+	Status      string     `json:"status,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	Description string     `json:"description,omitempty"`
+	QueueID     *uuid.UUID `json:"queue_id,omitempty"`
+	AgentID     *uuid.UUID `json:"agent_id,omitempty"`
+	Auto        bool       `json:"auto,omitempty"`
+	Unassign    bool       `json:"unassign,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	AddTags     []string   `json:"add_tags,omitempty"`
+	RemoveTags  []string   `json:"remove_tags,omitempty"`
+	Body        string     `json:"body,omitempty"`
+	Visibility  string     `json:"visibility,omitempty"`
+}
+
+// NewBulkJob is a bulk job to create
+type NewBulkJob struct {
+	Action string
+	Params BulkParams
+	Search TicketSearch
+}
+
+// BulkService applies an action to many tickets at once, as jobs run in the
+// background that keep the result of every ticket
+type BulkService struct {
+	db       *gorm.DB
+	tickets  *TicketService
+	comments *CommentService
+}
+
+// NewBulkService creates a new instance of BulkService
+func NewBulkService(db *gorm.DB, tickets *TicketService) *BulkService {
+	return &BulkService{
+		db:       db,
+		tickets:  tickets,
+		comments: NewCommentService(db),
+	}
+}
+
+// CreateJob queues a bulk job for the tickets its search matches now, in the
+// order they were opened, on behalf of a supervisor or admin. Tickets opened
+// later are left out, and merged tickets are skipped. The search needs at least
+// one filter and may match up to MaxBulkTickets tickets.
+func (s *BulkService) CreateJob(ctx context.Context, input NewBulkJob, actor Actor) (*models.BulkJob, error) {
+	if actor.Role != models.RoleSupervisor && actor.Role != models.RoleAdmin {
+		return nil, fmt.Errorf("%w: only supervisors and admins can run bulk jobs", ErrForbidden)
+	}
+	params, err := checkBulkParams(input.Action, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !input.Search.filtered() {
+		return nil, fmt.Errorf("%w: a bulk job needs at least one search filter", ErrInvalidInput)
+	}
+
+	query, err := s.tickets.searchQuery(ctx, input.Search, actor)
+	if err != nil {
+		return nil, err
+	}
+	var tickets []models.Ticket
+	if err := query.Select("tickets.id", "tickets.number").
+		Where("tickets.merged_into_id IS NULL").
+		Order("tickets.created_at, tickets.id").
+		Limit(MaxBulkTickets + 1).
+		Find(&tickets).Error; err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+	if len(tickets) == 0 {
+		return nil, fmt.Errorf("%w: no tickets match the search", ErrInvalidInput)
+	}
+	if len(tickets) > MaxBulkTickets {
+		return nil, fmt.Errorf("%w: the search matches more than %d tickets", ErrInvalidInput, MaxBulkTickets)
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job parameters: %w", err)
+	}
+	searchJSON, err := json.Marshal(input.Search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job search: %w", err)
+	}
+	job := &models.BulkJob{
+		ID:          uuid.New(),
+		Action:      input.Action,
+		Params:      string(paramsJSON),
+		Search:      string(searchJSON),
+		Status:      models.BulkJobPending,
+		Total:       len(tickets),
+		CreatedBy:   actor.UserID,
+		CreatorRole: actor.Role,
+	}
+	items := make([]models.BulkJobItem, 0, len(tickets))
+	for i, ticket := range tickets {
+		items = append(items, models.BulkJobItem{
+			JobID:        job.ID,
+			TicketID:     ticket.ID,
+			TicketNumber: ticket.Number,
+			Position:     i + 1,
+			Status:       models.BulkItemPending,
+		})
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return fmt.Errorf("failed to create bulk job: %w", err)
+		}
+		if err := tx.CreateInBatches(items, 500).Error; err != nil {
+			return fmt.Errorf("failed to create bulk job items: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// checkBulkParams checks the parameters of a bulk action and returns them with
+// the fields of other actions cleared and defaults filled in
+func checkBulkParams(action string, params BulkParams) (BulkParams, error) {
+	switch action {
+	case models.BulkActionStatus:
+		if _, ok := ticketTransitions[params.Status]; !ok {
+			return params, fmt.Errorf("%w: unknown ticket status %q", ErrInvalidInput, params.Status)
+		}
+		if params.Reason != "" && !containsString(statusReasons, params.Reason) {
+			return params, fmt.Errorf("%w: unknown status reason %q", ErrInvalidInput, params.Reason)
+		}
+		return BulkParams{Status: params.Status, Reason: params.Reason, Description: params.Description}, nil
+
+	case models.BulkActionAssign:
+		if params.QueueID == nil && params.AgentID == nil && !params.Auto && !params.Unassign {
+			return params, fmt.Errorf("%w: nothing to assign", ErrInvalidInput)
+		}
+		return BulkParams{
+			QueueID:  params.QueueID,
+			AgentID:  params.AgentID,
+			Auto:     params.Auto,
+			Unassign: params.Unassign,
+			Note:     params.Note,
+		}, nil
+
+	case models.BulkActionTag:
+		add, remove := normalizeTags(params.AddTags), normalizeTags(params.RemoveTags)
+		if len(add) == 0 && len(remove) == 0 {
+			return params, fmt.Errorf("%w: no tags to add or remove", ErrInvalidInput)
+		}
+		if err := checkTags(add); err != nil {
+			return params, err
+		}
+		return BulkParams{AddTags: add, RemoveTags: remove}, nil
+
+	case models.BulkActionComment:
+		body := strings.TrimSpace(params.Body)
+		if body == "" {
+			return params, fmt.Errorf("%w: comment body is required", ErrInvalidInput)
+		}
+		visibility := params.Visibility
+		if visibility == "" {
+			visibility = models.CommentInternal
+		}
+		if visibility != models.CommentPublic && visibility != models.CommentInternal {
+			return params, fmt.Errorf("%w: unknown visibility %q", ErrInvalidInput, visibility)
+		}
+		return BulkParams{Body: body, Visibility: visibility}, nil
+
+	case models.BulkActionCancel:
+		reason := strings.TrimSpace(params.Reason)
+		if reason == "" {
+			reason = "Bulk cancellation"
+		}
+		return BulkParams{Reason: reason}, nil
+
+	default:
+		return params, fmt.Errorf("%w: unknown bulk action %q", ErrInvalidInput, action)
+	}
+}
+
+// filtered tells whether a search has any filter
+func (search TicketSearch) filtered() bool {
+	return search.UserID != nil || search.Number != "" || search.Phone != "" || search.Email != "" ||
+		len(search.Statuses) > 0 || len(search.Priorities) > 0 || len(search.Types) > 0 ||
+		search.CreatedFrom != nil || search.CreatedTo != nil || search.UpdatedFrom != nil || search.UpdatedTo != nil ||
+		search.Assignee != "" || search.QueueID != nil || len(search.Tags) > 0 || search.SLA != "" ||
+		len(significantWords(search.Text)) > 0
+}
+
+// GetJob retrieves a bulk job with the results of its tickets in the order they
+// are processed, only those of one item status when status is not empty
+func (s *BulkService) GetJob(ctx context.Context, jobID uuid.UUID, status string) (*models.BulkJob, error) {
+	var job models.BulkJob
+	if err := s.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			if status != "" {
+				db = db.Where("status = ?", status)
+			}
+			return db.Order("position")
+		}).
+		First(&job, "id = ?", jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bulk job %w: %s", ErrNotFound, jobID)
+		}
+		return nil, fmt.Errorf("failed to get bulk job: %w", err)
+	}
+	return &job, nil
+}
+
+// ListJobs lists the latest bulk jobs, newest first, without their items
+func (s *BulkService) ListJobs(ctx context.Context, limit int) ([]models.BulkJob, error) {
+	var jobs []models.BulkJob
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list bulk jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Poll runs the pending bulk jobs one after another, oldest first, and takes
+// over running jobs that stopped making progress. Workers on several servers
+// never run the same job at once. A ticket whose result was not recorded when
+// its job stopped has the action applied again.
+func (s *BulkService) Poll(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := s.claim(ctx)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+		if err := s.process(ctx, job); err != nil {
+			log.Printf("bulk job %s: %v", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// Run polls every interval until the context is cancelled
+func (s *BulkService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Poll(ctx); err != nil {
+			log.Printf("bulk jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the next job to run as running, or returns nil when there is none
+func (s *BulkService) claim(ctx context.Context) (*models.BulkJob, error) {
+	var job *models.BulkJob
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var jobs []models.BulkJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND updated_at < ?)",
+				models.BulkJobPending, models.BulkJobRunning, time.Now().Add(-bulkJobStale)).
+			Order("created_at").
+			Limit(1).
+			Find(&jobs).Error; err != nil {
+			return fmt.Errorf("failed to get bulk jobs: %w", err)
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		job = &jobs[0]
+		updates := map[string]interface{}{"status": models.BulkJobRunning}
+		if job.StartedAt == nil {
+			updates["started_at"] = time.Now()
+		}
+		if err := tx.Model(job).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to start bulk job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// process applies the action of a running job to its pending tickets and
+// finishes the job once none is left
+func (s *BulkService) process(ctx context.Context, job *models.BulkJob) error {
+	var params BulkParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return fmt.Errorf("failed to decode job parameters: %w", err)
+	}
+	actor := Actor{UserID: job.CreatedBy, Role: job.CreatorRole}
+
+	for {
+		var items []models.BulkJobItem
+		if err := s.db.WithContext(ctx).
+			Where("job_id = ? AND status = ?", job.ID, models.BulkItemPending).
+			Order("position").
+			Limit(bulkBatch).
+			Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to get bulk job items: %w", err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		for i := range items {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			applyErr := s.apply(ctx, job.Action, params, &items[i], actor)
+			if err := s.record(ctx, job, &items[i], applyErr); err != nil {
+				return err
+			}
+		}
+	}
+	return s.finish(ctx, job)
+}
+
+// apply applies the action of a job to one ticket
+func (s *BulkService) apply(ctx context.Context, action string, params BulkParams, item *models.BulkJobItem, actor Actor) error {
+	switch action {
+	case models.BulkActionStatus:
+		_, err := s.tickets.UpdateStatus(ctx, item.TicketID, params.Status, params.Reason, params.Description, actor)
+		return err
+	case models.BulkActionAssign:
+		_, err := s.tickets.AssignTicket(ctx, item.TicketID, Assignment{
+			QueueID:  params.QueueID,
+			AgentID:  params.AgentID,
+			Auto:     params.Auto,
+			Unassign: params.Unassign,
+			Note:     params.Note,
+		}, actor)
+		return err
+	case models.BulkActionTag:
+		_, err := s.tickets.TagTicket(ctx, item.TicketID, params.AddTags, params.RemoveTags, actor)
+		return err
+	}
+
+	ticket, err := s.tickets.FindTicket(ctx, item.TicketID.String(), actor)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case models.BulkActionComment:
+		_, err = s.comments.AddComment(ctx, ticket, NewComment{Body: params.Body, Visibility: params.Visibility}, actor)
+		return err
+	case models.BulkActionCancel:
+		quote, err := s.tickets.QuoteCancellation(ctx, ticket.Number, RefundSelection{}, actor)
+		if err != nil {
+			return err
+		}
+		_, err = s.tickets.CancelTicket(ctx, ticket.Number, quote.ID, params.Reason, actor)
+		return err
+	default:
+		return fmt.Errorf("%w: unknown bulk action %q", ErrInvalidInput, action)
+	}
+}
+
+// record stores the result of a ticket of a job and counts it, which also
+// shows the job is making progress
+func (s *BulkService) record(ctx context.Context, job *models.BulkJob, item *models.BulkJobItem, applyErr error) error {
+	status, counter, message := models.BulkItemSucceeded, "succeeded", ""
+	if applyErr != nil {
+		status, counter, message = models.BulkItemFailed, "failed", applyErr.Error()
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BulkJobItem{}).
+			Where("job_id = ? AND ticket_id = ? AND status = ?", item.JobID, item.TicketID, models.BulkItemPending).
+			Updates(map[string]interface{}{
+				"status":       status,
+				"error":        message,
+				"processed_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to record bulk job item: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Model(job).Update(counter, gorm.Expr(counter+" + 1")).Error; err != nil {
+			return fmt.Errorf("failed to update bulk job: %w", err)
+		}
+		return nil
+	})
+}
+
+// finish sets the final status of a job from the results of its tickets
+func (s *BulkService) finish(ctx context.Context, job *models.BulkJob) error {
+	db := s.db.WithContext(ctx)
+	var counts []struct {
+		Status string
+		Count  int
+	}
+	if err := db.Model(&models.BulkJobItem{}).
+		Select("status, count(*) AS count").
+		Where("job_id = ?", job.ID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return fmt.Errorf("failed to count bulk job items: %w", err)
+	}
+	succeeded, failed := 0, 0
+	for _, count := range counts {
+		switch count.Status {
+		case models.BulkItemSucceeded:
+			succeeded = count.Count
+		case models.BulkItemFailed:
+			failed = count.Count
+		}
+	}
+
+	status := models.BulkJobCompleted
+	switch {
+	case failed > 0 && succeeded == 0:
+		status = models.BulkJobFailed
+	case failed > 0:
+		status = models.BulkJobPartiallyFailed
+	}
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status":      status,
+		"succeeded":   succeeded,
+		"failed":      failed,
+		"finished_at": time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to finish bulk job: %w", err)
+	}
+	return nil
+}
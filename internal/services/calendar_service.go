@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/calendar"
+	"callcenter/internal/models"
+)
+
+// CalendarService manages business calendars and computes business time on them
+type CalendarService struct {
+	db *gorm.DB
+}
+
+// NewCalendarService creates a new instance of CalendarService
+func NewCalendarService(db *gorm.DB) *CalendarService {
+	return &CalendarService{db: db}
+}
+
+// ListCalendars returns every calendar with its working hours and holidays,
+// the default calendar first
+func (s *CalendarService) ListCalendars(ctx context.Context) ([]models.BusinessCalendar, error) {
+	var calendars []models.BusinessCalendar
+	if err := s.db.WithContext(ctx).
+		Preload("Hours", orderWorkingHours).
+		Preload("Holidays", orderHolidays).
+		Order("is_default DESC, name").
+		Find(&calendars).Error; err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+	return calendars, nil
+}
+
+// GetCalendar retrieves a calendar with its working hours and holidays
+func (s *CalendarService) GetCalendar(ctx context.Context, id uuid.UUID) (*models.BusinessCalendar, error) {
+	return getCalendar(s.db.WithContext(ctx), id)
+}
+
+func getCalendar(tx *gorm.DB, id uuid.UUID) (*models.BusinessCalendar, error) {
+	var model models.BusinessCalendar
+	if err := tx.
+		Preload("Hours", orderWorkingHours).
+		Preload("Holidays", orderHolidays).
+		First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("calendar %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	return &model, nil
+}
+
+func orderWorkingHours(db *gorm.DB) *gorm.DB {
+	return db.Order("weekday, opens")
+}
+
+func orderHolidays(db *gorm.DB) *gorm.DB {
+	return db.Order("system, year, month, day")
+}
+
+// SaveCalendar creates or updates a calendar and replaces its working hours
+// and holidays. Making a calendar the default takes over from the previous
+// default; there is always one default calendar once calendars exist.
+func (s *CalendarService) SaveCalendar(ctx context.Context, model *models.BusinessCalendar) error {
+	model.Name = strings.TrimSpace(model.Name)
+	if model.Name == "" {
+		return fmt.Errorf("%w: calendar name is required", ErrInvalidInput)
+	}
+	for i := range model.Holidays {
+		if model.Holidays[i].System == "" {
+			model.Holidays[i].System = calendar.Gregorian
+		}
+	}
+	if _, err := toCalendar(model); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.BusinessCalendar
+		if err := tx.Where("name = ? OR is_default OR id = ?", model.Name, model.ID).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check calendars: %w", err)
+		}
+		found := model.ID == uuid.Nil
+		hasDefault := false
+		for _, other := range existing {
+			switch {
+			case other.ID == model.ID:
+				found = true
+				if other.IsDefault && !model.IsDefault {
+					return fmt.Errorf("%w: make another calendar the default first", ErrConflict)
+				}
+			case other.Name == model.Name:
+				return fmt.Errorf("%w: calendar %s already exists", ErrConflict, model.Name)
+			case other.IsDefault:
+				hasDefault = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("calendar %w: %s", ErrNotFound, model.ID)
+		}
+
+		if !hasDefault {
+			model.IsDefault = true
+		} else if model.IsDefault {
+			if err := tx.Model(&models.BusinessCalendar{}).Where("is_default AND id <> ?", model.ID).
+				Update("is_default", false).Error; err != nil {
+				return fmt.Errorf("failed to change the default calendar: %w", err)
+			}
+		}
+
+		hours, holidays := model.Hours, model.Holidays
+		if model.ID == uuid.Nil {
+			model.ID = uuid.New()
+			if err := tx.Omit("Hours", "Holidays").Create(model).Error; err != nil {
+				return fmt.Errorf("failed to create calendar: %w", err)
+			}
+		} else {
+			if err := tx.Model(model).Select("name", "time_zone", "is_default").Updates(model).Error; err != nil {
+				return fmt.Errorf("failed to update calendar: %w", err)
+			}
+			if err := tx.Where("calendar_id = ?", model.ID).Delete(&models.WorkingHours{}).Error; err != nil {
+				return fmt.Errorf("failed to replace working hours: %w", err)
+			}
+			if err := tx.Where("calendar_id = ?", model.ID).Delete(&models.CalendarHoliday{}).Error; err != nil {
+				return fmt.Errorf("failed to replace holidays: %w", err)
+			}
+		}
+
+		for i := range hours {
+			hours[i].ID = uuid.New()
+			hours[i].CalendarID = model.ID
+		}
+		for i := range holidays {
+			holidays[i].ID = uuid.New()
+			holidays[i].CalendarID = model.ID
+		}
+		if len(hours) > 0 {
+			if err := tx.Create(&hours).Error; err != nil {
+				return fmt.Errorf("failed to save working hours: %w", err)
+			}
+		}
+		if len(holidays) > 0 {
+			if err := tx.Create(&holidays).Error; err != nil {
+				return fmt.Errorf("failed to save holidays: %w", err)
+			}
+		}
+		model.Hours, model.Holidays = hours, holidays
+		return nil
+	})
+}
+
+// DeleteCalendar removes a calendar other than the default one. Queues on it
+// fall back to the default calendar; due times already set stay.
+func (s *CalendarService) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var model models.BusinessCalendar
+		if err := tx.First(&model, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("calendar %w: %s", ErrNotFound, id)
+			}
+			return fmt.Errorf("failed to get calendar: %w", err)
+		}
+		if model.IsDefault {
+			return fmt.Errorf("%w: the default calendar cannot be deleted", ErrConflict)
+		}
+
+		if err := tx.Model(&models.Queue{}).Where("calendar_id = ?", id).Update("calendar_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to move queues to the default calendar: %w", err)
+		}
+		for _, child := range []interface{}{&models.WorkingHours{}, &models.CalendarHoliday{}} {
+			if err := tx.Where("calendar_id = ?", id).Delete(child).Error; err != nil {
+				return fmt.Errorf("failed to delete calendar: %w", err)
+			}
+		}
+		if err := tx.Delete(&model).Error; err != nil {
+			return fmt.Errorf("failed to delete calendar: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddHoliday adds a day off to a calendar
+func (s *CalendarService) AddHoliday(ctx context.Context, calendarID uuid.UUID, holiday *models.CalendarHoliday) error {
+	holiday.Name = strings.TrimSpace(holiday.Name)
+	if holiday.System == "" {
+		holiday.System = calendar.Gregorian
+	}
+	if holiday.Name == "" {
+		return fmt.Errorf("%w: holiday name is required", ErrInvalidInput)
+	}
+	if err := toHoliday(holiday).Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if _, err := s.GetCalendar(ctx, calendarID); err != nil {
+		return err
+	}
+
+	holiday.ID = uuid.New()
+	holiday.CalendarID = calendarID
+	if err := s.db.WithContext(ctx).Create(holiday).Error; err != nil {
+		return fmt.Errorf("failed to add holiday: %w", err)
+	}
+	return nil
+}
+
+// RemoveHoliday removes a day off from a calendar
+func (s *CalendarService) RemoveHoliday(ctx context.Context, calendarID, holidayID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.CalendarHoliday{}, "id = ? AND calendar_id = ?", holidayID, calendarID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove holiday: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("holiday %w: %s", ErrNotFound, holidayID)
+	}
+	return nil
+}
+
+// Calendar returns a calendar ready to compute business time with
+func (s *CalendarService) Calendar(ctx context.Context, id uuid.UUID) (*calendar.Calendar, error) {
+	model, err := s.GetCalendar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toCalendar(model)
+}
+
+// DefaultCalendar returns the default calendar ready to compute business time with
+func (s *CalendarService) DefaultCalendar(ctx context.Context) (*calendar.Calendar, error) {
+	return s.forQueue(s.db.WithContext(ctx), nil)
+}
+
+// forQueue returns the calendar the tickets of a queue count business time on:
+// the queue's calendar, else the default calendar, else calendar.Default while
+// no calendars are set up
+func (s *CalendarService) forQueue(tx *gorm.DB, queueID *uuid.UUID) (*calendar.Calendar, error) {
+	query := tx.Preload("Hours").Preload("Holidays")
+	if queueID != nil {
+		// The queue's own calendar comes before the default one
+		query = query.Where("is_default OR id = (SELECT calendar_id FROM queues WHERE id = ?)", *queueID).
+			Order("is_default")
+	} else {
+		query = query.Where("is_default")
+	}
+
+	var model models.BusinessCalendar
+	err := query.First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return calendar.Default, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	return toCalendar(&model)
+}
+
+// toCalendar converts a stored calendar for computing business time, checking
+// its time zone, working hours and holidays
+func toCalendar(model *models.BusinessCalendar) (*calendar.Calendar, error) {
+	location, err := time.LoadLocation(model.TimeZone)
+	if err != nil || model.TimeZone == "" {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidInput, model.TimeZone)
+	}
+
+	result := &calendar.Calendar{Location: location}
+	for _, hours := range model.Hours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return nil, fmt.Errorf("%w: invalid weekday %d", ErrInvalidInput, hours.Weekday)
+		}
+		opens, err := parseClock(hours.Opens)
+		if err != nil {
+			return nil, err
+		}
+		closes, err := parseClock(hours.Closes)
+		if err != nil {
+			return nil, err
+		}
+		result.Week[hours.Weekday] = append(result.Week[hours.Weekday], calendar.Span{Start: opens, End: closes})
+	}
+	for _, spans := range result.Week {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	}
+	for i := range model.Holidays {
+		result.Holidays = append(result.Holidays, toHoliday(&model.Holidays[i]))
+	}
+
+	if err := result.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return result, nil
+}
+
+func toHoliday(holiday *models.CalendarHoliday) calendar.Holiday {
+	return calendar.Holiday{
+		Name:   holiday.Name,
+		System: holiday.System,
+		Year:   holiday.Year,
+		Month:  holiday.Month,
+		Day:    holiday.Day,
+	}
+}
+
+// parseClock parses a time of day such as 08:30 into minutes after midnight.
+// 24:00 stands for the end of the day.
+func parseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time of day %q", ErrInvalidInput, value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"callcenter/internal/i18n"
 	"callcenter/internal/models"
 )
 
@@ -46,20 +47,33 @@ func (s *ChatService) CreateSession(ctx context.Context, platform, userID string
 	return session, nil
 }
 
+// GetSession retrieves a chat session by ID
+func (s *ChatService) GetSession(ctx context.Context, sessionID uuid.UUID) (*models.ChatSession, error) {
+	var session models.ChatSession
+	if err := s.db.WithContext(ctx).Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("chat session not found: %s", sessionID)
+		}
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+	return &session, nil
+}
+
 // AddMessage adds a new message to a chat session
-func (s *ChatService) AddMessage(ctx context.Context, sessionID uuid.UUID, content string, role string, intent string, entities map[string]interface{}) (*models.ChatMessage, error) {
+func (s *ChatService) AddMessage(ctx context.Context, sessionID uuid.UUID, content string, role string, intent string, intentScore float64, entities map[string]interface{}) (*models.ChatMessage, error) {
 	entitiesJSON, err := json.Marshal(entities)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entities: %w", err)
 	}
 
 	message := &models.ChatMessage{
-		ID:        uuid.New(),
-		SessionID: sessionID,
-		Content:   content,
-		Role:      role,
-		Intent:    intent,
-		Entities:  string(entitiesJSON),
+		ID:          uuid.New(),
+		SessionID:   sessionID,
+		Content:     content,
+		Role:        role,
+		Intent:      intent,
+		IntentScore: intentScore,
+		Entities:    string(entitiesJSON),
 	}
 
 	if err := s.db.WithContext(ctx).Create(message).Error; err != nil {
@@ -88,6 +102,62 @@ func (s *ChatService) GetChatHistory(ctx context.Context, sessionID uuid.UUID) (
 	return messages, nil
 }
 
+// BuildDialogContext summarizes the dialog state of a session for intent validation
+// and suggestions: the intents seen so far, the ticket under discussion and the status of its booking.
+// Only the session user's own tickets are looked up unless the actor is staff; the
+// booking of anyone else's ticket stays unknown.
+func (s *ChatService) BuildDialogContext(ctx context.Context, session *models.ChatSession, actor Actor) (map[string]interface{}, error) {
+	messages, err := s.GetChatHistory(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	dialogContext := make(map[string]interface{})
+	var intents []string
+	var ticketNumber string
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		if msg.Intent != "" {
+			intents = append(intents, msg.Intent)
+		}
+		var entities map[string]interface{}
+		if err := json.Unmarshal([]byte(msg.Entities), &entities); err == nil {
+			if number, ok := entities["ticket_number"].(string); ok && number != "" {
+				ticketNumber = number
+			}
+		}
+	}
+
+	dialogContext[ContextIntentHistory] = intents
+	if len(intents) > 0 {
+		dialogContext[ContextLastIntent] = intents[len(intents)-1]
+	}
+
+	if ticketNumber != "" {
+		dialogContext[ContextTicketNumber] = ticketNumber
+
+		query := s.db.WithContext(ctx).Preload("Booking").Where("number = ?", ticketNumber)
+		if !models.IsStaff(actor.Role) {
+			query = query.Where("user_id = ?", session.UserID)
+		}
+		var ticket models.Ticket
+		err := query.First(&ticket).Error
+		switch {
+		case err == nil:
+			if ticket.Booking != nil {
+				dialogContext[ContextBookingStatus] = ticket.Booking.Status
+				dialogContext[ContextRefundStatus] = ticket.Booking.RefundStatus
+			}
+		case err != gorm.ErrRecordNotFound:
+			return nil, fmt.Errorf("failed to get ticket: %w", err)
+		}
+	}
+
+	return dialogContext, nil
+}
+
 // LogChatEvent logs a chat-related event for monitoring and analytics
 func (s *ChatService) LogChatEvent(ctx context.Context, sessionID uuid.UUID, eventType string, eventData map[string]interface{}, processingTime int64, success bool, err error) error {
 	var errorMsg string
@@ -137,3 +207,46 @@ func (s *ChatService) CloseSession(ctx context.Context, sessionID uuid.UUID) err
 	}
 	return nil
 }
+
+// ResolveLanguage returns the language to reply in for a new message in the session.
+// A pinned preference always wins. Otherwise the language detected in text is used and
+// remembered on the session; when the text is too short to tell, the session's last
+// language is kept, falling back to the request's language.
+func (s *ChatService) ResolveLanguage(ctx context.Context, session *models.ChatSession, text, fallback string) (string, error) {
+	if session.LanguagePinned && i18n.IsSupported(session.Language) {
+		return session.Language, nil
+	}
+
+	lang := i18n.DetectLanguage(text)
+	if lang == "" {
+		if i18n.IsSupported(session.Language) {
+			return session.Language, nil
+		}
+		lang = fallback
+	}
+
+	if lang != session.Language {
+		if err := s.db.WithContext(ctx).Model(session).Update("language", lang).Error; err != nil {
+			return "", fmt.Errorf("failed to update session language: %w", err)
+		}
+	}
+	return lang, nil
+}
+
+// SetSessionLanguage pins the reply language of a session to lang
+func (s *ChatService) SetSessionLanguage(ctx context.Context, sessionID uuid.UUID, lang string) error {
+	if !i18n.IsSupported(lang) {
+		return fmt.Errorf("language %w: %s", ErrInvalidInput, lang)
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.ChatSession{}).
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"language": lang, "language_pinned": true})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update session language: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("chat session %w: %s", ErrNotFound, sessionID)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"callcenter/internal/models"
+)
+
+func TestBuildDialogContextOnlyLooksUpOwnTickets(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	chats := NewChatService(db)
+
+	owner := createTestUser(t, db, models.RoleUser)
+	ticket := createTestTicket(t, db, owner, createTestBooking(t, db, owner, 1))
+	stranger := createTestUser(t, db, models.RoleUser)
+	agentUser := createTestUser(t, db, models.RoleAgent)
+
+	tests := []struct {
+		name        string
+		user        *models.User
+		actor       *models.User
+		wantBooking bool
+	}{
+		{name: "owner", user: owner, actor: owner, wantBooking: true},
+		{name: "another customer", user: stranger, actor: stranger},
+		{name: "agent", user: agentUser, actor: agentUser, wantBooking: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := chats.CreateSession(ctx, "web", tt.user.ID.String())
+			if err != nil {
+				t.Fatalf("CreateSession: %v", err)
+			}
+			entities := map[string]interface{}{"ticket_number": ticket.Number}
+			if _, err := chats.AddMessage(ctx, session.ID, "cancel "+ticket.Number, "user", IntentTicketCancellation, 0.9, entities); err != nil {
+				t.Fatalf("AddMessage: %v", err)
+			}
+
+			dialogContext, err := chats.BuildDialogContext(ctx, session, Actor{UserID: tt.actor.ID, Role: tt.actor.Role})
+			if err != nil {
+				t.Fatalf("BuildDialogContext: %v", err)
+			}
+			_, gotBooking := dialogContext[ContextBookingStatus]
+			_, gotRefund := dialogContext[ContextRefundStatus]
+			if gotBooking != tt.wantBooking || gotRefund != tt.wantBooking {
+				t.Errorf("booking and refund status known = %t, %t; want %t", gotBooking, gotRefund, tt.wantBooking)
+			}
+		})
+	}
+}
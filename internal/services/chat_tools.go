@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/llm"
+	"callcenter/internal/models"
+	"callcenter/internal/money"
+)
+
+// Tool names exposed to the language model
+const (
+	ToolGetTicket        = "get_ticket"
+	ToolGetTicketByPhone = "get_ticket_by_phone"
+	ToolQuoteRefund      = "quote_refund"
+	ToolCancelTicket     = "cancel_ticket"
+	ToolGetRefundStatus  = "get_refund_status"
+)
+
+// roleTools lists the tools each role is allowed to trigger through the bot.
+// Customers may only act on their own tickets and cannot search by phone.
+var roleTools = map[string][]string{
+	models.RoleUser:       {ToolGetTicket, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus},
+	models.RoleAgent:      {ToolGetTicket, ToolGetTicketByPhone, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus},
+	models.RoleSupervisor: {ToolGetTicket, ToolGetTicketByPhone, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus},
+	models.RoleAdmin:      {ToolGetTicket, ToolGetTicketByPhone, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus},
+}
+
+// toolSpecs holds the function definitions sent to the model
+var toolSpecs = map[string]llm.FunctionSpec{
+	ToolGetTicket: {
+		Name:        ToolGetTicket,
+		Description: "Look up a flight ticket by its ticket number.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"ticket_number": {"type": "string", "description": "The ticket number"}
+			},
+			"required": ["ticket_number"]
+		}`),
+	},
+	ToolGetTicketByPhone: {
+		Name:        ToolGetTicketByPhone,
+		Description: "List the tickets booked with a passenger phone number.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"phone_number": {"type": "string", "description": "The passenger phone number"}
+			},
+			"required": ["phone_number"]
+		}`),
+	},
+	ToolQuoteRefund: {
+		Name: ToolQuoteRefund,
+		Description: "Compute how much the customer gets back if the ticket's booking is cancelled now. " +
+			"To cancel only some passengers or flights of a group booking, pass their e-ticket numbers or flights. " +
+			"Returns the refund breakdown and a quote_token that expires after a few minutes.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"ticket_number": {"type": "string", "description": "The ticket number"},
+				"e_ticket_numbers": {"type": "array", "items": {"type": "string"}, "description": "E-ticket numbers of the passengers to cancel; omit for all passengers"},
+				"flights": {"type": "array", "items": {"type": "string"}, "description": "Flights to cancel, e.g. IR452; omit for all flights"}
+			},
+			"required": ["ticket_number"]
+		}`),
+	},
+	ToolCancelTicket: {
+		Name: ToolCancelTicket,
+		Description: "Cancel a ticket and start the refund process at the quoted amount. Only call this after the customer " +
+			"has seen the refund quote and explicitly confirmed, in their latest message, that they want this ticket cancelled.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"ticket_number": {"type": "string", "description": "The ticket number"},
+				"quote_token": {"type": "string", "description": "The quote_token of the quote the customer confirmed"},
+				"reason": {"type": "string", "description": "Why the customer wants to cancel"},
+				"confirmed": {"type": "boolean", "description": "True only if the customer explicitly confirmed the cancellation"}
+			},
+			"required": ["ticket_number", "quote_token", "confirmed"]
+		}`),
+	},
+	ToolGetRefundStatus: {
+		Name:        ToolGetRefundStatus,
+		Description: "Get the status of every refund request for a ticket, newest first.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"ticket_number": {"type": "string", "description": "The ticket number"}
+			},
+			"required": ["ticket_number"]
+		}`),
+	},
+}
+
+// confirmationPhrases are the replies accepted as an explicit confirmation
+var confirmationPhrases = []string{
+	"yes", "confirm", "confirmed", "i confirm", "go ahead", "proceed",
+	"بله", "آره", "تایید", "تأیید", "تایید میکنم", "تأیید می‌کنم", "نعم",
+}
+
+// toolsForRole returns the tool definitions a role may use
+func toolsForRole(role string) []llm.Tool {
+	var tools []llm.Tool
+	for _, name := range roleTools[role] {
+		tools = append(tools, llm.Tool{Type: "function", Function: toolSpecs[name]})
+	}
+	return tools
+}
+
+// toolAllowed reports whether the role may trigger the named tool
+func toolAllowed(role, name string) bool {
+	for _, allowed := range roleTools[role] {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isExplicitConfirmation checks whether a message is an unambiguous confirmation
+func isExplicitConfirmation(text string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(strings.Trim(text, ".!؟?")))
+	for _, phrase := range confirmationPhrases {
+		if normalized == phrase || strings.HasPrefix(normalized, phrase+" ") || strings.HasPrefix(normalized, phrase+",") {
+			return true
+		}
+	}
+	return false
+}
+
+type ticketArgs struct {
+	TicketNumber   string   `json:"ticket_number"`
+	PhoneNumber    string   `json:"phone_number"`
+	ETicketNumbers []string `json:"e_ticket_numbers"`
+	Flights        []string `json:"flights"`
+	QuoteToken     string   `json:"quote_token"`
+	Reason         string   `json:"reason"`
+	Confirmed      bool     `json:"confirmed"`
+}
+
+// selection resolves the passengers and flights named in the arguments on a booking
+func (a ticketArgs) selection(booking *models.Booking) (RefundSelection, error) {
+	var selection RefundSelection
+	for _, number := range a.ETicketNumbers {
+		found := false
+		for _, passenger := range booking.Passengers {
+			if passenger.TicketNumber == number {
+				selection.PassengerIDs = append(selection.PassengerIDs, passenger.ID)
+				found = true
+			}
+		}
+		if !found {
+			return selection, fmt.Errorf("no passenger with e-ticket %s on this booking", number)
+		}
+	}
+	for _, flight := range a.Flights {
+		found := false
+		for _, segment := range booking.Segments {
+			if strings.EqualFold(segment.Airline+segment.FlightNumber, strings.ReplaceAll(flight, " ", "")) {
+				selection.SegmentIDs = append(selection.SegmentIDs, segment.ID)
+				found = true
+			}
+		}
+		if !found {
+			return selection, fmt.Errorf("no flight %s on this booking", flight)
+		}
+	}
+	return selection, nil
+}
+
+// executeTool runs a tool call on behalf of the actor and returns the JSON result for the model.
+// Failures are reported to the model as results rather than aborting the conversation.
+func (s *ResponseService) executeTool(ctx context.Context, actor Actor, call llm.ToolCall, history []models.ChatMessage) string {
+	if !toolAllowed(actor.Role, call.Function.Name) {
+		return toolError(fmt.Sprintf("tool %s is not permitted for role %s", call.Function.Name, actor.Role))
+	}
+
+	var args ticketArgs
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		return toolError("invalid arguments")
+	}
+
+	switch call.Function.Name {
+	case ToolGetTicket:
+		ticket, err := s.tickets.GetTicket(ctx, args.TicketNumber)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		if !actor.canAccess(ticket) {
+			return toolError(fmt.Sprintf("ticket not found: %s", args.TicketNumber))
+		}
+		return toolResult(ticketSummary(ticket))
+
+	case ToolGetTicketByPhone:
+		tickets, err := s.tickets.GetTicketByPhone(ctx, args.PhoneNumber, actor)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		summaries := make([]map[string]interface{}, 0, len(tickets))
+		for i := range tickets {
+			summaries = append(summaries, ticketSummary(&tickets[i]))
+		}
+		return toolResult(map[string]interface{}{"tickets": summaries})
+
+	case ToolQuoteRefund:
+		ticket, err := s.tickets.GetTicket(ctx, args.TicketNumber)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		if !actor.canAccess(ticket) {
+			return toolError(fmt.Sprintf("ticket not found: %s", args.TicketNumber))
+		}
+		if ticket.Booking == nil {
+			return toolError(fmt.Sprintf("ticket %s has no booking", args.TicketNumber))
+		}
+		selection, err := args.selection(ticket.Booking)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		quote, err := s.tickets.QuoteCancellation(ctx, args.TicketNumber, selection, actor)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		return toolResult(quoteSummary(quote))
+
+	case ToolCancelTicket:
+		ticket, err := s.tickets.GetTicket(ctx, args.TicketNumber)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		if !actor.canAccess(ticket) {
+			return toolError(fmt.Sprintf("ticket not found: %s", args.TicketNumber))
+		}
+		confirmed := confirmation(history, args.TicketNumber)
+		if !args.Confirmed || confirmed == nil {
+			return toolResult(map[string]interface{}{
+				"status":  "confirmation_required",
+				"message": fmt.Sprintf("Ask the customer to explicitly confirm cancelling ticket %s before calling this tool.", args.TicketNumber),
+			})
+		}
+		quoteID, err := uuid.Parse(args.QuoteToken)
+		if err != nil {
+			return toolError("a valid quote_token from quote_refund is required")
+		}
+		quote, err := s.tickets.GetRefundQuote(ctx, args.TicketNumber, quoteID)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		// The customer can only have confirmed a quote shown before their confirmation
+		if !quote.CreatedAt.Before(confirmed.CreatedAt) {
+			return toolResult(map[string]interface{}{
+				"status":  "confirmation_required",
+				"message": fmt.Sprintf("Show the customer this quote for ticket %s and ask them to explicitly confirm it before calling this tool.", args.TicketNumber),
+			})
+		}
+		refund, err := s.tickets.CancelTicket(ctx, args.TicketNumber, quoteID, args.Reason, actor)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		return toolResult(map[string]interface{}{
+			"status":        "cancelled",
+			"ticket_number": args.TicketNumber,
+			"refund_amount": money.Display(refund.Amount, refund.Currency),
+		})
+
+	case ToolGetRefundStatus:
+		ticket, err := s.tickets.GetTicket(ctx, args.TicketNumber)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		if !actor.canAccess(ticket) {
+			return toolError(fmt.Sprintf("ticket not found: %s", args.TicketNumber))
+		}
+		refunds, err := s.tickets.ListRefundRequests(ctx, args.TicketNumber)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		if len(refunds) == 0 {
+			return toolError(fmt.Sprintf("no refund requested for ticket %s", args.TicketNumber))
+		}
+		summaries := make([]map[string]interface{}, 0, len(refunds))
+		for _, refund := range refunds {
+			summaries = append(summaries, map[string]interface{}{
+				"status":       refund.Status,
+				"amount":       money.Display(refund.Amount, refund.Currency),
+				"requested_at": refund.CreatedAt,
+			})
+		}
+		return toolResult(map[string]interface{}{
+			"ticket_number": args.TicketNumber,
+			"refunds":       summaries,
+		})
+	}
+
+	return toolError(fmt.Sprintf("unknown tool: %s", call.Function.Name))
+}
+
+// confirmation returns the latest user message if it is an explicit confirmation
+// replying to an assistant message that asked about this ticket, or nil
+func confirmation(history []models.ChatMessage, ticketNumber string) *models.ChatMessage {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "user" {
+			continue
+		}
+		if !isExplicitConfirmation(history[i].Content) {
+			return nil
+		}
+		for j := i - 1; j >= 0; j-- {
+			if history[j].Role == "assistant" {
+				if strings.Contains(history[j].Content, ticketNumber) {
+					return &history[i]
+				}
+				return nil
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func ticketSummary(ticket *models.Ticket) map[string]interface{} {
+	summary := map[string]interface{}{
+		"ticket_number": ticket.Number,
+		"status":        ticket.Status,
+		"subject":       ticket.Subject,
+	}
+	if ticket.Booking != nil {
+		summary["booking"] = bookingSummary(ticket.Booking)
+	}
+	return summary
+}
+
+func bookingSummary(booking *models.Booking) map[string]interface{} {
+	segments := make([]map[string]interface{}, 0, len(booking.Segments))
+	for _, segment := range booking.Segments {
+		segments = append(segments, map[string]interface{}{
+			"flight":       segment.Airline + segment.FlightNumber,
+			"origin":       segment.Origin,
+			"destination":  segment.Destination,
+			"departure_at": segment.DepartureAt,
+			"status":       segment.Status,
+		})
+	}
+	passengers := make([]map[string]interface{}, 0, len(booking.Passengers))
+	for _, passenger := range booking.Passengers {
+		passengers = append(passengers, map[string]interface{}{
+			"name":          passenger.FirstName + " " + passenger.LastName,
+			"type":          passenger.Type,
+			"ticket_number": passenger.TicketNumber,
+			"status":        passenger.Status,
+		})
+	}
+	return map[string]interface{}{
+		"pnr":           booking.PNR,
+		"airline":       booking.Airline,
+		"ticket_type":   booking.TicketType,
+		"status":        booking.Status,
+		"total_fare":    money.Display(booking.TotalFare, booking.Currency),
+		"refund_status": booking.RefundStatus,
+		"segments":      segments,
+		"passengers":    passengers,
+	}
+}
+
+// quoteSummary is the refund breakdown shown to the customer before they
+// confirm. Amounts are formatted so the model repeats them as customers expect,
+// in tomans for rial fares.
+func quoteSummary(quote *models.RefundQuote) map[string]interface{} {
+	return map[string]interface{}{
+		"quote_token":    quote.ID,
+		"base_fare":      money.Display(quote.BaseFare, quote.Currency),
+		"penalty":        money.Display(quote.Penalty, quote.Currency),
+		"fee":            money.Display(quote.Fee, quote.Currency),
+		"taxes_refunded": money.Display(quote.TaxesRefunded, quote.Currency),
+		"amount":         money.Display(quote.Amount, quote.Currency),
+		"coupons":        len(quote.Items), // passengers times flights being cancelled
+		"expires_at":     quote.ExpiresAt,
+	}
+}
+
+func toolResult(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return toolError("failed to encode result")
+	}
+	return string(data)
+}
+
+func toolError(msg string) string {
+	data, _ := json.Marshal(map[string]string{"error": msg})
+	return string(data)
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/events"
+	"callcenter/internal/i18n"
+	"callcenter/internal/models"
+)
+
+// MaxAttachmentSize bounds the size of a file attached to a comment
+const MaxAttachmentSize = 10 << 20
+
+// CommentService handles the comments on support tickets: replies to the
+// customer and internal notes between staff
+type CommentService struct {
+	db  *gorm.DB
+	sla *SLAService
+}
+
+// NewCommentService creates a new instance of CommentService
+func NewCommentService(db *gorm.DB) *CommentService {
+	return &CommentService{
+		db:  db,
+		sla: NewSLAService(db),
+	}
+}
+
+// NewComment is a comment to add to a ticket
+type NewComment struct {
+	Body       string
+	Visibility string      // models.CommentPublic or models.CommentInternal
+	Mentions   []uuid.UUID // agents to call into the ticket
+}
+
+// ListComments lists the comments on a ticket, oldest first, with their
+// mentions and attachments. Staff also get the edit history; customers only
+// get the public comments.
+func (s *CommentService) ListComments(ctx context.Context, ticketID uuid.UUID, actor Actor) ([]models.TicketComment, error) {
+	query := s.db.WithContext(ctx).
+		Preload("Mentions").
+		Preload("Attachments", withoutAttachmentData).
+		Where("ticket_id = ?", ticketID)
+	if models.IsStaff(actor.Role) {
+		query = query.Preload("Edits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		})
+	} else {
+		query = query.Where("visibility = ?", models.CommentPublic)
+	}
+
+	var comments []models.TicketComment
+	if err := query.Order("created_at, id").Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+	return comments, nil
+}
+
+// AddComment adds a comment to a ticket on behalf of the actor and records it
+// in the ticket history. Customers can only add public comments and mention
+// nobody. A public comment by staff is a reply: it is sent to the customer's
+// latest chat session and meets the first response target of the ticket.
+func (s *CommentService) AddComment(ctx context.Context, ticket *models.Ticket, input NewComment, actor Actor) (*models.TicketComment, error) {
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Body == "" {
+		return nil, fmt.Errorf("%w: comment body is required", ErrInvalidInput)
+	}
+	staff := models.IsStaff(actor.Role)
+	if !staff && (input.Visibility != models.CommentPublic || len(input.Mentions) > 0) {
+		return nil, fmt.Errorf("%w: customers can only add public comments without mentions", ErrForbidden)
+	}
+	if input.Visibility != models.CommentPublic && input.Visibility != models.CommentInternal {
+		return nil, fmt.Errorf("%w: unknown visibility %q", ErrInvalidInput, input.Visibility)
+	}
+	mentions := uniqueIDs(input.Mentions)
+
+	comment := &models.TicketComment{
+		ID:         uuid.New(),
+		TicketID:   ticket.ID,
+		AuthorID:   actor.UserID,
+		Visibility: input.Visibility,
+		Body:       input.Body,
+	}
+	reply := staff && comment.Visibility == models.CommentPublic
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.requireStaff(tx, mentions); err != nil {
+			return err
+		}
+		if reply {
+			if err := s.deliver(tx, ticket, comment); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(comment).Error; err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		for _, userID := range mentions {
+			mention := models.CommentMention{CommentID: comment.ID, UserID: userID}
+			if err := tx.Create(&mention).Error; err != nil {
+				return fmt.Errorf("failed to create mention: %w", err)
+			}
+			comment.Mentions = append(comment.Mentions, mention)
+		}
+
+		action := "commented"
+		if comment.Visibility == models.CommentInternal {
+			action = "noted"
+		}
+		if err := recordTicketHistory(tx, ticket.ID, action, excerpt(comment.Body), actor); err != nil {
+			return err
+		}
+		if reply {
+			return s.sla.responded(tx, ticket.ID, comment.CreatedAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events.Publish(ctx, events.New(events.TicketCommented, ticket.ID, map[string]interface{}{
+		"number":      ticket.Number,
+		"comment_id":  comment.ID,
+		"author_id":   comment.AuthorID,
+		"visibility":  comment.Visibility,
+		"channel":     comment.Channel,
+		"customer_id": ticket.UserID,
+	}))
+	if len(mentions) > 0 {
+		events.Publish(ctx, events.New(events.TicketMentioned, ticket.ID, map[string]interface{}{
+			"number":     ticket.Number,
+			"comment_id": comment.ID,
+			"author_id":  comment.AuthorID,
+			"mentions":   mentions,
+		}))
+	}
+	return comment, nil
+}
+
+// EditComment replaces the body of a comment, keeping the previous body in
+// its edit history. Only the author can edit a comment; a reply that was
+// already sent to the customer is not sent again.
+func (s *CommentService) EditComment(ctx context.Context, ticketID, commentID uuid.UUID, body string, actor Actor) (*models.TicketComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, fmt.Errorf("%w: comment body is required", ErrInvalidInput)
+	}
+
+	var comment models.TicketComment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&comment, "id = ? AND ticket_id = ?", commentID, ticketID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("comment %w: %s", ErrNotFound, commentID)
+			}
+			return fmt.Errorf("failed to get comment: %w", err)
+		}
+		if !models.IsStaff(actor.Role) && comment.Visibility != models.CommentPublic {
+			return fmt.Errorf("comment %w: %s", ErrNotFound, commentID)
+		}
+		if comment.AuthorID != actor.UserID {
+			return fmt.Errorf("%w: only the author can edit a comment", ErrForbidden)
+		}
+		if body == comment.Body {
+			return nil
+		}
+
+		edit := models.CommentEdit{
+			ID:        uuid.New(),
+			CommentID: comment.ID,
+			Body:      comment.Body,
+			EditorID:  actor.UserID,
+		}
+		if err := tx.Create(&edit).Error; err != nil {
+			return fmt.Errorf("failed to record comment edit: %w", err)
+		}
+		now := time.Now()
+		if err := tx.Model(&comment).Updates(map[string]interface{}{"body": body, "edited_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+		return recordTicketHistory(tx, ticketID, "comment_edited", excerpt(body), actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.getComment(ctx, ticketID, commentID, actor)
+}
+
+// AddAttachment attaches a file to a comment. Only the author of the comment
+// can attach files to it.
+func (s *CommentService) AddAttachment(ctx context.Context, ticketID, commentID uuid.UUID, attachment *models.CommentAttachment, actor Actor) error {
+	if attachment.FileName == "" {
+		return fmt.Errorf("%w: file name is required", ErrInvalidInput)
+	}
+	if attachment.Size = int64(len(attachment.Data)); attachment.Size == 0 || attachment.Size > MaxAttachmentSize {
+		return fmt.Errorf("%w: attachments must have between 1 and %d bytes", ErrInvalidInput, MaxAttachmentSize)
+	}
+
+	comment, err := s.getComment(ctx, ticketID, commentID, actor)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != actor.UserID {
+		return fmt.Errorf("%w: only the author can attach files to a comment", ErrForbidden)
+	}
+
+	attachment.ID = uuid.New()
+	attachment.CommentID = comment.ID
+	attachment.UploaderID = actor.UserID
+	if err := s.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachment retrieves a file attached to a comment on a ticket with its
+// content. Customers only get the files of public comments.
+func (s *CommentService) GetAttachment(ctx context.Context, ticketID, attachmentID uuid.UUID, actor Actor) (*models.CommentAttachment, error) {
+	query := s.db.WithContext(ctx).
+		Joins("JOIN ticket_comments ON ticket_comments.id = comment_attachments.comment_id").
+		Where("comment_attachments.id = ? AND ticket_comments.ticket_id = ?", attachmentID, ticketID)
+	if !models.IsStaff(actor.Role) {
+		query = query.Where("ticket_comments.visibility = ?", models.CommentPublic)
+	}
+
+	var attachment models.CommentAttachment
+	if err := query.First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("attachment %w: %s", ErrNotFound, attachmentID)
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+// getComment retrieves a comment on a ticket that the actor may see
+func (s *CommentService) getComment(ctx context.Context, ticketID, commentID uuid.UUID, actor Actor) (*models.TicketComment, error) {
+	query := s.db.WithContext(ctx).
+		Preload("Mentions").
+		Preload("Attachments", withoutAttachmentData).
+		Where("id = ? AND ticket_id = ?", commentID, ticketID)
+	if models.IsStaff(actor.Role) {
+		query = query.Preload("Edits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		})
+	} else {
+		query = query.Where("visibility = ?", models.CommentPublic)
+	}
+
+	var comment models.TicketComment
+	if err := query.First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("comment %w: %s", ErrNotFound, commentID)
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return &comment, nil
+}
+
+// deliver sends a reply to the chat session the customer was last active in,
+// in the language of the session, and records where it went. Customers without
+// a chat session read the reply on the ticket.
+func (s *CommentService) deliver(tx *gorm.DB, ticket *models.Ticket, comment *models.TicketComment) error {
+	var session models.ChatSession
+	err := tx.Where("user_id = ? AND status <> ?", ticket.UserID, "closed").
+		Order("last_activity DESC").
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get chat session: %w", err)
+	}
+
+	message := models.ChatMessage{
+		ID:        uuid.New(),
+		SessionID: session.ID,
+		Content: i18n.T(session.Language, "bot.ticket_reply", i18n.Params{
+			"ticket_number": ticket.Number,
+			"reply":         comment.Body,
+		}),
+		Role:     "agent",
+		Entities: "{}",
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	if err := tx.Model(&session).Update("last_activity", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	comment.Channel = session.Platform
+	comment.ChatMessageID = &message.ID
+	return nil
+}
+
+// requireStaff checks that the users are all call center staff
+func (s *CommentService) requireStaff(tx *gorm.DB, userIDs []uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.User{}).
+		Where("id IN ? AND role IN ?", userIDs, []string{models.RoleAgent, models.RoleSupervisor, models.RoleAdmin}).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to get mentioned agents: %w", err)
+	}
+	if int(count) != len(userIDs) {
+		return fmt.Errorf("%w: only agents can be mentioned", ErrInvalidInput)
+	}
+	return nil
+}
+
+// withoutAttachmentData loads attachments without their content
+func withoutAttachmentData(db *gorm.DB) *gorm.DB {
+	return db.Omit("data").Order("created_at")
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	var unique []uuid.UUID
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// excerpt shortens a comment body for the ticket history
+func excerpt(body string) string {
+	const maxRunes = 200
+	runes := []rune(body)
+	if len(runes) <= maxRunes {
+		return body
+	}
+	return string(runes[:maxRunes]) + "…"
+}
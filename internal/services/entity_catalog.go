@@ -0,0 +1,53 @@
+package services
+
+import "callcenter/internal/fuzzy"
+
+// airlineEntries maps IATA airline codes to the names customers use for them
+var airlineEntries = []fuzzy.Entry{
+	{Value: "IR", Phrases: []string{"iran air", "iranair", "homa", "ایران ایر", "هما"}},
+	{Value: "B9", Phrases: []string{"iran airtour", "airtour", "ایران ایرتور", "ایرتور"}},
+	{Value: "W5", Phrases: []string{"mahan air", "mahan", "ماهان"}},
+	{Value: "EP", Phrases: []string{"aseman", "iran aseman", "آسمان"}},
+	{Value: "QB", Phrases: []string{"qeshm air", "qeshm airlines", "قشم ایر"}},
+	{Value: "Y9", Phrases: []string{"kish air", "کیش ایر"}},
+	{Value: "RV", Phrases: []string{"caspian", "کاسپین"}},
+	{Value: "ZV", Phrases: []string{"zagros", "زاگرس"}},
+	{Value: "VR", Phrases: []string{"varesh", "وارش"}},
+	{Value: "HH", Phrases: []string{"taban", "تابان"}},
+	{Value: "IS", Phrases: []string{"sepehran", "سپهران"}},
+	{Value: "NV", Phrases: []string{"karun", "karoon", "کارون"}},
+	{Value: "TK", Phrases: []string{"turkish airlines", "ترکیش"}},
+	{Value: "EK", Phrases: []string{"emirates", "امارات"}},
+}
+
+var airlineDictionary = fuzzy.NewDictionary(airlineEntries)
+
+// isAirlineCode reports whether code is an airline of the catalog
+func isAirlineCode(code string) bool {
+	for _, entry := range airlineEntries {
+		if entry.Value == code {
+			return true
+		}
+	}
+	return false
+}
+
+// airportDictionary maps IATA airport codes to city and airport names
+var airportDictionary = fuzzy.NewDictionary([]fuzzy.Entry{
+	{Value: "IKA", Phrases: []string{"imam khomeini", "ika", "فرودگاه امام", "امام خمینی"}},
+	{Value: "THR", Phrases: []string{"mehrabad", "tehran", "مهرآباد", "تهران"}},
+	{Value: "MHD", Phrases: []string{"mashhad", "mashad", "مشهد"}},
+	{Value: "SYZ", Phrases: []string{"shiraz", "شیراز"}},
+	{Value: "IFN", Phrases: []string{"isfahan", "esfahan", "اصفهان"}},
+	{Value: "TBZ", Phrases: []string{"tabriz", "تبریز"}},
+	{Value: "AWZ", Phrases: []string{"ahvaz", "ahwaz", "اهواز"}},
+	{Value: "BND", Phrases: []string{"bandar abbas", "بندرعباس", "بندر عباس"}},
+	{Value: "KER", Phrases: []string{"kerman", "کرمان"}},
+	{Value: "RAS", Phrases: []string{"rasht", "رشت"}},
+	{Value: "KSH", Phrases: []string{"kermanshah", "کرمانشاه"}},
+	{Value: "KIH", Phrases: []string{"kish island", "کیش"}},
+	{Value: "GSM", Phrases: []string{"qeshm island", "جزیره قشم"}},
+	{Value: "IST", Phrases: []string{"istanbul", "استانبول"}},
+	{Value: "DXB", Phrases: []string{"dubai", "دبی"}},
+	{Value: "NJF", Phrases: []string{"najaf", "نجف"}},
+})
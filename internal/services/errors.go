@@ -0,0 +1,25 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors wrapped by service methods so handlers can map them to HTTP statuses
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is returned when a request is well-formed but semantically invalid
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrConflict is returned when a change clashes with the current state of a record
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is returned when the actor's role does not allow the change
+	ErrForbidden = errors.New("forbidden")
+)
+
+// isUniqueViolation reports whether err is a Postgres unique violation of the named index
+func isUniqueViolation(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == index
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/fuzzy"
+	"callcenter/internal/i18n"
+	"callcenter/internal/models"
+)
+
+// maxSearchTerms bounds the number of words of a query sent to full-text search
+const maxSearchTerms = 16
+
+// maxExcerptLength bounds the excerpt quoted for articles without a summary
+const maxExcerptLength = 300
+
+// slugPattern restricts article slugs to URL-safe lowercase words
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// stopWords are left out of search queries. The simple text search configuration
+// keeps every word, so without this list any article containing "the" or "از"
+// would match.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "is": true, "are": true, "was": true, "be": true,
+	"to": true, "of": true, "in": true, "on": true, "at": true, "for": true, "from": true,
+	"with": true, "and": true, "or": true, "my": true, "me": true, "i": true, "you": true,
+	"your": true, "we": true, "it": true, "this": true, "that": true, "what": true,
+	"how": true, "much": true, "many": true, "do": true, "does": true, "can": true,
+	"could": true, "would": true, "should": true, "will": true, "please": true,
+	"about": true, "there": true, "any": true, "have": true, "has": true, "get": true,
+	"از": true, "به": true, "با": true, "در": true, "برای": true, "که": true, "این": true,
+	"آن": true, "را": true, "و": true, "یا": true, "من": true, "ما": true, "شما": true,
+	"چه": true, "چقدر": true, "چطور": true, "چگونه": true, "آیا": true, "است": true,
+	"هست": true, "می": true, "خواهم": true, "میخواهم": true, "لطفا": true, "لطفاً": true,
+	"تا": true, "هم": true, "رو": true,
+}
+
+// ArticleFilter narrows the articles returned by ListArticles
+type ArticleFilter struct {
+	Airline  string
+	Topic    string
+	Language string
+	// IncludeDrafts also returns unpublished articles
+	IncludeDrafts bool
+}
+
+// ArticleQuery is a full-text search over published articles. Airline and Topic
+// narrow the results; Language only ranks articles in that language first.
+type ArticleQuery struct {
+	Text     string
+	Airline  string
+	Topic    string
+	Language string
+	Limit    int
+}
+
+// ArticleMatch is an article found by full-text search with its relevance
+type ArticleMatch struct {
+	models.Article
+	Rank float64
+}
+
+// KnowledgeService manages the FAQ knowledge base and searches it
+type KnowledgeService struct {
+	db            *gorm.DB
+	helpCenterURL string
+}
+
+// NewKnowledgeService creates a new instance of KnowledgeService. Articles without
+// their own URL link to helpCenterURL followed by their slug.
+func NewKnowledgeService(db *gorm.DB, helpCenterURL string) *KnowledgeService {
+	return &KnowledgeService{
+		db:            db,
+		helpCenterURL: strings.TrimRight(helpCenterURL, "/"),
+	}
+}
+
+// CreateArticle validates and stores a new article
+func (s *KnowledgeService) CreateArticle(ctx context.Context, article *models.Article) error {
+	if err := s.prepareArticle(ctx, article); err != nil {
+		return err
+	}
+	article.ID = uuid.New()
+
+	if err := s.db.WithContext(ctx).Create(article).Error; err != nil {
+		return fmt.Errorf("failed to create article: %w", err)
+	}
+	return nil
+}
+
+// UpdateArticle validates and saves changes to an existing article
+func (s *KnowledgeService) UpdateArticle(ctx context.Context, article *models.Article) error {
+	if err := s.prepareArticle(ctx, article); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Save(article).Error; err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+	return nil
+}
+
+// prepareArticle validates an article and derives its search text
+func (s *KnowledgeService) prepareArticle(ctx context.Context, article *models.Article) error {
+	article.Slug = strings.ToLower(strings.TrimSpace(article.Slug))
+	article.Airline = strings.ToUpper(strings.TrimSpace(article.Airline))
+
+	switch {
+	case !slugPattern.MatchString(article.Slug):
+		return fmt.Errorf("%w: slug must be lowercase words separated by hyphens", ErrInvalidInput)
+	case !isArticleTopic(article.Topic):
+		return fmt.Errorf("%w: unknown topic %s", ErrInvalidInput, article.Topic)
+	case !i18n.IsSupported(article.Language):
+		return fmt.Errorf("%w: unsupported language %s", ErrInvalidInput, article.Language)
+	case article.Airline != "" && !isAirlineCode(article.Airline):
+		return fmt.Errorf("%w: unknown airline %s", ErrInvalidInput, article.Airline)
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Article{}).
+		Where("slug = ? AND id <> ?", article.Slug, article.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check article slug: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: slug %s is already in use", ErrConflict, article.Slug)
+	}
+
+	article.SearchText = fuzzy.Normalize(strings.Join([]string{article.Title, article.Summary, article.Body}, "\n"))
+	return nil
+}
+
+// GetArticle retrieves an article by ID
+func (s *KnowledgeService) GetArticle(ctx context.Context, id uuid.UUID) (*models.Article, error) {
+	var article models.Article
+	if err := s.db.WithContext(ctx).First(&article, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("article %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get article: %w", err)
+	}
+	return &article, nil
+}
+
+// ListArticles returns articles matching filter, most recently updated first,
+// with the total number of matches
+func (s *KnowledgeService) ListArticles(ctx context.Context, filter ArticleFilter, limit, offset int) ([]models.Article, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Article{})
+	if filter.Airline != "" {
+		query = query.Where("airline = ?", strings.ToUpper(filter.Airline))
+	}
+	if filter.Topic != "" {
+		query = query.Where("topic = ?", filter.Topic)
+	}
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	if !filter.IncludeDrafts {
+		query = query.Where("published = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count articles: %w", err)
+	}
+
+	var articles []models.Article
+	if err := query.Order("updated_at DESC").Limit(limit).Offset(offset).Find(&articles).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list articles: %w", err)
+	}
+	return articles, total, nil
+}
+
+// DeleteArticle removes an article from the knowledge base
+func (s *KnowledgeService) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.Article{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete article: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("article %w: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// Search finds published articles matching any significant word of the query,
+// best first. Articles of the query's airline rank above general ones.
+func (s *KnowledgeService) Search(ctx context.Context, q ArticleQuery) ([]ArticleMatch, error) {
+	terms := searchTerms(q.Text)
+	if terms == "" {
+		return []ArticleMatch{}, nil
+	}
+	if q.Limit <= 0 {
+		q.Limit = 5
+	}
+
+	// The language is selected rather than ordered on, as Order does not take parameters
+	query := s.db.WithContext(ctx).Model(&models.Article{}).
+		Select("articles.*, ts_rank_cd(search_vector, to_tsquery('english', @q) || to_tsquery('simple', @q)) AS rank, language = @lang AS language_match",
+			sql.Named("q", terms), sql.Named("lang", q.Language)).
+		Where("published = ?", true).
+		Where("search_vector @@ (to_tsquery('english', @q) || to_tsquery('simple', @q))", sql.Named("q", terms))
+	if q.Topic != "" {
+		query = query.Where("topic = ?", q.Topic)
+	}
+	if q.Airline != "" {
+		query = query.Where("airline = ? OR airline = ''", strings.ToUpper(q.Airline)).
+			Order("airline = '' ASC")
+	}
+
+	var matches []ArticleMatch
+	if err := query.Order("language_match DESC").Order("rank DESC").Limit(q.Limit).Scan(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to search articles: %w", err)
+	}
+	return matches, nil
+}
+
+// BestMatch returns the most relevant published article for a query, or nil if none match
+func (s *KnowledgeService) BestMatch(ctx context.Context, q ArticleQuery) (*models.Article, error) {
+	q.Limit = 1
+	matches, err := s.Search(ctx, q)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	return &matches[0].Article, nil
+}
+
+// ArticleURL returns the page where customers can read the full article
+func (s *KnowledgeService) ArticleURL(article *models.Article) string {
+	if article.URL != "" {
+		return article.URL
+	}
+	return s.helpCenterURL + "/" + article.Slug
+}
+
+// ArticleExcerpt returns the summary of an article, or the start of its body
+func ArticleExcerpt(article *models.Article) string {
+	if article.Summary != "" {
+		return article.Summary
+	}
+
+	body := strings.TrimSpace(article.Body)
+	if i := strings.Index(body, "\n\n"); i >= 0 {
+		body = body[:i]
+	}
+	if utf8.RuneCountInString(body) <= maxExcerptLength {
+		return body
+	}
+	runes := []rune(body)[:maxExcerptLength]
+	if i := strings.LastIndexFunc(string(runes), unicode.IsSpace); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// searchTerms turns free text into an OR query of its significant words in
+// to_tsquery syntax
+func searchTerms(text string) string {
+	return strings.Join(significantWords(text), " | ")
+}
+
+// significantWords returns the distinct words of free text that are worth
+// searching for. Only letters and digits are kept, so the words are safe to
+// pass to to_tsquery.
+func significantWords(text string) []string {
+	words := strings.FieldsFunc(fuzzy.Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 2 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+func isArticleTopic(topic string) bool {
+	for _, t := range models.ArticleTopics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/models"
+)
+
+// LabelingService maintains the review queue of messages whose intent needs a human label
+type LabelingService struct {
+	db        *gorm.DB
+	threshold float64
+}
+
+// NewLabelingService creates a new instance of LabelingService. Messages whose
+// intent score is below threshold are queued for review.
+func NewLabelingService(db *gorm.DB, threshold float64) *LabelingService {
+	return &LabelingService{
+		db:        db,
+		threshold: threshold,
+	}
+}
+
+// EnqueueIfLowConfidence queues a user message whose intent score fell below the threshold
+func (s *LabelingService) EnqueueIfLowConfidence(ctx context.Context, message *models.ChatMessage) error {
+	if message.Role != "user" || message.IntentScore >= s.threshold {
+		return nil
+	}
+	return s.enqueue(ctx, []models.ChatMessage{*message}, models.LabelingReasonLowConfidence)
+}
+
+// EnqueueEscalatedSession queues every user message of a session that ended in escalation
+func (s *LabelingService) EnqueueEscalatedSession(ctx context.Context, sessionID uuid.UUID) error {
+	var messages []models.ChatMessage
+	if err := s.db.WithContext(ctx).
+		Where("session_id = ? AND role = ?", sessionID, "user").
+		Order("created_at ASC").
+		Find(&messages).Error; err != nil {
+		return fmt.Errorf("failed to get session messages: %w", err)
+	}
+	return s.enqueue(ctx, messages, models.LabelingReasonEscalated)
+}
+
+// enqueue creates pending tasks, leaving messages that are already queued untouched
+func (s *LabelingService) enqueue(ctx context.Context, messages []models.ChatMessage, reason string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	tasks := make([]models.LabelingTask, 0, len(messages))
+	for _, msg := range messages {
+		tasks = append(tasks, models.LabelingTask{
+			ID:                uuid.New(),
+			MessageID:         msg.ID,
+			SessionID:         msg.SessionID,
+			Text:              msg.Content,
+			PredictedIntent:   msg.Intent,
+			PredictedScore:    msg.IntentScore,
+			PredictedEntities: msg.Entities,
+			Reason:            reason,
+			Status:            models.LabelingStatusPending,
+		})
+	}
+
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "message_id"}}, DoNothing: true}).
+		Create(&tasks).Error; err != nil {
+		return fmt.Errorf("failed to create labeling tasks: %w", err)
+	}
+	return nil
+}
+
+// ListTasks returns labeling tasks filtered by status and reason, oldest first.
+// Empty filters match everything.
+func (s *LabelingService) ListTasks(ctx context.Context, status, reason string, limit, offset int) ([]models.LabelingTask, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.LabelingTask{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count labeling tasks: %w", err)
+	}
+
+	var tasks []models.LabelingTask
+	if err := query.Order("created_at ASC").Limit(limit).Offset(offset).Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list labeling tasks: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// LabelTask records the corrected intent and entities of a queued message and
+// copies the label onto the message itself
+func (s *LabelingService) LabelTask(ctx context.Context, taskID uuid.UUID, intent string, entities map[string]string, labelledBy uuid.UUID) (*models.LabelingTask, error) {
+	if !IsKnownIntent(intent) {
+		return nil, fmt.Errorf("%w: unknown intent %s", ErrInvalidInput, intent)
+	}
+
+	entitiesJSON, err := json.Marshal(entities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entities: %w", err)
+	}
+
+	var task models.LabelingTask
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", taskID).First(&task).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("labeling task %w: %s", ErrNotFound, taskID)
+			}
+			return fmt.Errorf("failed to get labeling task: %w", err)
+		}
+
+		now := time.Now()
+		task.Status = models.LabelingStatusLabelled
+		task.CorrectedIntent = intent
+		task.CorrectedEntities = string(entitiesJSON)
+		task.LabelledBy = &labelledBy
+		task.LabelledAt = &now
+		if err := tx.Save(&task).Error; err != nil {
+			return fmt.Errorf("failed to update labeling task: %w", err)
+		}
+
+		if err := tx.Model(&models.ChatMessage{}).
+			Where("id = ?", task.MessageID).
+			Updates(map[string]interface{}{
+				"intent":       intent,
+				"intent_score": 1.0,
+				"entities":     string(entitiesJSON),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update message label: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// SkipTask removes a task from the queue without labelling it
+func (s *LabelingService) SkipTask(ctx context.Context, taskID uuid.UUID, skippedBy uuid.UUID) error {
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.LabelingTask{}).
+		Where("id = ?", taskID).
+		Updates(map[string]interface{}{
+			"status":      models.LabelingStatusSkipped,
+			"labelled_by": skippedBy,
+			"labelled_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to skip labeling task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("labeling task %w: %s", ErrNotFound, taskID)
+	}
+	return nil
+}
+
+// LabelledTasks returns every labelled task, oldest first, for export as training data
+func (s *LabelingService) LabelledTasks(ctx context.Context) ([]models.LabelingTask, error) {
+	var tasks []models.LabelingTask
+	if err := s.db.WithContext(ctx).
+		Where("status = ?", models.LabelingStatusLabelled).
+		Order("labelled_at ASC").
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get labelled tasks: %w", err)
+	}
+	return tasks, nil
+}
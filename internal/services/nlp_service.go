@@ -2,15 +2,104 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
+
+	"callcenter/internal/fuzzy"
+	"callcenter/internal/models"
+	"callcenter/internal/ticketnumber"
+)
+
+// Intent names
+const (
+	IntentTicketLookup       = "ticket_lookup"
+	IntentTicketCancellation = "ticket_cancellation"
+	IntentRefundInquiry      = "refund_inquiry"
+	IntentBaggagePolicy      = "baggage_policy"
+	IntentCheckIn            = "check_in"
+	IntentAgentRequest       = "agent_request"
+	IntentGeneralInquiry     = "general_inquiry"
+)
+
+// Intents lists every intent the chatbot understands
+var Intents = []string{
+	IntentTicketLookup,
+	IntentTicketCancellation,
+	IntentRefundInquiry,
+	IntentBaggagePolicy,
+	IntentCheckIn,
+	IntentAgentRequest,
+	IntentGeneralInquiry,
+}
+
+// IsKnownIntent reports whether name is one of Intents
+func IsKnownIntent(name string) bool {
+	for _, intent := range Intents {
+		if intent == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialog context keys used by ValidateIntent and GetIntentSuggestions
+const (
+	ContextLastIntent    = "last_intent"
+	ContextIntentHistory = "intent_history"
+	ContextTicketNumber  = "ticket_number"
+	ContextBookingStatus = "booking_status"
+	ContextRefundStatus  = "refund_status"
+)
+
+// maxSuggestions caps the number of suggestion chips returned to the client
+const maxSuggestions = 3
+
+// intentDictionary maps each intent to the English and Persian phrases that signal it.
+// Intents are checked in this order, so more specific intents come first.
+var intentDictionary = fuzzy.NewDictionary([]fuzzy.Entry{
+	{Value: IntentAgentRequest, Phrases: []string{"agent", "human", "operator", "representative", "اپراتور", "کارشناس", "پشتیبان"}},
+	{Value: IntentRefundInquiry, Phrases: []string{"refund", "money back", "reimburse", "reimbursement", "استرداد", "بازپرداخت", "عودت", "برگشت پول"}},
+	{Value: IntentTicketCancellation, Phrases: []string{"cancel", "cancellation", "لغو", "کنسل", "ابطال"}},
+	{Value: IntentBaggagePolicy, Phrases: []string{"baggage", "luggage", "suitcase", "چمدان", "بار مجاز", "توشه"}},
+	{Value: IntentCheckIn, Phrases: []string{"check in", "checkin", "boarding pass", "online check", "پذیرش", "چک این", "کارت پرواز"}},
+	{Value: IntentTicketLookup, Phrases: []string{"ticket", "booking", "reservation", "flight", "بلیط", "بلیت", "رزرو", "پرواز"}},
+})
+
+var (
+	// legacyTicketNumberPattern matches the random numbers of tickets opened
+	// before numbers were sequential
+	legacyTicketNumberPattern = regexp.MustCompile(`(?i)\bTKT-[0-9A-F]{8}\b`)
+	phoneNumberPattern        = regexp.MustCompile(`(?:\+98|\b0098|\b0|\b)9\d{9}\b`)
+	emailPattern              = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// digitReplacer maps Persian and Arabic-Indic digits to ASCII
+	digitReplacer = strings.NewReplacer(
+		"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4", "۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+		"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4", "٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+	)
 )
 
-// NLPService handles natural language processing tasks
-type NLPService struct {
-	client *http.Client
-	apiKey string
-	apiURL string
+// NLPService handles natural language processing tasks. Implementations can be
+// compared against labelled data with the nlp-eval command.
+type NLPService interface {
+	// DetectIntent analyzes user input to detect intent and extract entities
+	DetectIntent(ctx context.Context, text string) (*Intent, error)
+	// ValidateIntent validates if the detected intent is valid for the current context
+	ValidateIntent(ctx context.Context, intent *Intent, context map[string]interface{}) error
+	// GetIntentSuggestions returns suggested intents based on the current context
+	GetIntentSuggestions(ctx context.Context, context map[string]interface{}) ([]string, error)
+}
+
+// KeywordNLPService is the built-in NLPService based on keyword rules and pattern extraction.
+// Keywords and known entity values are matched with typo tolerance.
+type KeywordNLPService struct {
+	client  *http.Client
+	apiKey  string
+	apiURL  string
+	matcher *fuzzy.Matcher
+	numbers *ticketnumber.Parser
 }
 
 // Intent represents a detected user intent
@@ -20,85 +109,197 @@ type Intent struct {
 	Entities map[string]interface{} `json:"entities"`
 }
 
-// NewNLPService creates a new instance of NLPService
-func NewNLPService() *NLPService {
-	return &NLPService{}
+// Validation codes identify why an intent was rejected
+const (
+	ValidationRefundNotCancelled = "refund_not_cancelled"
+	ValidationAlreadyCancelled   = "already_cancelled"
+)
+
+// IntentValidationError reports an intent that contradicts the dialog state.
+// Code and Params let callers render the reason in the customer's language.
+type IntentValidationError struct {
+	Intent string
+	Code   string
+	Reason string
+	Params map[string]interface{}
+}
+
+func (e *IntentValidationError) Error() string {
+	return fmt.Sprintf("intent %s is not valid in the current context: %s", e.Intent, e.Reason)
+}
+
+// NewNLPService creates a new instance of the keyword-based NLPService with default
+// fuzzy matching and ticket numbers
+func NewNLPService() *KeywordNLPService {
+	return NewNLPServiceWithOptions(fuzzy.DefaultOptions, ticketnumber.DefaultFormat)
+}
+
+// NewNLPServiceWithOptions creates a keyword-based NLPService with the given matching
+// tolerance that extracts ticket numbers of the given format
+func NewNLPServiceWithOptions(opts fuzzy.Options, numbers ticketnumber.Format) *KeywordNLPService {
+	return &KeywordNLPService{
+		matcher: fuzzy.NewMatcher(opts),
+		numbers: numbers.Parser(),
+	}
 }
 
 // DetectIntent analyzes user input to detect intent and extract entities
-func (s *NLPService) DetectIntent(ctx context.Context, text string) (*Intent, error) {
-	// TODO: Implement actual NLP logic
-	// For now, return a mock intent
-	return &Intent{
-		Name:     "general_inquiry",
-		Score:    0.9,
-		Entities: make(map[string]interface{}),
-	}, nil
-}
-
-// containsKeywords checks if the text contains any of the given keywords
-func containsKeywords(text string, keywords []string) bool {
-	for _, keyword := range keywords {
-		if strings.Contains(strings.ToLower(text), strings.ToLower(keyword)) {
-			return true
-		}
+func (s *KeywordNLPService) DetectIntent(ctx context.Context, text string) (*Intent, error) {
+	prepared := fuzzy.Prepare(text)
+	matched := s.matcher.Match(prepared, intentDictionary)
+
+	intent := &Intent{
+		Name:     IntentGeneralInquiry,
+		Score:    0.3,
+		Entities: s.extractEntities(text, prepared),
 	}
-	return false
+
+	switch {
+	case len(matched) == 1:
+		intent.Name = matched[0]
+		intent.Score = 0.9
+	case len(matched) > 1:
+		// Several intents matched; the most specific one wins with lower confidence
+		intent.Name = matched[0]
+		intent.Score = 0.6
+	case intent.Entities["ticket_number"] != nil || intent.Entities["phone_number"] != nil:
+		// A bare ticket number or phone number is most likely a lookup
+		intent.Name = IntentTicketLookup
+		intent.Score = 0.5
+	}
+
+	return intent, nil
 }
 
 // extractEntities extracts relevant entities from the text
-func extractEntities(text string) map[string]interface{} {
+func (s *KeywordNLPService) extractEntities(text string, prepared *fuzzy.Text) map[string]interface{} {
 	entities := make(map[string]interface{})
+	text = digitReplacer.Replace(text)
 
-	// Extract ticket number (placeholder implementation)
-	if ticketNumber := extractTicketNumber(text); ticketNumber != "" {
+	if ticketNumber := s.extractTicketNumber(text); ticketNumber != "" {
 		entities["ticket_number"] = ticketNumber
 	}
 
-	// Extract phone number (placeholder implementation)
 	if phoneNumber := extractPhoneNumber(text); phoneNumber != "" {
 		entities["phone_number"] = phoneNumber
 	}
 
-	// Extract email (placeholder implementation)
 	if email := extractEmail(text); email != "" {
 		entities["email"] = email
 	}
 
+	if airline, ok := s.matcher.Find(prepared, airlineDictionary); ok {
+		entities["airline"] = airline
+	}
+
+	if airport, ok := s.matcher.Find(prepared, airportDictionary); ok {
+		entities["airport"] = airport
+	}
+
 	return entities
 }
 
-// extractTicketNumber extracts a ticket number from the text
-func extractTicketNumber(text string) string {
-	// TODO: Implement actual ticket number extraction
-	// This is a placeholder implementation
-	return ""
+// extractTicketNumber extracts a ticket number from the text in its stored form.
+// Numbers with a wrong check digit are ignored; legacy numbers (TKT-<hex>) are
+// stored in lower case.
+func (s *KeywordNLPService) extractTicketNumber(text string) string {
+	if number := s.numbers.Find(text); number != "" {
+		return number
+	}
+	match := legacyTicketNumberPattern.FindString(text)
+	if match == "" {
+		return ""
+	}
+	return "TKT-" + strings.ToLower(match[4:])
 }
 
-// extractPhoneNumber extracts a phone number from the text
+// extractPhoneNumber extracts an Iranian mobile number and normalizes it to 09xxxxxxxxx
 func extractPhoneNumber(text string) string {
-	// TODO: Implement actual phone number extraction
-	// This is a placeholder implementation
-	return ""
+	match := phoneNumberPattern.FindString(text)
+	if match == "" {
+		return ""
+	}
+	return "0" + match[len(match)-10:]
 }
 
 // extractEmail extracts an email address from the text
 func extractEmail(text string) string {
-	// TODO: Implement actual email extraction
-	// This is a placeholder implementation
-	return ""
+	return strings.ToLower(emailPattern.FindString(text))
 }
 
-// ValidateIntent validates if the detected intent is valid for the current context
-func (s *NLPService) ValidateIntent(ctx context.Context, intent *Intent, context map[string]interface{}) error {
-	// TODO: Implement intent validation logic
-	// This should check if the intent makes sense given the conversation context
+// ValidateIntent validates if the detected intent is valid for the current context.
+// It returns an *IntentValidationError when the intent contradicts what is known
+// about the ticket under discussion and its booking.
+func (s *KeywordNLPService) ValidateIntent(ctx context.Context, intent *Intent, context map[string]interface{}) error {
+	ticketNumber, _ := context[ContextTicketNumber].(string)
+	bookingStatus, _ := context[ContextBookingStatus].(string)
+	if ticketNumber == "" || bookingStatus == "" {
+		// Nothing is known about a booking yet, so any intent is acceptable
+		return nil
+	}
+
+	switch intent.Name {
+	case IntentRefundInquiry:
+		refundStatus, _ := context[ContextRefundStatus].(string)
+		if bookingStatus != models.BookingStatusCancelled && refundStatus == "" {
+			return &IntentValidationError{
+				Intent: intent.Name,
+				Code:   ValidationRefundNotCancelled,
+				Reason: fmt.Sprintf("ticket %s has not been cancelled, so there is no refund to check", ticketNumber),
+				Params: map[string]interface{}{"ticket_number": ticketNumber},
+			}
+		}
+	case IntentTicketCancellation:
+		if bookingStatus == models.BookingStatusCancelled {
+			return &IntentValidationError{
+				Intent: intent.Name,
+				Code:   ValidationAlreadyCancelled,
+				Reason: fmt.Sprintf("ticket %s is already cancelled", ticketNumber),
+				Params: map[string]interface{}{"ticket_number": ticketNumber},
+			}
+		}
+	}
+
 	return nil
 }
 
 // GetIntentSuggestions returns suggested intents based on the current context
-func (s *NLPService) GetIntentSuggestions(ctx context.Context, context map[string]interface{}) ([]string, error) {
-	// TODO: Implement intent suggestions logic
-	// This should return relevant intents based on the conversation context
-	return []string{}, nil
+func (s *KeywordNLPService) GetIntentSuggestions(ctx context.Context, context map[string]interface{}) ([]string, error) {
+	bookingStatus, _ := context[ContextBookingStatus].(string)
+	lastIntent, _ := context[ContextLastIntent].(string)
+	history, _ := context[ContextIntentHistory].([]string)
+
+	var candidates []string
+	switch bookingStatus {
+	case "":
+		candidates = []string{IntentTicketLookup, IntentRefundInquiry, IntentBaggagePolicy}
+	case models.BookingStatusCancelled:
+		candidates = []string{IntentRefundInquiry, IntentTicketLookup}
+	default:
+		candidates = []string{IntentTicketCancellation, IntentBaggagePolicy, IntentTicketLookup}
+	}
+
+	// Offer a human once the bot has failed to understand the customer twice
+	if countTrailing(history, IntentGeneralInquiry) >= 2 {
+		candidates = append([]string{IntentAgentRequest}, candidates...)
+	}
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, candidate := range candidates {
+		if candidate == lastIntent || len(suggestions) == maxSuggestions {
+			continue
+		}
+		suggestions = append(suggestions, candidate)
+	}
+
+	return suggestions, nil
+}
+
+// countTrailing counts how many entries at the end of history equal value
+func countTrailing(history []string, value string) int {
+	count := 0
+	for i := len(history) - 1; i >= 0 && history[i] == value; i-- {
+		count++
+	}
+	return count
 }
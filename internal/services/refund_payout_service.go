@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/models"
+	"callcenter/internal/payment"
+)
+
+// systemActor makes the refund changes driven by the payment gateway
+var systemActor = Actor{Role: models.RoleSystem}
+
+// RefundPayoutService pays approved refunds through the payment gateway and
+// moves them to processed or failed as the gateway reports back, by callback or
+// by polling
+type RefundPayoutService struct {
+	db          *gorm.DB
+	gateway     payment.Gateway
+	bookings    *BookingService
+	callbackURL string
+}
+
+// NewRefundPayoutService creates a new instance of RefundPayoutService.
+// callbackURL is where the gateway posts payout updates; polling alone is used
+// when it is empty.
+func NewRefundPayoutService(db *gorm.DB, gateway payment.Gateway, callbackURL string) *RefundPayoutService {
+	return &RefundPayoutService{
+		db:          db,
+		gateway:     gateway,
+		bookings:    NewBookingService(db),
+		callbackURL: callbackURL,
+	}
+}
+
+// Pay moves an approved refund request to processing and submits its payout
+func (s *RefundPayoutService) Pay(ctx context.Context, refundRequestID uint) (*models.RefundRequest, error) {
+	refundRequest, err := s.bookings.TransitionRefund(ctx, refundRequestID, models.RefundStatusProcessing, systemActor, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.submit(ctx, refundRequest)
+}
+
+// submit sends the current payout attempt of a processing refund request to the
+// gateway. The idempotency key is stored before the call, so resubmitting after
+// a lost response returns the same payout instead of paying twice.
+func (s *RefundPayoutService) submit(ctx context.Context, refundRequest *models.RefundRequest) (*models.RefundRequest, error) {
+	db := s.db.WithContext(ctx)
+
+	if refundRequest.PayoutKey == "" {
+		key := fmt.Sprintf("refund-%d-%s", refundRequest.ID, uuid.NewString())
+		result := db.Model(&models.RefundRequest{}).
+			Where("id = ? AND status = ? AND payout_key = ''", refundRequest.ID, models.RefundStatusProcessing).
+			Update("payout_key", key)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to save payout key: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: refund request %d is no longer awaiting a payout", ErrConflict, refundRequest.ID)
+		}
+		refundRequest.PayoutKey = key
+	}
+
+	var booking models.Booking
+	if err := db.Select("id", "user_id", "pnr").First(&booking, "id = ?", refundRequest.BookingID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	payout, err := s.gateway.CreatePayout(ctx, payment.PayoutRequest{
+		IdempotencyKey: refundRequest.PayoutKey,
+		Reference:      fmt.Sprint(refundRequest.ID),
+		CustomerID:     booking.UserID.String(),
+		Amount:         refundRequest.Amount,
+		Currency:       refundRequest.Currency,
+		Description:    "Refund for booking " + booking.PNR,
+		CallbackURL:    s.callbackURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payout: %w", err)
+	}
+
+	if err := db.Model(refundRequest).Update("payout_id", payout.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to save payout: %w", err)
+	}
+	refundRequest.PayoutID = payout.ID
+	return s.apply(ctx, refundRequest, payout)
+}
+
+// apply moves a processing refund request to processed or failed once its
+// payout is final. Updates for an earlier attempt or already applied are ignored.
+func (s *RefundPayoutService) apply(ctx context.Context, refundRequest *models.RefundRequest, payout *payment.Payout) (*models.RefundRequest, error) {
+	if !payout.Final() || refundRequest.Status != models.RefundStatusProcessing || refundRequest.PayoutID != payout.ID {
+		return refundRequest, nil
+	}
+
+	status, reason := models.RefundStatusProcessed, ""
+	if payout.Status == payment.StatusFailed {
+		status, reason = models.RefundStatusFailed, payout.FailureReason
+		if reason == "" {
+			reason = "payout failed"
+		}
+	}
+
+	updated, err := s.bookings.TransitionRefund(ctx, refundRequest.ID, status, systemActor, reason)
+	if errors.Is(err, ErrConflict) {
+		// A concurrent callback or poll got there first
+		return refundRequest, nil
+	}
+	return updated, err
+}
+
+// HandleCallback applies a payout update pushed by the gateway. The callback
+// only says which payout changed; its status is read back from the gateway.
+func (s *RefundPayoutService) HandleCallback(ctx context.Context, payoutID string) (*models.RefundRequest, error) {
+	var refundRequest models.RefundRequest
+	if err := s.db.WithContext(ctx).Where("payout_id = ?", payoutID).First(&refundRequest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refund request %w for payout %s", ErrNotFound, payoutID)
+		}
+		return nil, fmt.Errorf("failed to get refund request: %w", err)
+	}
+
+	payout, err := s.gateway.GetPayout(ctx, payoutID)
+	if err != nil {
+		if errors.Is(err, payment.ErrNotFound) {
+			return nil, fmt.Errorf("payout %w: %s", ErrNotFound, payoutID)
+		}
+		return nil, fmt.Errorf("failed to get payout: %w", err)
+	}
+	return s.apply(ctx, &refundRequest, payout)
+}
+
+// Poll advances every refund awaiting the gateway: approved refunds are paid,
+// processing refunds whose payout was never confirmed are resubmitted, and the
+// others have their payout status checked. Failures are logged and retried on
+// the next poll.
+func (s *RefundPayoutService) Poll(ctx context.Context) error {
+	var refundRequests []models.RefundRequest
+	if err := s.db.WithContext(ctx).
+		Where("status IN ?", []string{models.RefundStatusApproved, models.RefundStatusProcessing}).
+		Order("id ASC").
+		Find(&refundRequests).Error; err != nil {
+		return fmt.Errorf("failed to get refunds awaiting payout: %w", err)
+	}
+
+	for i := range refundRequests {
+		refundRequest := &refundRequests[i]
+		var err error
+		switch {
+		case refundRequest.Status == models.RefundStatusApproved:
+			_, err = s.Pay(ctx, refundRequest.ID)
+		case refundRequest.PayoutID == "":
+			_, err = s.submit(ctx, refundRequest)
+		default:
+			var payout *payment.Payout
+			payout, err = s.gateway.GetPayout(ctx, refundRequest.PayoutID)
+			if err == nil {
+				_, err = s.apply(ctx, refundRequest, payout)
+			}
+		}
+		if err != nil {
+			log.Printf("refund %d payout: %v", refundRequest.ID, err)
+		}
+	}
+	return nil
+}
+
+// Run polls every interval until the context is cancelled
+func (s *RefundPayoutService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Poll(ctx); err != nil {
+			log.Printf("refund payouts: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/models"
+	"callcenter/internal/payment"
+	"callcenter/internal/payment/paymenttest"
+)
+
+// lossyGateway creates payouts but loses the response of the first lose calls,
+// as a timeout after the provider accepted the request would
+type lossyGateway struct {
+	*paymenttest.Gateway
+	lose int
+}
+
+func (g *lossyGateway) CreatePayout(ctx context.Context, req payment.PayoutRequest) (*payment.Payout, error) {
+	payout, err := g.Gateway.CreatePayout(ctx, req)
+	if err == nil && g.lose > 0 {
+		g.lose--
+		return nil, errors.New("read tcp: connection reset by peer")
+	}
+	return payout, err
+}
+
+// createApprovedRefund cancels a new booking and approves its refund request
+func createApprovedRefund(t *testing.T, db *gorm.DB) *models.RefundRequest {
+	t.Helper()
+	ctx := t.Context()
+	bookings := NewBookingService(db)
+
+	customer := createTestUser(t, db, models.RoleUser)
+	booking := createTestBooking(t, db, customer, 1)
+	actor := Actor{UserID: customer.ID, Role: customer.Role}
+	quote, err := bookings.QuoteRefund(ctx, booking, RefundSelection{}, actor)
+	if err != nil {
+		t.Fatalf("QuoteRefund: %v", err)
+	}
+	refundRequest, err := bookings.CancelBooking(ctx, Cancellation{BookingID: booking.ID, QuoteID: quote.ID, Actor: actor})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	supervisor := Actor{UserID: uuid.New(), Role: models.RoleSupervisor}
+	refundRequest, err = bookings.TransitionRefund(ctx, refundRequest.ID, models.RefundStatusApproved, supervisor, "")
+	if err != nil {
+		t.Fatalf("approve refund: %v", err)
+	}
+	return refundRequest
+}
+
+// refundState reloads a refund request with its history and the refund status of its booking
+func refundState(t *testing.T, db *gorm.DB, id uint) (*models.RefundRequest, string) {
+	t.Helper()
+	var refundRequest models.RefundRequest
+	if err := db.Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&refundRequest, id).Error; err != nil {
+		t.Fatalf("get refund request: %v", err)
+	}
+	var booking models.Booking
+	if err := db.First(&booking, "id = ?", refundRequest.BookingID).Error; err != nil {
+		t.Fatalf("get booking: %v", err)
+	}
+	return &refundRequest, booking.RefundStatus
+}
+
+// statusMoves lists the statuses a refund request moved through, e.g. "approved>processing"
+func statusMoves(refundRequest *models.RefundRequest) []string {
+	var moves []string
+	for _, change := range refundRequest.History {
+		moves = append(moves, change.FromStatus+">"+change.ToStatus)
+	}
+	return moves
+}
+
+// payoutRequestsFor returns the payout requests the gateway received for a
+// refund; polls also pay the refunds of other tests in the shared database
+func payoutRequestsFor(gateway *paymenttest.Gateway, refundRequestID uint) []payment.PayoutRequest {
+	var requests []payment.PayoutRequest
+	for _, req := range gateway.Requests() {
+		if req.Reference == fmt.Sprint(refundRequestID) {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+func TestPayoutRetryReusesIdempotencyKey(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	fake := paymenttest.NewGateway()
+	payouts := NewRefundPayoutService(db, &lossyGateway{Gateway: fake, lose: 1}, "")
+	refundRequest := createApprovedRefund(t, db)
+
+	if _, err := payouts.Pay(ctx, refundRequest.ID); err == nil {
+		t.Fatal("Pay succeeded although the gateway response was lost")
+	}
+	stored, _ := refundState(t, db, refundRequest.ID)
+	if stored.Status != models.RefundStatusProcessing || stored.PayoutKey == "" || stored.PayoutID != "" {
+		t.Fatalf("after the lost response: status %s, key %q, payout %q; want processing with a key and no payout",
+			stored.Status, stored.PayoutKey, stored.PayoutID)
+	}
+
+	// The next poll resubmits the same attempt
+	if err := payouts.Poll(ctx); err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	requests := payoutRequestsFor(fake, refundRequest.ID)
+	if len(requests) != 2 {
+		t.Fatalf("gateway got %d payout requests, want 2", len(requests))
+	}
+	if requests[0].IdempotencyKey != stored.PayoutKey || requests[1].IdempotencyKey != stored.PayoutKey {
+		t.Errorf("idempotency keys %q and %q, want both %q", requests[0].IdempotencyKey, requests[1].IdempotencyKey, stored.PayoutKey)
+	}
+	if requests[0].Amount != refundRequest.Amount || requests[0].Currency != refundRequest.Currency {
+		t.Errorf("payout of %d %s, want %d %s", requests[0].Amount, requests[0].Currency, refundRequest.Amount, refundRequest.Currency)
+	}
+
+	resubmitted, _ := refundState(t, db, refundRequest.ID)
+	if resubmitted.PayoutID == "" || resubmitted.Status != models.RefundStatusProcessing {
+		t.Fatalf("after the retry: status %s, payout %q; want processing with the payout", resubmitted.Status, resubmitted.PayoutID)
+	}
+	payout, err := fake.GetPayout(ctx, resubmitted.PayoutID)
+	if err != nil {
+		t.Fatalf("GetPayout: %v", err)
+	}
+	if payout.Reference != fmt.Sprint(refundRequest.ID) {
+		t.Errorf("payout reference = %s, want %d", payout.Reference, refundRequest.ID)
+	}
+}
+
+func TestPayoutPolling(t *testing.T) {
+	tests := []struct {
+		name       string
+		settle     func(gateway *paymenttest.Gateway, payoutID string) error
+		wantStatus string
+		wantReason string
+	}{
+		{
+			name:       "payout succeeds",
+			settle:     func(gateway *paymenttest.Gateway, payoutID string) error { return gateway.Settle(payoutID) },
+			wantStatus: models.RefundStatusProcessed,
+		},
+		{
+			name: "payout fails",
+			settle: func(gateway *paymenttest.Gateway, payoutID string) error {
+				return gateway.Fail(payoutID, "card expired")
+			},
+			wantStatus: models.RefundStatusFailed,
+			wantReason: "card expired",
+		},
+		{
+			name:       "payout fails without a reason",
+			settle:     func(gateway *paymenttest.Gateway, payoutID string) error { return gateway.Fail(payoutID, "") },
+			wantStatus: models.RefundStatusFailed,
+			wantReason: "payout failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			ctx := t.Context()
+			gateway := paymenttest.NewGateway()
+			payouts := NewRefundPayoutService(db, gateway, "")
+			refundRequest := createApprovedRefund(t, db)
+
+			// The first poll pays the approved refund; the payout is still pending
+			if err := payouts.Poll(ctx); err != nil {
+				t.Fatalf("Poll: %v", err)
+			}
+			pending, bookingStatus := refundState(t, db, refundRequest.ID)
+			if pending.Status != models.RefundStatusProcessing || pending.PayoutID == "" || bookingStatus != models.RefundStatusProcessing {
+				t.Fatalf("after paying: status %s (booking %s), payout %q; want processing", pending.Status, bookingStatus, pending.PayoutID)
+			}
+
+			if err := tt.settle(gateway, pending.PayoutID); err != nil {
+				t.Fatalf("settle payout: %v", err)
+			}
+			if err := payouts.Poll(ctx); err != nil {
+				t.Fatalf("Poll: %v", err)
+			}
+			// Polling a final refund again changes nothing
+			if err := payouts.Poll(ctx); err != nil {
+				t.Fatalf("Poll: %v", err)
+			}
+
+			stored, bookingStatus := refundState(t, db, refundRequest.ID)
+			if stored.Status != tt.wantStatus || bookingStatus != tt.wantStatus {
+				t.Errorf("status = %s (booking %s), want %s", stored.Status, bookingStatus, tt.wantStatus)
+			}
+			want := []string{">pending", "pending>approved", "approved>processing", "processing>" + tt.wantStatus}
+			if fmt.Sprint(statusMoves(stored)) != fmt.Sprint(want) {
+				t.Errorf("history = %v, want %v", statusMoves(stored), want)
+			}
+			last := stored.History[len(stored.History)-1]
+			if last.ActorRole != models.RoleSystem || last.Reason != tt.wantReason {
+				t.Errorf("last change by %s with reason %q, want system with %q", last.ActorRole, last.Reason, tt.wantReason)
+			}
+			if len(payoutRequestsFor(gateway, refundRequest.ID)) != 1 {
+				t.Errorf("gateway got %d payout requests, want 1", len(payoutRequestsFor(gateway, refundRequest.ID)))
+			}
+		})
+	}
+}
+
+func TestPayoutSettledImmediately(t *testing.T) {
+	db := testDB(t)
+	gateway := paymenttest.NewGateway()
+	gateway.AutoSettle = true
+	payouts := NewRefundPayoutService(db, gateway, "")
+	refundRequest := createApprovedRefund(t, db)
+
+	paid, err := payouts.Pay(t.Context(), refundRequest.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	if paid.Status != models.RefundStatusProcessed || paid.ProcessedAt == nil {
+		t.Errorf("status = %s, want processed", paid.Status)
+	}
+}
+
+func TestPayoutCallbacks(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	gateway := paymenttest.NewGateway()
+	payouts := NewRefundPayoutService(db, gateway, "https://example.com/api/v1/payments/callback")
+	refundRequest := createApprovedRefund(t, db)
+
+	paid, err := payouts.Pay(ctx, refundRequest.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	if requests := payoutRequestsFor(gateway, refundRequest.ID); len(requests) != 1 || requests[0].CallbackURL == "" {
+		t.Fatalf("payout requests = %+v, want one with the callback URL", requests)
+	}
+
+	// A callback that arrives before the payout is final changes nothing
+	early, err := payouts.HandleCallback(ctx, paid.PayoutID)
+	if err != nil {
+		t.Fatalf("early callback: %v", err)
+	}
+	if early.Status != models.RefundStatusProcessing {
+		t.Errorf("after an early callback: status %s, want processing", early.Status)
+	}
+
+	if err := gateway.Settle(paid.PayoutID); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		updated, err := payouts.HandleCallback(ctx, paid.PayoutID)
+		if err != nil {
+			t.Fatalf("callback %d: %v", i+1, err)
+		}
+		if updated.Status != models.RefundStatusProcessed {
+			t.Errorf("after callback %d: status %s, want processed", i+1, updated.Status)
+		}
+	}
+
+	stored, bookingStatus := refundState(t, db, refundRequest.ID)
+	want := []string{">pending", "pending>approved", "approved>processing", "processing>processed"}
+	if fmt.Sprint(statusMoves(stored)) != fmt.Sprint(want) || bookingStatus != models.RefundStatusProcessed {
+		t.Errorf("history = %v (booking %s), want %v once", statusMoves(stored), bookingStatus, want)
+	}
+
+	if _, err := payouts.HandleCallback(ctx, "po_unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("callback for an unknown payout: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPayoutCallbackOfEarlierAttempt(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	gateway := paymenttest.NewGateway()
+	payouts := NewRefundPayoutService(db, gateway, "https://example.com/api/v1/payments/callback")
+	bookings := NewBookingService(db)
+	refundRequest := createApprovedRefund(t, db)
+
+	first, err := payouts.Pay(ctx, refundRequest.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	gateway.Fail(first.PayoutID, "insufficient balance")
+	if failed, err := payouts.HandleCallback(ctx, first.PayoutID); err != nil || failed.Status != models.RefundStatusFailed {
+		t.Fatalf("failure callback: %v, want failed", err)
+	}
+
+	// A supervisor retries; the new attempt gets its own key and payout
+	supervisor := Actor{UserID: uuid.New(), Role: models.RoleSupervisor}
+	if _, err := bookings.TransitionRefund(ctx, refundRequest.ID, models.RefundStatusProcessing, supervisor, ""); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if err := payouts.Poll(ctx); err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	second, _ := refundState(t, db, refundRequest.ID)
+	requests := payoutRequestsFor(gateway, refundRequest.ID)
+	if len(requests) != 2 || requests[0].IdempotencyKey == requests[1].IdempotencyKey {
+		t.Fatalf("payout requests = %+v, want two attempts with their own keys", requests)
+	}
+	if second.PayoutID == "" || second.PayoutID == first.PayoutID {
+		t.Fatalf("second attempt payout = %q, want a new payout", second.PayoutID)
+	}
+
+	// The failure of the first attempt arrives again, out of order: it is ignored
+	if _, err := payouts.HandleCallback(ctx, first.PayoutID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("callback of the first attempt: err = %v, want ErrNotFound", err)
+	}
+	gateway.Settle(second.PayoutID)
+	settled, err := payouts.HandleCallback(ctx, second.PayoutID)
+	if err != nil {
+		t.Fatalf("callback of the second attempt: %v", err)
+	}
+	if settled.Status != models.RefundStatusProcessed {
+		t.Errorf("status = %s, want processed", settled.Status)
+	}
+
+	stored, _ := refundState(t, db, refundRequest.ID)
+	want := []string{">pending", "pending>approved", "approved>processing", "processing>failed", "failed>processing", "processing>processed"}
+	if fmt.Sprint(statusMoves(stored)) != fmt.Sprint(want) {
+		t.Errorf("history = %v, want %v", statusMoves(stored), want)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/models"
+	"callcenter/internal/refund"
+)
+
+// RefundPolicyService manages refund policies and quotes refunds of bookings under them
+type RefundPolicyService struct {
+	db *gorm.DB
+}
+
+// NewRefundPolicyService creates a new instance of RefundPolicyService
+func NewRefundPolicyService(db *gorm.DB) *RefundPolicyService {
+	return &RefundPolicyService{
+		db: db,
+	}
+}
+
+// CreatePolicy validates and stores a new refund policy with its tiers
+func (s *RefundPolicyService) CreatePolicy(ctx context.Context, policy *models.RefundPolicy) error {
+	policy.ID = uuid.New()
+	if err := s.preparePolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Create(policy).Error; err != nil {
+		return fmt.Errorf("failed to create refund policy: %w", err)
+	}
+	return nil
+}
+
+// UpdatePolicy replaces a refund policy and its tiers
+func (s *RefundPolicyService) UpdatePolicy(ctx context.Context, id uuid.UUID, policy *models.RefundPolicy) error {
+	existing, err := s.GetPolicy(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	policy.ID = id
+	policy.CreatedAt = existing.CreatedAt
+	if err := s.preparePolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", id).Delete(&models.RefundPenaltyTier{}).Error; err != nil {
+			return fmt.Errorf("failed to replace refund policy tiers: %w", err)
+		}
+		if err := tx.Save(policy).Error; err != nil {
+			return fmt.Errorf("failed to update refund policy: %w", err)
+		}
+		return nil
+	})
+}
+
+// preparePolicy validates a policy, normalizes its codes and assigns IDs to its tiers
+func (s *RefundPolicyService) preparePolicy(ctx context.Context, policy *models.RefundPolicy) error {
+	if err := ValidateRefundPolicy(policy); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.RefundPolicy{}).
+		Where("airline = ? AND fare_class = ? AND ticket_type = ? AND id <> ?", policy.Airline, policy.FareClass, policy.TicketType, policy.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check refund policy scope: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: another refund policy already covers this airline, fare class and ticket type", ErrConflict)
+	}
+
+	for i := range policy.Tiers {
+		policy.Tiers[i].ID = uuid.New()
+		policy.Tiers[i].PolicyID = policy.ID
+	}
+	return nil
+}
+
+// ValidateRefundPolicy checks that a policy can be applied and normalizes the case of its codes
+func ValidateRefundPolicy(policy *models.RefundPolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	policy.Airline = strings.ToUpper(strings.TrimSpace(policy.Airline))
+	policy.FareClass = strings.ToUpper(strings.TrimSpace(policy.FareClass))
+
+	switch {
+	case policy.Name == "":
+		return fmt.Errorf("%w: policy name is required", ErrInvalidInput)
+	case policy.Airline != "" && !airlineCodePattern.MatchString(policy.Airline):
+		return fmt.Errorf("%w: invalid airline code %s", ErrInvalidInput, policy.Airline)
+	case policy.TicketType != "" && policy.TicketType != models.TicketTypeCharter && policy.TicketType != models.TicketTypeSystematic:
+		return fmt.Errorf("%w: invalid ticket type %s", ErrInvalidInput, policy.TicketType)
+	case policy.FixedFee < 0:
+		return fmt.Errorf("%w: fixed fee cannot be negative", ErrInvalidInput)
+	case !validPercent(policy.NoShowPenaltyPercent):
+		return fmt.Errorf("%w: no-show penalty must be between 0 and 100 percent", ErrInvalidInput)
+	case !policy.NonRefundable && len(policy.Tiers) == 0:
+		return fmt.Errorf("%w: a refundable policy needs at least one penalty tier", ErrInvalidInput)
+	}
+
+	seen := make(map[int]bool)
+	for _, tier := range policy.Tiers {
+		switch {
+		case tier.MinHoursBeforeDeparture < 0:
+			return fmt.Errorf("%w: tier hours before departure cannot be negative", ErrInvalidInput)
+		case seen[tier.MinHoursBeforeDeparture]:
+			return fmt.Errorf("%w: duplicate tier for %d hours before departure", ErrInvalidInput, tier.MinHoursBeforeDeparture)
+		case !validPercent(tier.PenaltyPercent):
+			return fmt.Errorf("%w: tier penalty must be between 0 and 100 percent", ErrInvalidInput)
+		}
+		seen[tier.MinHoursBeforeDeparture] = true
+	}
+	return nil
+}
+
+func validPercent(value float64) bool {
+	return value >= 0 && value <= 100
+}
+
+// GetPolicy retrieves a refund policy with its tiers
+func (s *RefundPolicyService) GetPolicy(ctx context.Context, id uuid.UUID) (*models.RefundPolicy, error) {
+	var policy models.RefundPolicy
+	if err := s.db.WithContext(ctx).Preload("Tiers", orderTiers).First(&policy, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refund policy %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get refund policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// ListPolicies lists refund policies, optionally only those of one airline
+func (s *RefundPolicyService) ListPolicies(ctx context.Context, airline string) ([]models.RefundPolicy, error) {
+	query := s.db.WithContext(ctx).Preload("Tiers", orderTiers)
+	if airline != "" {
+		query = query.Where("airline = ?", strings.ToUpper(airline))
+	}
+
+	var policies []models.RefundPolicy
+	if err := query.Order("airline, fare_class, ticket_type").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list refund policies: %w", err)
+	}
+	return policies, nil
+}
+
+// DeletePolicy removes a refund policy with its tiers; bookings it covered fall
+// back to less specific policies. The policy is soft-deleted, which the ON DELETE
+// CASCADE of its tiers does not see, so they are deleted here.
+func (s *RefundPolicyService) DeletePolicy(ctx context.Context, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.RefundPolicy{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete refund policy: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("refund policy %w: %s", ErrNotFound, id)
+		}
+		if err := tx.Where("policy_id = ?", id).Delete(&models.RefundPenaltyTier{}).Error; err != nil {
+			return fmt.Errorf("failed to delete refund policy tiers: %w", err)
+		}
+		return nil
+	})
+}
+
+func orderTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("min_hours_before_departure DESC")
+}
+
+// PolicyFor returns the refund policy that applies to a booking
+func (s *RefundPolicyService) PolicyFor(ctx context.Context, booking *models.Booking) (*models.RefundPolicy, error) {
+	var candidates []models.RefundPolicy
+	if err := s.db.WithContext(ctx).Preload("Tiers").
+		Where("airline IN ?", []string{booking.Airline, ""}).
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get refund policies: %w", err)
+	}
+
+	policy := refund.Select(candidates, booking)
+	if policy == nil {
+		return nil, fmt.Errorf("%w: no refund policy applies to booking %s", ErrConflict, booking.PNR)
+	}
+	return policy, nil
+}
+
+// Quote computes the refund of some coupons of a booking cancelled at the given
+// time under the policy that applies to it; nil coupons quote the whole booking.
+// The booking must include its passengers and segments.
+func (s *RefundPolicyService) Quote(ctx context.Context, booking *models.Booking, coupons []refund.Coupon, at time.Time) (*refund.Quote, error) {
+	policy, err := s.PolicyFor(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+	return QuoteWithPolicy(policy, booking, coupons, at)
+}
+
+// QuoteWithPolicy computes the refund of some coupons of a booking under the
+// given policy; nil coupons quote the whole booking
+func QuoteWithPolicy(policy *models.RefundPolicy, booking *models.Booking, coupons []refund.Coupon, at time.Time) (*refund.Quote, error) {
+	var quote *refund.Quote
+	var err error
+	if coupons == nil {
+		quote, err = refund.Compute(policy, booking, at)
+	} else {
+		quote, err = refund.ComputeCoupons(policy, booking, coupons, at)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, refund.ErrNoItinerary), errors.Is(err, refund.ErrNoCoupons):
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		case errors.Is(err, refund.ErrUnknownCoupon), errors.Is(err, refund.ErrDuplicateCoupon):
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		return nil, fmt.Errorf("failed to compute refund: %w", err)
+	}
+	return quote, nil
+}
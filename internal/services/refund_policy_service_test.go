@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+
+	"callcenter/internal/models"
+)
+
+func TestDeletePolicyDeletesTiers(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	policies := NewRefundPolicyService(db)
+
+	// An airline no test booking flies, so the policy never applies to them
+	policy := &models.RefundPolicy{
+		Name:      "ZZ test",
+		Airline:   "ZZ",
+		FareClass: randomPNR()[:2],
+		Tiers: []models.RefundPenaltyTier{
+			{MinHoursBeforeDeparture: 72, PenaltyPercent: 10},
+			{MinHoursBeforeDeparture: 0, PenaltyPercent: 50},
+		},
+	}
+	if err := policies.CreatePolicy(ctx, policy); err != nil {
+		t.Fatalf("CreatePolicy: %v", err)
+	}
+
+	if err := policies.DeletePolicy(ctx, policy.ID); err != nil {
+		t.Fatalf("DeletePolicy: %v", err)
+	}
+
+	var tiers int64
+	if err := db.Model(&models.RefundPenaltyTier{}).Where("policy_id = ?", policy.ID).Count(&tiers).Error; err != nil {
+		t.Fatalf("count tiers: %v", err)
+	}
+	if tiers != 0 {
+		t.Errorf("%d tiers of the deleted policy are left, want 0", tiers)
+	}
+	if err := policies.DeletePolicy(ctx, policy.ID); err == nil {
+		t.Error("deleting the policy again succeeded, want not found")
+	}
+}
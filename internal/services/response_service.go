@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/i18n"
+	"callcenter/internal/llm"
+	"callcenter/internal/models"
+)
+
+// maxToolRounds bounds how many tool-call round trips a single reply may take
+const maxToolRounds = 4
+
+const systemPrompt = `You are the support assistant of a flight ticketing call center.
+Help customers look up tickets, cancel tickets and check refund status using the provided tools.
+Never invent ticket details; only report what the tools return.
+Before cancelling, call quote_refund and tell the customer which ticket will be cancelled, quoting
+its ticket number, and the refund breakdown: base fare, penalty, fees, taxes returned and the total.
+Ask them to reply "yes" to confirm. Only call cancel_ticket after they explicitly confirm, passing
+the quote_token of the quote they saw. If the quote has expired, quote again and ask again.
+If they only want to cancel some passengers or flights of the booking, pass those to quote_refund
+and say which passengers and flights the quote covers.
+Reply in the language the customer writes in and keep answers short.`
+
+// languagePrompt tells the model which language the session has settled on
+const languagePrompt = "Unless the customer switches language, reply in %s."
+
+// Actor identifies the authenticated user the bot is acting for
+type Actor struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// String identifies the actor in audit fields: the user ID, or system for automated actors
+func (a Actor) String() string {
+	if a.UserID == uuid.Nil {
+		return models.RoleSystem
+	}
+	return a.UserID.String()
+}
+
+// canAccess reports whether the actor may see the ticket. Staff see all tickets,
+// customers only their own.
+func (a Actor) canAccess(ticket *models.Ticket) bool {
+	return models.IsStaff(a.Role) || ticket.UserID == a.UserID
+}
+
+// ticketTools are the ticket operations behind the tools of the bot
+type ticketTools interface {
+	GetTicket(ctx context.Context, ticketNumber string) (*models.Ticket, error)
+	GetTicketByPhone(ctx context.Context, phoneNumber string, actor Actor) ([]models.Ticket, error)
+	QuoteCancellation(ctx context.Context, ticketNumber string, selection RefundSelection, actor Actor) (*models.RefundQuote, error)
+	GetRefundQuote(ctx context.Context, ticketNumber string, quoteID uuid.UUID) (*models.RefundQuote, error)
+	CancelTicket(ctx context.Context, ticketNumber string, quoteID uuid.UUID, reason string, actor Actor) (*models.RefundRequest, error)
+	ListRefundRequests(ctx context.Context, ticketNumber string) ([]models.RefundRequest, error)
+}
+
+// ResponseService generates bot replies with a language model that can call ticket tools
+type ResponseService struct {
+	client  *llm.Client
+	tickets ticketTools
+}
+
+// NewResponseService creates a new instance of ResponseService
+func NewResponseService(client *llm.Client, ticketService *TicketService) *ResponseService {
+	return &ResponseService{
+		client:  client,
+		tickets: ticketService,
+	}
+}
+
+// GenerateResponse produces the assistant reply for a conversation whose history
+// already contains the latest user message. lang is the session's reply language.
+func (s *ResponseService) GenerateResponse(ctx context.Context, actor Actor, lang string, history []models.ChatMessage) (string, error) {
+	prompt := systemPrompt
+	if i18n.IsSupported(lang) {
+		prompt += "\n" + fmt.Sprintf(languagePrompt, i18n.Name(lang))
+	}
+
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: prompt}}
+	for _, msg := range history {
+		switch msg.Role {
+		case "user":
+			messages = append(messages, llm.Message{Role: llm.RoleUser, Content: msg.Content})
+		case "assistant", "agent":
+			messages = append(messages, llm.Message{Role: llm.RoleAssistant, Content: msg.Content})
+		}
+	}
+
+	tools := toolsForRole(actor.Role)
+	for round := 0; round <= maxToolRounds; round++ {
+		reply, err := s.client.CreateChatCompletion(ctx, messages, tools)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate response: %w", err)
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+
+		messages = append(messages, *reply)
+		for _, call := range reply.ToolCalls {
+			messages = append(messages, llm.Message{
+				Role:       llm.RoleTool,
+				ToolCallID: call.ID,
+				Content:    s.executeTool(ctx, actor, call, history),
+			})
+		}
+	}
+
+	return "", fmt.Errorf("failed to generate response: exceeded %d tool rounds", maxToolRounds)
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"callcenter/internal/llm"
+	"callcenter/internal/llm/llmtest"
+	"callcenter/internal/models"
+)
+
+// fakeTickets serves the bot tools from memory and records what was called
+type fakeTickets struct {
+	tickets   map[string]*models.Ticket
+	quotes    map[uuid.UUID]*models.RefundQuote
+	nextQuote uuid.UUID // ID of the next quote, random if unset
+	calls     []string
+	cancelled []uuid.UUID // quote IDs CancelTicket was called with
+}
+
+func (f *fakeTickets) GetTicket(ctx context.Context, ticketNumber string) (*models.Ticket, error) {
+	f.calls = append(f.calls, "GetTicket "+ticketNumber)
+	ticket, ok := f.tickets[ticketNumber]
+	if !ok {
+		return nil, fmt.Errorf("ticket %w: %s", ErrNotFound, ticketNumber)
+	}
+	return ticket, nil
+}
+
+func (f *fakeTickets) GetTicketByPhone(ctx context.Context, phoneNumber string, actor Actor) ([]models.Ticket, error) {
+	f.calls = append(f.calls, "GetTicketByPhone "+phoneNumber)
+	var tickets []models.Ticket
+	for _, ticket := range f.tickets {
+		if ticket.Booking != nil && ticket.Booking.ContactPhone == phoneNumber {
+			tickets = append(tickets, *ticket)
+		}
+	}
+	return tickets, nil
+}
+
+func (f *fakeTickets) QuoteCancellation(ctx context.Context, ticketNumber string, selection RefundSelection, actor Actor) (*models.RefundQuote, error) {
+	f.calls = append(f.calls, "QuoteCancellation "+ticketNumber)
+	quote := &models.RefundQuote{ID: f.nextQuote, BaseFare: 10000000, Amount: 8000000, Currency: "IRR", CreatedAt: time.Now()}
+	if quote.ID == uuid.Nil {
+		quote.ID = uuid.New()
+	}
+	f.quotes[quote.ID] = quote
+	return quote, nil
+}
+
+func (f *fakeTickets) GetRefundQuote(ctx context.Context, ticketNumber string, quoteID uuid.UUID) (*models.RefundQuote, error) {
+	quote, ok := f.quotes[quoteID]
+	if !ok {
+		return nil, fmt.Errorf("refund quote %w: %s", ErrNotFound, quoteID)
+	}
+	return quote, nil
+}
+
+func (f *fakeTickets) CancelTicket(ctx context.Context, ticketNumber string, quoteID uuid.UUID, reason string, actor Actor) (*models.RefundRequest, error) {
+	f.calls = append(f.calls, "CancelTicket "+ticketNumber)
+	f.cancelled = append(f.cancelled, quoteID)
+	return &models.RefundRequest{Status: models.RefundStatusPending, Amount: 8000000, Currency: "IRR"}, nil
+}
+
+func (f *fakeTickets) ListRefundRequests(ctx context.Context, ticketNumber string) ([]models.RefundRequest, error) {
+	f.calls = append(f.calls, "ListRefundRequests "+ticketNumber)
+	return []models.RefundRequest{{Status: models.RefundStatusPending, Amount: 8000000, Currency: "IRR"}}, nil
+}
+
+const (
+	ownTicket   = "TKT-2026-0001234"
+	otherTicket = "TKT-2026-0005678"
+)
+
+var (
+	customer      = Actor{UserID: uuid.New(), Role: models.RoleUser}
+	otherCustomer = Actor{UserID: uuid.New(), Role: models.RoleUser}
+	agent         = Actor{UserID: uuid.New(), Role: models.RoleAgent}
+)
+
+// newTestResponseService returns a ResponseService talking to a mock
+// completion server that answers with the given replies
+func newTestResponseService(t *testing.T, replies ...llm.Message) (*ResponseService, *fakeTickets, *llmtest.Server) {
+	t.Helper()
+	server := llmtest.NewServer(replies...)
+	t.Cleanup(server.Close)
+
+	tickets := &fakeTickets{quotes: make(map[uuid.UUID]*models.RefundQuote), tickets: map[string]*models.Ticket{
+		ownTicket: {
+			ID: uuid.New(), UserID: customer.UserID, Number: ownTicket, Status: models.TicketStatusOpen,
+			Booking: &models.Booking{PNR: "ABC123", ContactPhone: "09121234567", Currency: "IRR"},
+		},
+		otherTicket: {
+			ID: uuid.New(), UserID: otherCustomer.UserID, Number: otherTicket, Status: models.TicketStatusOpen,
+			Booking: &models.Booking{PNR: "XYZ789", ContactPhone: "09127654321", Currency: "IRR"},
+		},
+	}}
+	service := &ResponseService{
+		client:  llm.NewClient("test-key", server.URL, "test-model"),
+		tickets: tickets,
+	}
+	return service, tickets, server
+}
+
+// toolResults returns the tool messages sent back to the model, by tool call ID
+func toolResults(server *llmtest.Server) map[string]map[string]interface{} {
+	results := make(map[string]map[string]interface{})
+	for _, req := range server.Requests() {
+		for _, msg := range req.Messages {
+			if msg.Role != llm.RoleTool {
+				continue
+			}
+			var result map[string]interface{}
+			json.Unmarshal([]byte(msg.Content), &result)
+			results[msg.ToolCallID] = result
+		}
+	}
+	return results
+}
+
+func userMessage(content string) models.ChatMessage {
+	return models.ChatMessage{Role: "user", Content: content, CreatedAt: time.Now()}
+}
+
+func assistantMessage(content string) models.ChatMessage {
+	return models.ChatMessage{Role: "assistant", Content: content, CreatedAt: time.Now()}
+}
+
+func TestGenerateResponseDispatchesToolCalls(t *testing.T) {
+	service, tickets, server := newTestResponseService(t,
+		llmtest.ToolCallReply("call_1", ToolGetTicket, `{"ticket_number":"`+ownTicket+`"}`),
+		llmtest.TextReply("Your ticket is open."),
+	)
+
+	reply, err := service.GenerateResponse(context.Background(), customer, "en",
+		[]models.ChatMessage{userMessage("What is the status of " + ownTicket + "?")})
+	if err != nil {
+		t.Fatalf("GenerateResponse: %v", err)
+	}
+	if reply != "Your ticket is open." {
+		t.Errorf("reply = %q, want the final text reply", reply)
+	}
+	if want := []string{"GetTicket " + ownTicket}; strings.Join(tickets.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", tickets.calls, want)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d completion requests, want 2", len(requests))
+	}
+	result := toolResults(server)["call_1"]
+	if result == nil {
+		t.Fatal("the tool result was not sent back to the model")
+	}
+	if result["ticket_number"] != ownTicket || result["status"] != models.TicketStatusOpen {
+		t.Errorf("tool result = %v, want the summary of %s", result, ownTicket)
+	}
+}
+
+func TestGenerateResponseRoleGuardrails(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     Actor
+		tool      string
+		arguments string
+		wantCalls []string
+		wantError string // in the tool result; empty when the call succeeds
+	}{
+		{
+			name:      "customer cannot search by phone",
+			actor:     customer,
+			tool:      ToolGetTicketByPhone,
+			arguments: `{"phone_number":"09127654321"}`,
+			wantError: "not permitted",
+		},
+		{
+			name:      "customer cannot see another customer's ticket",
+			actor:     customer,
+			tool:      ToolGetTicket,
+			arguments: `{"ticket_number":"` + otherTicket + `"}`,
+			wantCalls: []string{"GetTicket " + otherTicket},
+			wantError: "ticket not found",
+		},
+		{
+			name:      "customer cannot quote another customer's ticket",
+			actor:     customer,
+			tool:      ToolQuoteRefund,
+			arguments: `{"ticket_number":"` + otherTicket + `"}`,
+			wantCalls: []string{"GetTicket " + otherTicket},
+			wantError: "ticket not found",
+		},
+		{
+			name:      "customer cannot cancel another customer's ticket",
+			actor:     customer,
+			tool:      ToolCancelTicket,
+			arguments: `{"ticket_number":"` + otherTicket + `","quote_token":"` + uuid.NewString() + `","confirmed":true}`,
+			wantCalls: []string{"GetTicket " + otherTicket},
+			wantError: "ticket not found",
+		},
+		{
+			name:      "unknown role gets no tools",
+			actor:     Actor{UserID: uuid.New(), Role: "guest"},
+			tool:      ToolGetTicket,
+			arguments: `{"ticket_number":"` + ownTicket + `"}`,
+			wantError: "not permitted",
+		},
+		{
+			name:      "agent can search by phone",
+			actor:     agent,
+			tool:      ToolGetTicketByPhone,
+			arguments: `{"phone_number":"09127654321"}`,
+			wantCalls: []string{"GetTicketByPhone 09127654321"},
+		},
+		{
+			name:      "agent can see any ticket",
+			actor:     agent,
+			tool:      ToolGetTicket,
+			arguments: `{"ticket_number":"` + otherTicket + `"}`,
+			wantCalls: []string{"GetTicket " + otherTicket},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, tickets, server := newTestResponseService(t,
+				llmtest.ToolCallReply("call_1", tt.tool, tt.arguments),
+				llmtest.TextReply("done"),
+			)
+
+			history := []models.ChatMessage{userMessage("Please help with " + otherTicket)}
+			if _, err := service.GenerateResponse(context.Background(), tt.actor, "en", history); err != nil {
+				t.Fatalf("GenerateResponse: %v", err)
+			}
+			if strings.Join(tickets.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", tickets.calls, tt.wantCalls)
+			}
+
+			result := toolResults(server)["call_1"]
+			message, _ := result["error"].(string)
+			switch {
+			case tt.wantError == "" && message != "":
+				t.Errorf("tool result error = %q, want success", message)
+			case tt.wantError != "" && !strings.Contains(message, tt.wantError):
+				t.Errorf("tool result = %v, want an error containing %q", result, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestGenerateResponseOffersToolsByRole(t *testing.T) {
+	tests := []struct {
+		actor Actor
+		want  []string
+	}{
+		{customer, []string{ToolGetTicket, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus}},
+		{agent, []string{ToolGetTicket, ToolGetTicketByPhone, ToolQuoteRefund, ToolCancelTicket, ToolGetRefundStatus}},
+		{Actor{Role: "guest"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.actor.Role, func(t *testing.T) {
+			service, _, server := newTestResponseService(t, llmtest.TextReply("hello"))
+			if _, err := service.GenerateResponse(context.Background(), tt.actor, "en", []models.ChatMessage{userMessage("hi")}); err != nil {
+				t.Fatalf("GenerateResponse: %v", err)
+			}
+
+			var offered []string
+			for _, tool := range server.Requests()[0].Tools {
+				offered = append(offered, tool.Function.Name)
+			}
+			if strings.Join(offered, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("tools = %v, want %v", offered, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelTicketRequiresConfirmation(t *testing.T) {
+	quoteToken := uuid.NewString()
+	asked := assistantMessage("Cancelling " + ownTicket + " refunds 800,000 tomans. Reply yes to confirm.")
+
+	tests := []struct {
+		name       string
+		history    []models.ChatMessage
+		confirmed  bool
+		wantCancel bool
+	}{
+		{
+			name:      "model did not claim a confirmation",
+			history:   []models.ChatMessage{userMessage("cancel " + ownTicket), asked, userMessage("yes")},
+			confirmed: false,
+		},
+		{
+			name:      "customer has not answered the question",
+			history:   []models.ChatMessage{userMessage("cancel " + ownTicket), asked},
+			confirmed: true,
+		},
+		{
+			name:      "latest message is not a confirmation",
+			history:   []models.ChatMessage{userMessage("cancel " + ownTicket), asked, userMessage("how much do I get back?")},
+			confirmed: true,
+		},
+		{
+			name: "confirmation was about another ticket",
+			history: []models.ChatMessage{
+				userMessage("cancel " + ownTicket),
+				assistantMessage("Cancelling " + otherTicket + " refunds 800,000 tomans. Reply yes to confirm."),
+				userMessage("yes"),
+			},
+			confirmed: true,
+		},
+		{
+			name:       "customer confirmed",
+			history:    []models.ChatMessage{userMessage("cancel " + ownTicket), asked, userMessage("Yes, go ahead")},
+			confirmed:  true,
+			wantCancel: true,
+		},
+		{
+			name:       "customer confirmed in Persian",
+			history:    []models.ChatMessage{userMessage("لغو " + ownTicket), asked, userMessage("بله")},
+			confirmed:  true,
+			wantCancel: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments := fmt.Sprintf(`{"ticket_number":%q,"quote_token":%q,"confirmed":%t}`, ownTicket, quoteToken, tt.confirmed)
+			service, tickets, server := newTestResponseService(t,
+				llmtest.ToolCallReply("call_1", ToolCancelTicket, arguments),
+				llmtest.TextReply("done"),
+			)
+			// The quote the customer saw was requested before they answered
+			tickets.quotes[uuid.MustParse(quoteToken)] = &models.RefundQuote{
+				ID: uuid.MustParse(quoteToken), Amount: 8000000, Currency: "IRR", CreatedAt: time.Now().Add(-time.Minute),
+			}
+
+			if _, err := service.GenerateResponse(context.Background(), customer, "en", tt.history); err != nil {
+				t.Fatalf("GenerateResponse: %v", err)
+			}
+
+			result := toolResults(server)["call_1"]
+			if !tt.wantCancel {
+				if len(tickets.cancelled) > 0 {
+					t.Fatalf("CancelTicket was called without a confirmation")
+				}
+				if result["status"] != "confirmation_required" {
+					t.Errorf("tool result = %v, want confirmation_required", result)
+				}
+				return
+			}
+			if len(tickets.cancelled) != 1 || tickets.cancelled[0].String() != quoteToken {
+				t.Fatalf("cancelled with quotes %v, want [%s]", tickets.cancelled, quoteToken)
+			}
+			if result["status"] != "cancelled" {
+				t.Errorf("tool result = %v, want cancelled", result)
+			}
+		})
+	}
+}
+
+func TestCancelTicketRequiresConfirmedQuote(t *testing.T) {
+	history := []models.ChatMessage{
+		userMessage("cancel " + ownTicket),
+		assistantMessage("Cancelling " + ownTicket + " refunds 800,000 tomans. Reply yes to confirm."),
+		userMessage("yes"),
+	}
+
+	t.Run("quote requested after the confirmation", func(t *testing.T) {
+		newQuote := uuid.New()
+		service, tickets, server := newTestResponseService(t,
+			llmtest.ToolCallReply("call_1", ToolQuoteRefund, `{"ticket_number":"`+ownTicket+`"}`),
+			llmtest.ToolCallReply("call_2", ToolCancelTicket, `{"ticket_number":"`+ownTicket+`","quote_token":"`+newQuote.String()+`","confirmed":true}`),
+			llmtest.TextReply("done"),
+		)
+		tickets.nextQuote = newQuote
+
+		if _, err := service.GenerateResponse(context.Background(), customer, "en", history); err != nil {
+			t.Fatalf("GenerateResponse: %v", err)
+		}
+		if len(tickets.cancelled) > 0 {
+			t.Fatalf("CancelTicket was called with a quote the customer never saw")
+		}
+		if result := toolResults(server)["call_2"]; result["status"] != "confirmation_required" {
+			t.Errorf("tool result = %v, want confirmation_required", result)
+		}
+	})
+
+	t.Run("unknown quote", func(t *testing.T) {
+		service, tickets, server := newTestResponseService(t,
+			llmtest.ToolCallReply("call_1", ToolCancelTicket, `{"ticket_number":"`+ownTicket+`","quote_token":"`+uuid.NewString()+`","confirmed":true}`),
+			llmtest.TextReply("done"),
+		)
+
+		if _, err := service.GenerateResponse(context.Background(), customer, "en", history); err != nil {
+			t.Fatalf("GenerateResponse: %v", err)
+		}
+		if len(tickets.cancelled) > 0 {
+			t.Fatalf("CancelTicket was called with an unknown quote")
+		}
+		if result := toolResults(server)["call_1"]; result["error"] == nil {
+			t.Errorf("tool result = %v, want an error", result)
+		}
+	})
+}
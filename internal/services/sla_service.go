@@ -0,0 +1,558 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/calendar"
+	"callcenter/internal/events"
+	"callcenter/internal/models"
+)
+
+// SLA targets of a ticket
+const (
+	SLAFirstResponse = "first_response"
+	SLAResolution    = "resolution"
+)
+
+// Ticket search filters on the SLA state of a ticket
+const (
+	SLAFilterAtRisk    = "at_risk"   // a target is about to be missed
+	SLAFilterBreached  = "breached"  // a target was missed
+	SLAFilterEscalated = "escalated" // supervisors were alerted
+)
+
+// defaultSLAWarningPercent is the share of a target after which its breach is
+// upcoming, for policies that do not set one
+const defaultSLAWarningPercent = 80
+
+// slaCheckBatch bounds the tickets one check flags
+const slaCheckBatch = 500
+
+// closedTicketStatuses are the statuses of tickets that need no more work
+var closedTicketStatuses = []string{"resolved", "closed"}
+
+// SLAService manages SLA policies and tracks support tickets against their
+// targets. Targets count business time on the calendar of the ticket's queue.
+type SLAService struct {
+	db        *gorm.DB
+	calendars *CalendarService
+}
+
+// NewSLAService creates a new instance of SLAService
+func NewSLAService(db *gorm.DB) *SLAService {
+	return &SLAService{
+		db:        db,
+		calendars: NewCalendarService(db),
+	}
+}
+
+// ListPolicies lists the SLA policies by priority, the policies for all queues first
+func (s *SLAService) ListPolicies(ctx context.Context) ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	if err := s.db.WithContext(ctx).
+		Order("CASE priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END, queue_id NULLS FIRST, name").
+		Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SLA policies: %w", err)
+	}
+	return policies, nil
+}
+
+// GetPolicy retrieves an SLA policy
+func (s *SLAService) GetPolicy(ctx context.Context, id uuid.UUID) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := s.db.WithContext(ctx).First(&policy, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("SLA policy %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get SLA policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates or updates an SLA policy. Only one policy may cover a
+// priority in a queue, or in all queues. Tickets keep the targets they were
+// given; new tickets and tickets moved to another queue get the new ones.
+func (s *SLAService) SavePolicy(ctx context.Context, policy *models.SLAPolicy) error {
+	if err := validateSLAPolicy(policy); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if policy.QueueID != nil {
+			if err := tx.First(&models.Queue{}, "id = ?", *policy.QueueID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("queue %w: %s", ErrNotFound, *policy.QueueID)
+				}
+				return fmt.Errorf("failed to get queue: %w", err)
+			}
+		}
+
+		var count int64
+		if err := tx.Model(&models.SLAPolicy{}).
+			Where("priority = ? AND queue_id IS NOT DISTINCT FROM ? AND id <> ?", policy.Priority, policy.QueueID, policy.ID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check SLA policy scope: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: another SLA policy already covers this priority and queue", ErrConflict)
+		}
+
+		if policy.ID == uuid.Nil {
+			policy.ID = uuid.New()
+			if err := tx.Create(policy).Error; err != nil {
+				return fmt.Errorf("failed to create SLA policy: %w", err)
+			}
+			return nil
+		}
+		result := tx.Model(policy).
+			Select("name", "priority", "queue_id", "first_response_minutes", "resolution_minutes", "warning_percent").
+			Updates(policy)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update SLA policy: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("SLA policy %w: %s", ErrNotFound, policy.ID)
+		}
+		return nil
+	})
+}
+
+// validateSLAPolicy checks that a policy's targets can be tracked and defaults
+// its warning share
+func validateSLAPolicy(policy *models.SLAPolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	if policy.WarningPercent == 0 {
+		policy.WarningPercent = defaultSLAWarningPercent
+	}
+
+	switch {
+	case policy.Name == "":
+		return fmt.Errorf("%w: policy name is required", ErrInvalidInput)
+	case priorityRank(policy.Priority) == 0:
+		return fmt.Errorf("%w: unknown priority %q", ErrInvalidInput, policy.Priority)
+	case policy.FirstResponseMinutes <= 0 || policy.ResolutionMinutes <= 0:
+		return fmt.Errorf("%w: SLA targets must be positive", ErrInvalidInput)
+	case policy.ResolutionMinutes < policy.FirstResponseMinutes:
+		return fmt.Errorf("%w: resolution target cannot be shorter than the first response target", ErrInvalidInput)
+	case policy.WarningPercent < 1 || policy.WarningPercent > 99:
+		return fmt.Errorf("%w: warning must be between 1 and 99 percent of a target", ErrInvalidInput)
+	}
+	return nil
+}
+
+// DeletePolicy removes an SLA policy. Tickets keep the targets it gave them.
+func (s *SLAService) DeletePolicy(ctx context.Context, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.SLAPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete SLA policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("SLA policy %w: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// apply gives a ticket the targets of the SLA policy for its priority and queue,
+// counted from when the ticket was opened. Targets already met are kept. A
+// ticket no policy covers keeps the targets it has.
+func (s *SLAService) apply(tx *gorm.DB, ticket *models.Ticket) error {
+	var policy models.SLAPolicy
+	err := tx.Where("priority = ? AND (queue_id IS NULL OR queue_id = ?)", ticket.Priority, ticket.QueueID).
+		Order("queue_id NULLS LAST").
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get SLA policy: %w", err)
+	}
+
+	sla, err := s.lock(tx, ticket.ID)
+	if err != nil {
+		return err
+	}
+	created := sla == nil
+	if created {
+		sla = &models.TicketSLA{TicketID: ticket.ID}
+	} else if sla.PolicyID == policy.ID {
+		ticket.SLA = sla
+		return nil
+	}
+
+	cal, err := s.calendars.forQueue(tx, ticket.QueueID)
+	if err != nil {
+		return err
+	}
+	sla.PolicyID = policy.ID
+	if sla.FirstRespondedAt == nil {
+		sla.FirstResponseDueAt, sla.FirstResponseWarnAt = target(cal, ticket.CreatedAt, policy.FirstResponseMinutes, policy.WarningPercent)
+		sla.FirstResponseWarnedAt, sla.FirstResponseBreachedAt = nil, nil
+	}
+	if sla.ResolvedAt == nil {
+		sla.ResolutionDueAt, sla.ResolutionWarnAt = target(cal, ticket.CreatedAt, policy.ResolutionMinutes, policy.WarningPercent)
+		sla.ResolutionWarnedAt, sla.ResolutionBreachedAt = nil, nil
+	}
+
+	if created {
+		err = tx.Create(sla).Error
+	} else {
+		err = tx.Save(sla).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save ticket SLA: %w", err)
+	}
+	ticket.SLA = sla
+	return nil
+}
+
+// target returns when a target of minutes of business time from start is due
+// and when its breach becomes upcoming
+func target(cal *calendar.Calendar, start time.Time, minutes, warningPercent int) (due, warn time.Time) {
+	length := time.Duration(minutes) * time.Minute
+	return cal.Add(start, length), cal.Add(start, length*time.Duration(warningPercent)/100)
+}
+
+// statusChanged moves the SLA clock of a ticket along with its status. The
+// first change by staff away from open is the first response. Waiting on the
+// customer stops the clock, and resolving or closing the ticket meets the
+// resolution target; when the ticket is picked up again, the targets not yet
+// met move out by the business time the clock stood still.
+func (s *SLAService) statusChanged(tx *gorm.DB, ticket *models.Ticket, from, to string, actor Actor) error {
+	sla, err := s.lock(tx, ticket.ID)
+	if err != nil || sla == nil {
+		return err
+	}
+	cal, err := s.calendars.forQueue(tx, ticket.QueueID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	if to != "open" && models.IsStaff(actor.Role) {
+		s.respond(sla, now)
+	}
+	if containsString(closedTicketStatuses, from) && !containsString(closedTicketStatuses, to) && sla.ResolvedAt != nil {
+		resolvedAt := *sla.ResolvedAt
+		sla.ResolvedAt = nil
+		resume(cal, sla, resolvedAt, now)
+	}
+	if from == "waiting_customer" && to != from && sla.PausedAt != nil {
+		resume(cal, sla, *sla.PausedAt, now)
+		sla.PausedAt = nil
+	}
+	if to == "waiting_customer" && sla.PausedAt == nil {
+		sla.PausedAt = &now
+	}
+	if containsString(closedTicketStatuses, to) && sla.ResolvedAt == nil {
+		sla.ResolvedAt = &now
+		if now.After(sla.ResolutionDueAt) && sla.ResolutionBreachedAt == nil {
+			sla.ResolutionBreachedAt = &sla.ResolutionDueAt
+		}
+	}
+
+	if err := tx.Save(sla).Error; err != nil {
+		return fmt.Errorf("failed to save ticket SLA: %w", err)
+	}
+	ticket.SLA = sla
+	return nil
+}
+
+// respond meets the first response target of a ticket at the given time, if it
+// is not met yet. A late response is a breach.
+func (s *SLAService) respond(sla *models.TicketSLA, at time.Time) {
+	if sla.FirstRespondedAt != nil {
+		return
+	}
+	sla.FirstRespondedAt = &at
+	if at.After(sla.FirstResponseDueAt) && sla.FirstResponseBreachedAt == nil {
+		sla.FirstResponseBreachedAt = &sla.FirstResponseDueAt
+	}
+}
+
+// responded meets the first response target of a ticket with a reply sent at
+// the given time
+func (s *SLAService) responded(tx *gorm.DB, ticketID uuid.UUID, at time.Time) error {
+	sla, err := s.lock(tx, ticketID)
+	if err != nil || sla == nil || sla.FirstRespondedAt != nil {
+		return err
+	}
+	s.respond(sla, at)
+	if err := tx.Save(sla).Error; err != nil {
+		return fmt.Errorf("failed to save ticket SLA: %w", err)
+	}
+	return nil
+}
+
+// resume restarts the clock stopped since the given time: the due and warning
+// times of the targets not yet met move out by the business time since then.
+// Times that had passed when the clock stopped stay.
+func resume(cal *calendar.Calendar, sla *models.TicketSLA, since, now time.Time) {
+	shift := func(t *time.Time) {
+		if t.After(since) {
+			*t = cal.Add(now, cal.Between(since, *t))
+		}
+	}
+	if sla.FirstRespondedAt == nil {
+		shift(&sla.FirstResponseDueAt)
+		shift(&sla.FirstResponseWarnAt)
+	}
+	if sla.ResolvedAt == nil {
+		shift(&sla.ResolutionDueAt)
+		shift(&sla.ResolutionWarnAt)
+	}
+}
+
+// lock reads the SLA of a ticket for update; nil if the ticket has none
+func (s *SLAService) lock(tx *gorm.DB, ticketID uuid.UUID) (*models.TicketSLA, error) {
+	var sla models.TicketSLA
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sla, "ticket_id = ?", ticketID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket SLA: %w", err)
+	}
+	return &sla, nil
+}
+
+// Run checks the SLA targets of open tickets every interval until ctx is done
+func (s *SLAService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(ctx, time.Now()); err != nil {
+			log.Printf("SLA check: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check flags the SLA targets of open tickets whose breach is upcoming or
+// happened by the given time, and publishes an event for each. The first breach
+// of a ticket escalates it to the supervisors. Every flag is set once, also
+// when several instances check at the same time; tickets waiting on the
+// customer are not checked.
+func (s *SLAService) Check(ctx context.Context, now time.Time) error {
+	var slas []models.TicketSLA
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN tickets ON tickets.id = ticket_slas.ticket_id AND tickets.deleted_at IS NULL").
+		Where("ticket_slas.resolved_at IS NULL AND ticket_slas.paused_at IS NULL").
+		Where(`(ticket_slas.first_responded_at IS NULL AND (
+				(ticket_slas.first_response_warned_at IS NULL AND ticket_slas.first_response_warn_at <= @now) OR
+				(ticket_slas.first_response_breached_at IS NULL AND ticket_slas.first_response_due_at <= @now)))
+			OR (ticket_slas.resolution_warned_at IS NULL AND ticket_slas.resolution_warn_at <= @now)
+			OR (ticket_slas.resolution_breached_at IS NULL AND ticket_slas.resolution_due_at <= @now)`, sql.Named("now", now)).
+		Select("ticket_slas.*").
+		Order("ticket_slas.resolution_due_at").
+		Limit(slaCheckBatch).
+		Find(&slas).Error; err != nil {
+		return fmt.Errorf("failed to find tickets due: %w", err)
+	}
+	if len(slas) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(slas))
+	for i := range slas {
+		ids[i] = slas[i].TicketID
+	}
+	var tickets []models.Ticket
+	if err := s.db.WithContext(ctx).Find(&tickets, "id IN ?", ids).Error; err != nil {
+		return fmt.Errorf("failed to get tickets due: %w", err)
+	}
+	byID := make(map[uuid.UUID]*models.Ticket, len(tickets))
+	for i := range tickets {
+		byID[tickets[i].ID] = &tickets[i]
+	}
+
+	for i := range slas {
+		if ticket := byID[slas[i].TicketID]; ticket != nil {
+			if err := s.checkTicket(ctx, ticket, &slas[i], now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// slaTarget is one target of a ticket SLA, with the columns it is flagged in
+type slaTarget struct {
+	name           string
+	met            *time.Time
+	warnAt         time.Time
+	dueAt          time.Time
+	warned         *time.Time
+	breached       *time.Time
+	warnedColumn   string
+	breachedColumn string
+}
+
+// checkTicket flags the targets of a ticket that are upcoming or breached and
+// escalates its first breach
+func (s *SLAService) checkTicket(ctx context.Context, ticket *models.Ticket, sla *models.TicketSLA, now time.Time) error {
+	targets := []slaTarget{
+		{SLAFirstResponse, sla.FirstRespondedAt, sla.FirstResponseWarnAt, sla.FirstResponseDueAt,
+			sla.FirstResponseWarnedAt, sla.FirstResponseBreachedAt, "first_response_warned_at", "first_response_breached_at"},
+		{SLAResolution, sla.ResolvedAt, sla.ResolutionWarnAt, sla.ResolutionDueAt,
+			sla.ResolutionWarnedAt, sla.ResolutionBreachedAt, "resolution_warned_at", "resolution_breached_at"},
+	}
+
+	breached := false
+	for _, target := range targets {
+		switch {
+		case target.met != nil:
+		case target.breached == nil && !target.dueAt.After(now):
+			// A breach found before its warning also settles the warning
+			flagged, err := s.flag(ctx, ticket.ID, target.breachedColumn, map[string]interface{}{
+				target.breachedColumn: target.dueAt,
+				target.warnedColumn:   gorm.Expr("COALESCE("+target.warnedColumn+", ?)", now),
+			})
+			if err != nil {
+				return err
+			}
+			if flagged {
+				breached = true
+				events.Publish(ctx, slaEvent(events.TicketSLABreach, ticket, target))
+			}
+		case target.warned == nil && !target.warnAt.After(now):
+			flagged, err := s.flag(ctx, ticket.ID, target.warnedColumn, map[string]interface{}{target.warnedColumn: now})
+			if err != nil {
+				return err
+			}
+			if flagged {
+				events.Publish(ctx, slaEvent(events.TicketSLAWarning, ticket, target))
+			}
+		}
+	}
+
+	if !breached || sla.EscalatedAt != nil {
+		return nil
+	}
+	return s.escalate(ctx, ticket, now)
+}
+
+// escalate alerts the supervisors to a ticket that missed a target
+func (s *SLAService) escalate(ctx context.Context, ticket *models.Ticket, now time.Time) error {
+	flagged, err := s.flag(ctx, ticket.ID, "escalated_at", map[string]interface{}{"escalated_at": now})
+	if err != nil || !flagged {
+		return err
+	}
+
+	var supervisors []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("role = ?", models.RoleSupervisor).
+		Pluck("id", &supervisors).Error; err != nil {
+		return fmt.Errorf("failed to get supervisors: %w", err)
+	}
+
+	events.Publish(ctx, events.New(events.TicketEscalated, ticket.ID, map[string]interface{}{
+		"number":      ticket.Number,
+		"priority":    ticket.Priority,
+		"queue_id":    ticket.QueueID,
+		"assignee_id": ticket.AssigneeID,
+		"supervisors": supervisors,
+	}))
+	return nil
+}
+
+// flag sets columns of a ticket SLA unless column is already set, and reports
+// whether it did
+func (s *SLAService) flag(ctx context.Context, ticketID uuid.UUID, column string, updates map[string]interface{}) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&models.TicketSLA{}).
+		Where("ticket_id = ? AND "+column+" IS NULL", ticketID).
+		Updates(updates)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to flag ticket SLA: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func slaEvent(eventType string, ticket *models.Ticket, target slaTarget) events.Event {
+	return events.New(eventType, ticket.ID, map[string]interface{}{
+		"number":      ticket.Number,
+		"target":      target.name,
+		"due_at":      target.dueAt,
+		"priority":    ticket.Priority,
+		"queue_id":    ticket.QueueID,
+		"assignee_id": ticket.AssigneeID,
+	})
+}
+
+// SLAReportRow counts the tickets of a queue and priority by how they fared
+// against their SLA targets. Compliance is the percentage of the decided
+// targets, met or breached, that were met.
+type SLAReportRow struct {
+	QueueID                 *uuid.UUID `json:"queue_id"`
+	Priority                string     `json:"priority"`
+	Tickets                 int64      `json:"tickets"`
+	FirstResponseMet        int64      `json:"first_response_met"`
+	FirstResponseBreached   int64      `json:"first_response_breached"`
+	ResolutionMet           int64      `json:"resolution_met"`
+	ResolutionBreached      int64      `json:"resolution_breached"`
+	Escalated               int64      `json:"escalated"`
+	FirstResponseCompliance float64    `json:"first_response_compliance" gorm:"-"`
+	ResolutionCompliance    float64    `json:"resolution_compliance" gorm:"-"`
+}
+
+// SLAReport is how the tickets opened in a period fared against their SLA targets
+type SLAReport struct {
+	From time.Time      `json:"from"`
+	To   time.Time      `json:"to"`
+	Rows []SLAReportRow `json:"rows"`
+}
+
+// Report summarizes the SLA performance of the tickets opened from one time
+// until another, optionally of one queue only
+func (s *SLAService) Report(ctx context.Context, from, to time.Time, queueID *uuid.UUID) (*SLAReport, error) {
+	query := s.db.WithContext(ctx).Model(&models.TicketSLA{}).
+		Joins("JOIN tickets ON tickets.id = ticket_slas.ticket_id AND tickets.deleted_at IS NULL").
+		Where("tickets.created_at >= ? AND tickets.created_at < ?", from, to)
+	if queueID != nil {
+		query = query.Where("tickets.queue_id = ?", *queueID)
+	}
+
+	report := &SLAReport{From: from, To: to, Rows: []SLAReportRow{}}
+	if err := query.Select(`tickets.queue_id, tickets.priority, count(*) AS tickets,
+			count(*) FILTER (WHERE ticket_slas.first_responded_at IS NOT NULL AND ticket_slas.first_response_breached_at IS NULL) AS first_response_met,
+			count(ticket_slas.first_response_breached_at) AS first_response_breached,
+			count(*) FILTER (WHERE ticket_slas.resolved_at IS NOT NULL AND ticket_slas.resolution_breached_at IS NULL) AS resolution_met,
+			count(ticket_slas.resolution_breached_at) AS resolution_breached,
+			count(ticket_slas.escalated_at) AS escalated`).
+		Group("tickets.queue_id, tickets.priority").
+		Order("tickets.queue_id NULLS FIRST, " + ticketSortColumns[TicketSortPriority] + " DESC").
+		Scan(&report.Rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to report SLA performance: %w", err)
+	}
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		row.FirstResponseCompliance = compliance(row.FirstResponseMet, row.FirstResponseBreached)
+		row.ResolutionCompliance = compliance(row.ResolutionMet, row.ResolutionBreached)
+	}
+	return report, nil
+}
+
+// compliance is the percentage of met targets, to one decimal, or 0 when none
+// were decided
+func compliance(met, breached int64) float64 {
+	if met+breached == 0 {
+		return 0
+	}
+	return math.Round(float64(met)*1000/float64(met+breached)) / 10
+}
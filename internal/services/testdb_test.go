@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"callcenter/internal/database"
+	"callcenter/internal/models"
+)
+
+var (
+	testDBOnce sync.Once
+	testDBConn *gorm.DB
+	testDBErr  error
+)
+
+// testDB connects to the Postgres database in TEST_DATABASE_URL and migrates it
+// once per run. Tests that need it are skipped when it is not set. Tests share
+// the database, so they create their own records and never rely on counts.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	testDBOnce.Do(func() {
+		testDBConn, testDBErr = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if testDBErr == nil {
+			testDBErr = database.Migrate(testDBConn)
+		}
+	})
+	if testDBErr != nil {
+		t.Fatalf("test database: %v", testDBErr)
+	}
+	return testDBConn
+}
+
+// createTestUser stores a user with the given role
+func createTestUser(t *testing.T, db *gorm.DB, role string) *models.User {
+	t.Helper()
+	user := &models.User{ID: uuid.New(), Role: role, Password: "x", Name: role}
+	user.Email = fmt.Sprintf("%s@example.com", user.ID)
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestBooking stores an active IRR systematic booking of a customer with
+// the given number of passengers on a return trip departing in ten days
+func createTestBooking(t *testing.T, db *gorm.DB, customer *models.User, passengers int) *models.Booking {
+	t.Helper()
+	departure := time.Now().Add(240 * time.Hour)
+	booking := &models.Booking{
+		UserID:     customer.ID,
+		PNR:        randomPNR(),
+		Airline:    "IR",
+		FareClass:  "Y",
+		TicketType: models.TicketTypeSystematic,
+		Currency:   "IRR",
+		Segments: []models.Segment{
+			{Airline: "IR", FlightNumber: "IR123", Origin: "THR", Destination: "MHD", DepartureAt: departure},
+			{Airline: "IR", FlightNumber: "IR124", Origin: "MHD", Destination: "THR", DepartureAt: departure.Add(72 * time.Hour)},
+		},
+	}
+	for i := 0; i < passengers; i++ {
+		booking.Passengers = append(booking.Passengers, models.Passenger{
+			FirstName: "Sara", LastName: fmt.Sprintf("Ahmadi %d", i), BaseFare: 20000000, Taxes: 2000000,
+		})
+	}
+	if err := NewBookingService(db).CreateBooking(t.Context(), booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	return booking
+}
+
+// createTestTicket stores an open support ticket of a customer about a booking
+func createTestTicket(t *testing.T, db *gorm.DB, customer *models.User, booking *models.Booking) *models.Ticket {
+	t.Helper()
+	ticket := &models.Ticket{
+		ID:          uuid.New(),
+		UserID:      customer.ID,
+		BookingID:   &booking.ID,
+		Number:      fmt.Sprintf("TST-%s", uuid.NewString()[:13]),
+		Status:      models.TicketStatusOpen,
+		Subject:     "Cancel my flight",
+		Description: "Please cancel my booking",
+		Type:        "cancellation",
+	}
+	if err := db.Omit("Booking", "User").Create(ticket).Error; err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	return ticket
+}
+
+func randomPNR() string {
+	const letters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	pnr := make([]byte, 6)
+	for i := range pnr {
+		pnr[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(pnr)
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/fuzzy"
+	"callcenter/internal/models"
+)
+
+// duplicateWindow is how long before or after a ticket was opened another
+// ticket can be its duplicate
+const duplicateWindow = 72 * time.Hour
+
+// similarSubject is the subject similarity from which tickets of the same
+// customer or phone number are likely duplicates
+const similarSubject = 0.5
+
+// Reasons a ticket is a likely duplicate of another
+const (
+	DuplicateSameBooking    = "same_booking"
+	DuplicateSameCustomer   = "same_customer"
+	DuplicateSamePhone      = "same_phone"
+	DuplicateSimilarSubject = "similar_subject"
+)
+
+// subjectMatcher compares ticket subjects, allowing typos
+var subjectMatcher = fuzzy.NewMatcher(fuzzy.DefaultOptions)
+
+// DuplicateTicket is a ticket that likely covers the same problem as another
+type DuplicateTicket struct {
+	Ticket     models.Ticket `json:"ticket"`
+	Reasons    []string      `json:"reasons"`    // DuplicateSameBooking, DuplicateSameCustomer, DuplicateSamePhone, DuplicateSimilarSubject
+	Similarity float64       `json:"similarity"` // of the subjects, from 0 to 1
+}
+
+// FindDuplicates returns the tickets opened within duplicateWindow of a ticket
+// that likely cover the same problem: those about the same booking, and those
+// of the same customer or contact phone number with a similar subject. Tickets
+// about the same booking come first, then the most similar.
+func (s *TicketService) FindDuplicates(ctx context.Context, ticket *models.Ticket) ([]DuplicateTicket, error) {
+	db := s.db.WithContext(ctx)
+	phone := ""
+	if ticket.BookingID != nil {
+		var phones []string
+		if err := db.Model(&models.Booking{}).Where("id = ?", *ticket.BookingID).Pluck("contact_phone", &phones).Error; err != nil {
+			return nil, fmt.Errorf("failed to get booking: %w", err)
+		}
+		if len(phones) > 0 {
+			phone = phones[0]
+		}
+	}
+
+	query := db.Model(&models.Ticket{}).
+		Joins("LEFT JOIN bookings ON bookings.id = tickets.booking_id AND bookings.deleted_at IS NULL").
+		Where("tickets.id <> ? AND tickets.merged_into_id IS NULL", ticket.ID).
+		Where("tickets.created_at BETWEEN ? AND ?", ticket.CreatedAt.Add(-duplicateWindow), ticket.CreatedAt.Add(duplicateWindow))
+	match := s.db.Where("tickets.user_id = ?", ticket.UserID)
+	if ticket.BookingID != nil {
+		match = match.Or("tickets.booking_id = ?", *ticket.BookingID)
+	}
+	if phone != "" {
+		match = match.Or("bookings.contact_phone = ?", phone)
+	}
+
+	var candidates []models.Ticket
+	if err := query.Where(match).
+		Select("tickets.*").
+		Preload("Booking").
+		Order("tickets.created_at").
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to find duplicate tickets: %w", err)
+	}
+
+	duplicates := []DuplicateTicket{}
+	for _, candidate := range candidates {
+		duplicate := DuplicateTicket{
+			Ticket:     candidate,
+			Similarity: subjectSimilarity(ticket.Subject, candidate.Subject),
+		}
+		sameBooking := ticket.BookingID != nil && candidate.BookingID != nil && *ticket.BookingID == *candidate.BookingID
+		if sameBooking {
+			duplicate.Reasons = append(duplicate.Reasons, DuplicateSameBooking)
+		}
+		if candidate.UserID == ticket.UserID {
+			duplicate.Reasons = append(duplicate.Reasons, DuplicateSameCustomer)
+		}
+		if phone != "" && candidate.Booking != nil && candidate.Booking.ContactPhone == phone {
+			duplicate.Reasons = append(duplicate.Reasons, DuplicateSamePhone)
+		}
+		if duplicate.Similarity >= similarSubject {
+			duplicate.Reasons = append(duplicate.Reasons, DuplicateSimilarSubject)
+		} else if !sameBooking {
+			continue
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		bookingI := duplicates[i].Reasons[0] == DuplicateSameBooking
+		bookingJ := duplicates[j].Reasons[0] == DuplicateSameBooking
+		if bookingI != bookingJ {
+			return bookingI
+		}
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+	return duplicates, nil
+}
+
+// flagDuplicates notes the likely duplicates of a new ticket in its history
+// so agents can merge them. Customers see the history of their tickets, so
+// only their own tickets are noted.
+func (s *TicketService) flagDuplicates(ctx context.Context, ticket *models.Ticket, actor Actor) error {
+	duplicates, err := s.FindDuplicates(ctx, ticket)
+	if err != nil {
+		return err
+	}
+
+	var numbers []string
+	for _, duplicate := range duplicates {
+		if duplicate.Ticket.UserID == ticket.UserID {
+			numbers = append(numbers, duplicate.Ticket.Number)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+	return recordTicketHistory(s.db.WithContext(ctx), ticket.ID, "possible_duplicate",
+		"Possible duplicate of "+strings.Join(numbers, ", "), actor)
+}
+
+// MergeTickets merges duplicate tickets of the same customer into a surviving
+// ticket on behalf of staff. The survivor gets the comments, history and tags
+// of the duplicates, with the attachments of the comments, and their original
+// subject and description as comments by the customer; it takes over their
+// booking if it has none. The duplicates are closed as duplicates, lose their SLA targets
+// and redirect to the survivor from then on.
+func (s *TicketService) MergeTickets(ctx context.Context, survivorID uuid.UUID, duplicateIDs []uuid.UUID, actor Actor) (*models.Ticket, error) {
+	duplicateIDs = uniqueIDs(duplicateIDs)
+	if len(duplicateIDs) == 0 {
+		return nil, fmt.Errorf("%w: no tickets to merge", ErrInvalidInput)
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking in ID order keeps concurrent merges from deadlocking
+		var tickets []models.Ticket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", append([]uuid.UUID{survivorID}, duplicateIDs...)).
+			Order("id").
+			Find(&tickets).Error; err != nil {
+			return fmt.Errorf("failed to get tickets: %w", err)
+		}
+		byID := make(map[uuid.UUID]*models.Ticket, len(tickets))
+		for i := range tickets {
+			byID[tickets[i].ID] = &tickets[i]
+		}
+
+		survivor := byID[survivorID]
+		if survivor == nil {
+			return fmt.Errorf("ticket %w: %s", ErrNotFound, survivorID)
+		}
+		if survivor.MergedIntoID != nil {
+			return fmt.Errorf("%w: ticket %s was merged into another ticket", ErrConflict, survivor.Number)
+		}
+		bookingID := survivor.BookingID
+		for _, id := range duplicateIDs {
+			duplicate := byID[id]
+			switch {
+			case duplicate == nil:
+				return fmt.Errorf("ticket %w: %s", ErrNotFound, id)
+			case duplicate.ID == survivor.ID:
+				return fmt.Errorf("%w: a ticket cannot be merged into itself", ErrInvalidInput)
+			case duplicate.MergedIntoID != nil:
+				return fmt.Errorf("%w: ticket %s was already merged", ErrConflict, duplicate.Number)
+			case duplicate.UserID != survivor.UserID:
+				return fmt.Errorf("%w: tickets %s and %s belong to different customers", ErrConflict, duplicate.Number, survivor.Number)
+			case duplicate.BookingID != nil && bookingID != nil && *duplicate.BookingID != *bookingID:
+				return fmt.Errorf("%w: tickets %s and %s are about different bookings", ErrConflict, duplicate.Number, survivor.Number)
+			}
+			if bookingID == nil {
+				bookingID = duplicate.BookingID
+			}
+		}
+
+		for _, id := range duplicateIDs {
+			if err := s.merge(tx, survivor, byID[id], actor); err != nil {
+				return err
+			}
+		}
+		if survivor.BookingID == nil && bookingID != nil {
+			if err := tx.Model(survivor).Update("booking_id", bookingID).Error; err != nil {
+				return fmt.Errorf("failed to update ticket booking: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindTicket(ctx, survivorID.String(), actor)
+}
+
+// merge moves the conversation of a duplicate ticket into the survivor, gives
+// the survivor its tags and closes the duplicate
+func (s *TicketService) merge(tx *gorm.DB, survivor, duplicate *models.Ticket, actor Actor) error {
+	original := models.TicketComment{
+		ID:         uuid.New(),
+		TicketID:   survivor.ID,
+		AuthorID:   duplicate.UserID,
+		Visibility: models.CommentPublic,
+		Body:       duplicate.Subject + "\n\n" + duplicate.Description,
+		CreatedAt:  duplicate.CreatedAt,
+	}
+	if err := tx.Create(&original).Error; err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	if err := tx.Model(&models.TicketComment{}).Where("ticket_id = ?", duplicate.ID).
+		Update("ticket_id", survivor.ID).Error; err != nil {
+		return fmt.Errorf("failed to move comments: %w", err)
+	}
+	if err := tx.Model(&models.TicketHistory{}).Where("ticket_id = ?", duplicate.ID).
+		Update("ticket_id", survivor.ID).Error; err != nil {
+		return fmt.Errorf("failed to move ticket history: %w", err)
+	}
+	if err := tx.Exec(`INSERT INTO ticket_tags (ticket_id, tag)
+		SELECT ?, tag FROM ticket_tags WHERE ticket_id = ?
+		ON CONFLICT DO NOTHING`, survivor.ID, duplicate.ID).Error; err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
+	}
+	if err := recordTicketHistory(tx, survivor.ID, "merged", "Merged "+duplicate.Number+" into this ticket", actor); err != nil {
+		return err
+	}
+	if err := recordTicketHistory(tx, duplicate.ID, "merged", "Merged into "+survivor.Number, actor); err != nil {
+		return err
+	}
+
+	if duplicate.Status != models.TicketStatusClosed {
+		if err := recordTicketStatus(tx, duplicate.ID, duplicate.Status, models.TicketStatusClosed,
+			models.StatusReasonDuplicate, "Merged into "+survivor.Number, actor); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(duplicate).Updates(map[string]interface{}{
+		"status":         models.TicketStatusClosed,
+		"merged_into_id": survivor.ID,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to close ticket: %w", err)
+	}
+	// Tickets merged into the duplicate before redirect to the survivor directly
+	if err := tx.Model(&models.Ticket{}).Where("merged_into_id = ?", duplicate.ID).
+		Update("merged_into_id", survivor.ID).Error; err != nil {
+		return fmt.Errorf("failed to redirect merged tickets: %w", err)
+	}
+	if err := tx.Where("ticket_id = ?", duplicate.ID).Delete(&models.TicketSLA{}).Error; err != nil {
+		return fmt.Errorf("failed to delete ticket SLA: %w", err)
+	}
+	return nil
+}
+
+// survivor returns the ticket a merged ticket was merged into, with the same
+// associations loaded, or the ticket itself when it was not merged
+func (s *TicketService) survivor(ctx context.Context, ticket *models.Ticket) (*models.Ticket, error) {
+	if ticket.MergedIntoID == nil {
+		return ticket, nil
+	}
+	var survivor models.Ticket
+	if err := s.db.WithContext(ctx).
+		Preload("Booking", withItinerary).
+		Preload("SLA").
+		Preload("Tags").
+		First(&survivor, "id = ?", *ticket.MergedIntoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w: %s", ErrNotFound, *ticket.MergedIntoID)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return &survivor, nil
+}
+
+// subjectSimilarity is the share of the significant words of two subjects
+// found in the other subject, allowing typos, from 0 to 1
+func subjectSimilarity(a, b string) float64 {
+	wordsA, wordsB := significantWords(a), significantWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	found := 0
+	for _, word := range wordsA {
+		if subjectMatcher.Contains(b, word) {
+			found++
+		}
+	}
+	for _, word := range wordsB {
+		if subjectMatcher.Contains(a, word) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(wordsA)+len(wordsB))
+}
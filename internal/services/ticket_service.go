@@ -2,211 +2,576 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"callcenter/internal/models"
+	"callcenter/internal/ticketnumber"
 )
 
-// TicketService handles ticket-related operations
+// maxTicketNumberAttempts bounds how often creating a ticket is retried when
+// its number is already taken
+const maxTicketNumberAttempts = 5
+
+// TicketService handles support ticket operations. Cancellation and refunds of
+// a support ticket act on the booking it references.
 type TicketService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	bookings    *BookingService
+	assignments *AssignmentService
+	sla         *SLAService
+	format      ticketnumber.Format
+	numbers     *ticketnumber.Parser
 }
 
-// NewTicketService creates a new instance of TicketService
-func NewTicketService(db *gorm.DB) *TicketService {
+// NewTicketService creates a new instance of TicketService that numbers new
+// tickets in the given format
+func NewTicketService(db *gorm.DB, format ticketnumber.Format) *TicketService {
 	return &TicketService{
-		db: db,
+		db:          db,
+		bookings:    NewBookingService(db),
+		assignments: NewAssignmentService(db),
+		sla:         NewSLAService(db),
+		format:      format,
+		numbers:     format.Parser(),
+	}
+}
+
+// CreateTicket opens a support ticket under the next ticket number, records its
+// creation in the ticket history and status timeline, routes it to the queue of
+// its type and starts the clock of its SLA targets.
+// Numbers come from the ticket_number_seq sequence; a number that is already
+// taken, e.g. after the format was changed, is skipped. Likely duplicates of
+// the ticket are noted in its history.
+func (s *TicketService) CreateTicket(ctx context.Context, ticket *models.Ticket, actor Actor) error {
+	for attempt := 1; ; attempt++ {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var sequence int64
+			if err := tx.Raw("SELECT nextval('ticket_number_seq')").Scan(&sequence).Error; err != nil {
+				return fmt.Errorf("failed to allocate ticket number: %w", err)
+			}
+			ticket.Number = s.format.Number(sequence, time.Now())
+			if err := tx.Create(ticket).Error; err != nil {
+				return err
+			}
+
+			history := models.TicketHistory{
+				ID:          uuid.New(),
+				TicketID:    ticket.ID,
+				Action:      "created",
+				Description: "Ticket created",
+				UserID:      actor.UserID,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+			if err := recordTicketStatus(tx, ticket.ID, "", ticket.Status, models.StatusReasonCreated, "", actor); err != nil {
+				return err
+			}
+			if err := s.assignments.route(tx, ticket, actor); err != nil {
+				return err
+			}
+			return s.sla.apply(tx, ticket)
+		})
+		if err == nil {
+			if err := s.flagDuplicates(ctx, ticket, actor); err != nil {
+				log.Printf("ticket %s: failed to flag duplicates: %v", ticket.Number, err)
+			}
+			return nil
+		}
+		if !isUniqueViolation(err, "idx_tickets_number") || attempt == maxTicketNumberAttempts {
+			return fmt.Errorf("failed to create ticket: %w", err)
+		}
 	}
 }
 
-// GetTicket retrieves ticket details by ticket number
+// GetTicket retrieves a support ticket and its booking by ticket number. Numbers
+// in the current format are found in any case. The number of a merged ticket
+// finds the ticket it was merged into.
 func (s *TicketService) GetTicket(ctx context.Context, ticketNumber string) (*models.Ticket, error) {
+	ticketNumber = strings.TrimSpace(ticketNumber)
+	if s.numbers.Valid(ticketNumber) {
+		ticketNumber = strings.ToUpper(ticketNumber)
+	}
+
 	var ticket models.Ticket
 	if err := s.db.WithContext(ctx).
-		Where("ticket_number = ?", ticketNumber).
+		Preload("Booking", withItinerary).
+		Preload("SLA").
+		Preload("Tags").
+		Where("number = ?", ticketNumber).
 		First(&ticket).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("ticket not found: %s", ticketNumber)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w: %s", ErrNotFound, ticketNumber)
 		}
 		return nil, fmt.Errorf("failed to get ticket: %w", err)
 	}
-	return &ticket, nil
+	return s.survivor(ctx, &ticket)
 }
 
-// GetTicketByPhone retrieves ticket details by passenger phone number
-func (s *TicketService) GetTicketByPhone(ctx context.Context, phoneNumber string) ([]models.Ticket, error) {
-	var tickets []models.Ticket
-	if err := s.db.WithContext(ctx).
-		Where("passenger_phone = ?", phoneNumber).
-		Find(&tickets).Error; err != nil {
-		return nil, fmt.Errorf("failed to get tickets: %w", err)
+// FindTicket retrieves a support ticket and its booking by ID or ticket number
+// if the actor may see it. Tickets of other customers are reported as not found.
+// A merged ticket finds the ticket it was merged into.
+func (s *TicketService) FindTicket(ctx context.Context, ref string, actor Actor) (*models.Ticket, error) {
+	var ticket *models.Ticket
+	if id, err := uuid.Parse(ref); err == nil {
+		ticket = &models.Ticket{}
+		if err := s.db.WithContext(ctx).
+			Preload("Booking", withItinerary).
+			Preload("SLA").
+			Preload("Tags").
+			First(ticket, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("ticket %w: %s", ErrNotFound, ref)
+			}
+			return nil, fmt.Errorf("failed to get ticket: %w", err)
+		}
+		if ticket, err = s.survivor(ctx, ticket); err != nil {
+			return nil, err
+		}
+	} else if ticket, err = s.GetTicket(ctx, ref); err != nil {
+		return nil, err
 	}
-	return tickets, nil
-}
 
-// CancelTicket cancels a ticket and initiates refund process
-func (s *TicketService) CancelTicket(ctx context.Context, ticketNumber string, reason string) error {
-	// Start transaction
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("failed to start transaction: %w", tx.Error)
+	if !actor.canAccess(ticket) {
+		return nil, fmt.Errorf("ticket %w: %s", ErrNotFound, ref)
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	return ticket, nil
+}
+
+// UpdateStatus moves a support ticket to a new status on behalf of the actor for
+// a reason code, records the change in the ticket history and status timeline
+// and moves the clock of its SLA targets along. Only the transitions of
+// ticketTransitions are allowed. Without a reason code the usual one for the
+// transition is recorded.
+func (s *TicketService) UpdateStatus(ctx context.Context, ticketID uuid.UUID, status, reason, description string, actor Actor) (*models.Ticket, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ticket models.Ticket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, "id = ?", ticketID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ticket %w: %s", ErrNotFound, ticketID)
+			}
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+		from := ticket.Status
+		if err := checkTransition(from, status); err != nil {
+			return err
+		}
+		reason, err := statusReason(from, status, reason)
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get ticket
-	var ticket models.Ticket
-	if err := tx.Where("ticket_number = ?", ticketNumber).First(&ticket).Error; err != nil {
-		tx.Rollback()
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("ticket not found: %s", ticketNumber)
+		if err := tx.Model(&ticket).Update("status", status).Error; err != nil {
+			return fmt.Errorf("failed to update ticket status: %w", err)
+		}
+		if err := recordTicketStatus(tx, ticket.ID, from, status, reason, description, actor); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to get ticket: %w", err)
+		if err := recordTicketHistory(tx, ticket.ID, "status_updated", description, actor); err != nil {
+			return err
+		}
+		return s.sla.statusChanged(tx, &ticket, from, status, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindTicket(ctx, ticketID.String(), actor)
+}
+
+// AssignTicket moves a support ticket between queues and agents; see AssignmentService.Assign
+func (s *TicketService) AssignTicket(ctx context.Context, ticketID uuid.UUID, assignment Assignment, actor Actor) (*models.Ticket, error) {
+	return s.assignments.Assign(ctx, ticketID, assignment, actor)
+}
+
+// GetTicketByPhone retrieves the support tickets whose booking was made with a
+// contact phone number. Customers only get their own tickets.
+func (s *TicketService) GetTicketByPhone(ctx context.Context, phoneNumber string, actor Actor) ([]models.Ticket, error) {
+	query := s.db.WithContext(ctx).
+		Preload("Booking", withItinerary).
+		Preload("SLA").
+		Preload("Tags").
+		Joins("JOIN bookings ON bookings.id = tickets.booking_id AND bookings.deleted_at IS NULL").
+		Where("bookings.contact_phone = ?", phoneNumber)
+	if !models.IsStaff(actor.Role) {
+		query = query.Where("tickets.user_id = ?", actor.UserID)
 	}
 
-	// Check if ticket can be cancelled
-	if ticket.Status != "active" {
-		tx.Rollback()
-		return fmt.Errorf("ticket cannot be cancelled: current status is %s", ticket.Status)
+	var tickets []models.Ticket
+	if err := query.Order("tickets.created_at DESC").Find(&tickets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tickets: %w", err)
 	}
+	return tickets, nil
+}
 
-	// Calculate refund amount based on ticket type and cancellation policy
-	refundAmount, err := s.calculateRefundAmount(ctx, &ticket)
+// QuoteCancellation quotes the refund for cancelling the selected passengers and
+// segments of the booking of a support ticket
+func (s *TicketService) QuoteCancellation(ctx context.Context, ticketNumber string, selection RefundSelection, actor Actor) (*models.RefundQuote, error) {
+	ticket, err := s.GetTicket(ctx, ticketNumber)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to calculate refund amount: %w", err)
+		return nil, err
+	}
+	if ticket.Booking == nil {
+		return nil, fmt.Errorf("%w: ticket %s has no booking", ErrConflict, ticketNumber)
 	}
+	return s.bookings.QuoteRefund(ctx, ticket.Booking, selection, actor)
+}
 
-	// Create refund request
-	refundRequest := &models.RefundRequest{
-		TicketNumber: ticketNumber,
-		RequestedBy:  "system", // TODO: Replace with actual user ID
-		Reason:       reason,
-		Status:       "pending",
-		Amount:       refundAmount,
-		Currency:     "USD", // Hardcoded for now since Ticket model doesn't have Currency field
+// GetRefundQuote retrieves a refund quote for the booking of a support ticket
+func (s *TicketService) GetRefundQuote(ctx context.Context, ticketNumber string, quoteID uuid.UUID) (*models.RefundQuote, error) {
+	ticket, err := s.GetTicket(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
 	}
+	if ticket.BookingID == nil {
+		return nil, fmt.Errorf("refund quote %w: %s", ErrNotFound, quoteID)
+	}
+	return s.bookings.GetRefundQuote(ctx, *ticket.BookingID, quoteID)
+}
 
-	if err := tx.Create(refundRequest).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to create refund request: %w", err)
+// CancelTicket cancels the booking of a support ticket on behalf of the actor at
+// the amount of an accepted refund quote and initiates the refund process
+func (s *TicketService) CancelTicket(ctx context.Context, ticketNumber string, quoteID uuid.UUID, reason string, actor Actor) (*models.RefundRequest, error) {
+	ticket, err := s.GetTicket(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.BookingID == nil {
+		return nil, fmt.Errorf("%w: ticket %s has no booking", ErrConflict, ticketNumber)
 	}
 
-	// Update ticket status
-	ticket.Status = "cancelled"
+	return s.bookings.CancelBooking(ctx, Cancellation{
+		BookingID: *ticket.BookingID,
+		QuoteID:   quoteID,
+		Reason:    reason,
+		Actor:     actor,
+		TicketID:  &ticket.ID,
+	})
+}
 
-	// Update ticket in database
-	if err := tx.Model(&ticket).Updates(map[string]interface{}{
-		"status": "cancelled",
-	}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update ticket: %w", err)
+// ListRefundRequests lists the refund requests of the booking of a support ticket, newest first
+func (s *TicketService) ListRefundRequests(ctx context.Context, ticketNumber string) ([]models.RefundRequest, error) {
+	ticket, err := s.GetTicket(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
 	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if ticket.BookingID == nil {
+		return []models.RefundRequest{}, nil
 	}
+	return s.bookings.ListRefundRequests(ctx, *ticket.BookingID)
+}
 
-	return nil
+// UpdateRefundStatus moves a refund request of the booking of a support ticket to a new status
+func (s *TicketService) UpdateRefundStatus(ctx context.Context, ticketNumber string, refundRequestID uint, status string, actor Actor, reason string) (*models.RefundRequest, error) {
+	ticket, err := s.GetTicket(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.BookingID == nil {
+		return nil, fmt.Errorf("refund request %w for ticket %s", ErrNotFound, ticketNumber)
+	}
+	return s.bookings.UpdateRefundStatus(ctx, *ticket.BookingID, refundRequestID, status, actor, reason)
 }
 
-// GetRefundStatus retrieves the refund status for a ticket
-func (s *TicketService) GetRefundStatus(ctx context.Context, ticketNumber string) (*models.RefundRequest, error) {
-	var refundRequest models.RefundRequest
-	if err := s.db.WithContext(ctx).
-		Where("ticket_number = ?", ticketNumber).
-		Order("created_at DESC").
-		First(&refundRequest).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("no refund request found for ticket: %s", ticketNumber)
-		}
-		return nil, fmt.Errorf("failed to get refund status: %w", err)
-	}
-	return &refundRequest, nil
-}
-
-// calculateRefundAmount calculates the refund amount based on ticket type and cancellation policy
-func (s *TicketService) calculateRefundAmount(ctx context.Context, ticket *models.Ticket) (float64, error) {
-	// TODO: Implement actual refund calculation logic
-	// This should consider:
-	// 1. Ticket type (charter/systematic)
-	// 2. Time until departure
-	// 3. Airline cancellation policy
-	// 4. Any applicable fees or penalties
-
-	// Placeholder implementation
-	switch ticket.TicketType {
-	case "charter":
-		return ticket.Price * 0.5, nil // 50% refund for charter tickets
-	case "systematic":
-		return ticket.Price * 0.8, nil // 80% refund for systematic tickets
-	default:
-		return 0, fmt.Errorf("invalid ticket type: %s", ticket.TicketType)
+// UpdateLatestRefundStatus moves the latest refund request of the booking of a
+// support ticket to a new status
+func (s *TicketService) UpdateLatestRefundStatus(ctx context.Context, ticketNumber string, status string, actor Actor, reason string) (*models.RefundRequest, error) {
+	refundRequests, err := s.ListRefundRequests(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(refundRequests) == 0 {
+		return nil, fmt.Errorf("refund request %w for ticket %s", ErrNotFound, ticketNumber)
 	}
+	latest := refundRequests[0]
+	return s.bookings.UpdateRefundStatus(ctx, latest.BookingID, latest.ID, status, actor, reason)
+}
+
+// Ticket search sort fields
+const (
+	TicketSortCreatedAt = "created_at"
+	TicketSortUpdatedAt = "updated_at"
+	TicketSortPriority  = "priority"
+	TicketSortStatus    = "status"
+)
+
+// ticketSortColumns are the expressions tickets are ordered by for each sort
+// field. Priorities sort by urgency rather than by name.
+var ticketSortColumns = map[string]string{
+	TicketSortCreatedAt: "tickets.created_at",
+	TicketSortUpdatedAt: "tickets.updated_at",
+	TicketSortPriority:  "CASE tickets.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 ELSE 0 END",
+	TicketSortStatus:    "tickets.status",
+}
+
+// Assignee filter values besides an agent ID
+const (
+	AssigneeMe   = "me"
+	AssigneeNone = "none"
+)
+
+// TicketSearch filters, orders and pages a ticket search. Empty fields do not
+// filter. Customers only ever see their own tickets. Bulk jobs keep the search
+// they matched their tickets with as JSON, named after the query parameters.
+type TicketSearch struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"` // customer who opened the ticket
+	Number      string     `json:"number,omitempty"`
+	Phone       string     `json:"phone,omitempty"` // contact phone of the booking
+	Email       string     `json:"email,omitempty"` // contact email of the booking or email of the customer
+	Statuses    []string   `json:"status,omitempty"`
+	Priorities  []string   `json:"priority,omitempty"`
+	Types       []string   `json:"type,omitempty"`
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"` // exclusive
+	UpdatedFrom *time.Time `json:"updated_from,omitempty"`
+	UpdatedTo   *time.Time `json:"updated_to,omitempty"` // exclusive
+	Assignee    string     `json:"assignee,omitempty"`   // agent ID, AssigneeMe or AssigneeNone
+	QueueID     *uuid.UUID `json:"queue_id,omitempty"`
+	Tags        []string   `json:"tag,omitempty"`  // tickets with any of the tags
+	SLA         string     `json:"sla,omitempty"`  // SLAFilterAtRisk, SLAFilterBreached or SLAFilterEscalated
+	Text        string     `json:"q,omitempty"`    // free text over number, subject and description
+	Sort        string     `json:"sort,omitempty"` // created_at (default), updated_at, priority or status
+	Ascending   bool       `json:"ascending,omitempty"`
+	Cursor      string     `json:"-"` // next_cursor of the previous page
+	Limit       int        `json:"-"`
+}
+
+// TicketPage is one page of a ticket search. NextCursor is empty on the last page.
+type TicketPage struct {
+	Tickets    []models.Ticket `json:"tickets"`
+	Total      int64           `json:"total"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
-// UpdateRefundStatus updates the refund status for a ticket
-func (s *TicketService) UpdateRefundStatus(ctx context.Context, ticketNumber string, status string, processedBy string) error {
-	// Start transaction
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("failed to start transaction: %w", tx.Error)
+// ticketCursor is the position after the last ticket of a page: its sort value
+// and ID, with the ordering it belongs to
+type ticketCursor struct {
+	Sort      string    `json:"s"`
+	Ascending bool      `json:"a,omitempty"`
+	Value     string    `json:"v"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// SearchTickets returns a page of the tickets matching search that the actor
+// may see, with the total number of matches. Pages are read with a keyset
+// cursor, so tickets created while paging do not shift later pages.
+func (s *TicketService) SearchTickets(ctx context.Context, search TicketSearch, actor Actor) (*TicketPage, error) {
+	if search.Sort == "" {
+		search.Sort = TicketSortCreatedAt
+	}
+	column, ok := ticketSortColumns[search.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidInput, search.Sort)
+	}
+	if search.Limit <= 0 {
+		search.Limit = 50
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	var cursor *ticketCursor
+	var cursorValue interface{}
+	if search.Cursor != "" {
+		decoded, err := decodeTicketCursor(search.Cursor)
+		if err == nil && (decoded.Sort != search.Sort || decoded.Ascending != search.Ascending) {
+			err = errors.New("cursor belongs to another sort")
 		}
-	}()
-
-	// Update refund request
-	var refundRequest models.RefundRequest
-	if err := tx.Where("ticket_number = ?", ticketNumber).
-		Order("created_at DESC").
-		First(&refundRequest).Error; err != nil {
-		tx.Rollback()
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("no refund request found for ticket: %s", ticketNumber)
+		if err == nil {
+			cursorValue, err = decoded.value()
 		}
-		return fmt.Errorf("failed to get refund request: %w", err)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidInput)
+		}
+		cursor = &decoded
+	}
+
+	query, err := s.searchQuery(ctx, search, actor)
+	if err != nil {
+		return nil, err
 	}
 
-	refundRequest.Status = status
-	refundRequest.ProcessedBy = processedBy
-	refundRequest.ProcessedAt = time.Now()
+	// The filters are shared by the count and the page query
+	query = query.Session(&gorm.Session{})
 
-	if err := tx.Save(&refundRequest).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update refund request: %w", err)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tickets: %w", err)
 	}
 
-	// Update ticket refund status
-	var ticket models.Ticket
-	if err := tx.Where("ticket_number = ?", ticketNumber).First(&ticket).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to get ticket: %w", err)
+	direction, after := "DESC", "<"
+	if search.Ascending {
+		direction, after = "ASC", ">"
+	}
+	if cursor != nil {
+		query = query.Where(fmt.Sprintf("(%s, tickets.id) %s (?, ?)", column, after), cursorValue, cursor.ID)
+	}
+
+	// One ticket more than the page is read to tell whether there is a next page
+	var tickets []models.Ticket
+	if err := query.Select("tickets.*").
+		Preload("SLA").
+		Preload("Tags").
+		Order(fmt.Sprintf("%s %s, tickets.id %s", column, direction, direction)).
+		Limit(search.Limit + 1).
+		Find(&tickets).Error; err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+
+	page := &TicketPage{Tickets: tickets, Total: total}
+	if len(tickets) > search.Limit {
+		page.Tickets = tickets[:search.Limit]
+		last := page.Tickets[search.Limit-1]
+		page.NextCursor = newTicketCursor(search, &last).encode()
+	}
+	return page, nil
+}
+
+// searchQuery applies the filters of a ticket search, leaving out its order
+// and paging
+func (s *TicketService) searchQuery(ctx context.Context, search TicketSearch, actor Actor) (*gorm.DB, error) {
+	query := s.db.WithContext(ctx).Model(&models.Ticket{})
+	if !models.IsStaff(actor.Role) {
+		query = query.Where("tickets.user_id = ?", actor.UserID)
+	}
+	if search.UserID != nil {
+		query = query.Where("tickets.user_id = ?", *search.UserID)
+	}
+	if search.Number != "" {
+		query = query.Where("tickets.number = ?", strings.TrimSpace(search.Number))
 	}
+	if search.Phone != "" || search.Email != "" {
+		query = query.Joins("LEFT JOIN bookings ON bookings.id = tickets.booking_id AND bookings.deleted_at IS NULL")
+	}
+	if search.Phone != "" {
+		query = query.Where("bookings.contact_phone = ?", strings.TrimSpace(search.Phone))
+	}
+	if search.Email != "" {
+		email := strings.ToLower(strings.TrimSpace(search.Email))
+		query = query.Joins("JOIN users ON users.id = tickets.user_id").
+			Where("lower(bookings.contact_email) = ? OR lower(users.email) = ?", email, email)
+	}
+	if len(search.Statuses) > 0 {
+		query = query.Where("tickets.status IN ?", search.Statuses)
+	}
+	if len(search.Priorities) > 0 {
+		query = query.Where("tickets.priority IN ?", search.Priorities)
+	}
+	if len(search.Types) > 0 {
+		query = query.Where("tickets.type IN ?", search.Types)
+	}
+	if search.CreatedFrom != nil {
+		query = query.Where("tickets.created_at >= ?", *search.CreatedFrom)
+	}
+	if search.CreatedTo != nil {
+		query = query.Where("tickets.created_at < ?", *search.CreatedTo)
+	}
+	if search.UpdatedFrom != nil {
+		query = query.Where("tickets.updated_at >= ?", *search.UpdatedFrom)
+	}
+	if search.UpdatedTo != nil {
+		query = query.Where("tickets.updated_at < ?", *search.UpdatedTo)
+	}
+	if len(search.Tags) > 0 {
+		query = query.Where("tickets.id IN (SELECT ticket_id FROM ticket_tags WHERE tag IN ?)", normalizeTags(search.Tags))
+	}
+	if search.QueueID != nil {
+		query = query.Where("tickets.queue_id = ?", *search.QueueID)
+	}
+	switch search.Assignee {
+	case "":
+	case AssigneeNone:
+		query = query.Where("tickets.assignee_id IS NULL")
+	case AssigneeMe:
+		query = query.Where("tickets.assignee_id = ?", actor.UserID)
+	default:
+		assigneeID, err := uuid.Parse(search.Assignee)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid assignee %q", ErrInvalidInput, search.Assignee)
+		}
+		query = query.Where("tickets.assignee_id = ?", assigneeID)
+	}
+	switch search.SLA {
+	case "":
+	case SLAFilterAtRisk:
+		query = query.Joins("JOIN ticket_slas ON ticket_slas.ticket_id = tickets.id").
+			Where(`ticket_slas.resolved_at IS NULL AND ticket_slas.paused_at IS NULL AND (
+				(ticket_slas.first_responded_at IS NULL AND ticket_slas.first_response_warned_at IS NOT NULL AND ticket_slas.first_response_breached_at IS NULL)
+				OR (ticket_slas.resolution_warned_at IS NOT NULL AND ticket_slas.resolution_breached_at IS NULL))`)
+	case SLAFilterBreached:
+		query = query.Joins("JOIN ticket_slas ON ticket_slas.ticket_id = tickets.id").
+			Where("ticket_slas.first_response_breached_at IS NOT NULL OR ticket_slas.resolution_breached_at IS NOT NULL")
+	case SLAFilterEscalated:
+		query = query.Joins("JOIN ticket_slas ON ticket_slas.ticket_id = tickets.id").
+			Where("ticket_slas.escalated_at IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("%w: unknown SLA filter %q", ErrInvalidInput, search.SLA)
+	}
+	if words := significantWords(search.Text); len(words) > 0 {
+		// Every word must match, as a prefix so partial words and numbers are found
+		query = query.Where("tickets.search_vector @@ to_tsquery('simple', ?)", strings.Join(words, ":* & ")+":*")
+	}
+	return query, nil
+}
 
-	ticket.RefundStatus = status
-	if status == "processed" {
-		ticket.RefundProcessed = time.Now()
+func newTicketCursor(search TicketSearch, ticket *models.Ticket) ticketCursor {
+	cursor := ticketCursor{Sort: search.Sort, Ascending: search.Ascending, ID: ticket.ID}
+	switch search.Sort {
+	case TicketSortCreatedAt:
+		cursor.Value = ticket.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case TicketSortUpdatedAt:
+		cursor.Value = ticket.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case TicketSortPriority:
+		cursor.Value = strconv.Itoa(priorityRank(ticket.Priority))
+	case TicketSortStatus:
+		cursor.Value = ticket.Status
 	}
+	return cursor
+}
 
-	if err := tx.Save(&ticket).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update ticket: %w", err)
+// value returns the sort value of the cursor as the type of its column
+func (c ticketCursor) value() (interface{}, error) {
+	switch c.Sort {
+	case TicketSortCreatedAt, TicketSortUpdatedAt:
+		return time.Parse(time.RFC3339Nano, c.Value)
+	case TicketSortPriority:
+		return strconv.Atoi(c.Value)
+	default:
+		return c.Value, nil
 	}
+}
+
+func (c ticketCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+func decodeTicketCursor(encoded string) (ticketCursor, error) {
+	var cursor ticketCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
 	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
 
-	return nil
+// priorityRank mirrors the priority sort expression of ticketSortColumns
+func priorityRank(priority string) int {
+	switch priority {
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	default:
+		return 0
+	}
 }
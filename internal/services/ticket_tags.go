@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"callcenter/internal/models"
+)
+
+// maxTagLength is the longest tag in characters
+const maxTagLength = 50
+
+// normalizeTags trims and lowercases tags, dropping empty and repeated ones
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !containsString(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// checkTags checks that tags can be stored and searched for. Commas separate
+// the tags of a search, so a tag cannot contain one.
+func checkTags(tags []string) error {
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidInput, tag, maxTagLength)
+		}
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("%w: tag %q contains a comma", ErrInvalidInput, tag)
+		}
+	}
+	return nil
+}
+
+// TagTicket adds and removes tags of a ticket on behalf of a staff member and
+// records the change in the ticket history. Adding a tag the ticket has or
+// removing one it does not have changes nothing.
+func (s *TicketService) TagTicket(ctx context.Context, ticketID uuid.UUID, add, remove []string, actor Actor) (*models.Ticket, error) {
+	if !models.IsStaff(actor.Role) {
+		return nil, fmt.Errorf("%w: only staff can tag tickets", ErrForbidden)
+	}
+	add, remove = normalizeTags(add), normalizeTags(remove)
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("%w: no tags to add or remove", ErrInvalidInput)
+	}
+	if err := checkTags(add); err != nil {
+		return nil, err
+	}
+	for _, tag := range add {
+		if containsString(remove, tag) {
+			return nil, fmt.Errorf("%w: tag %q is both added and removed", ErrInvalidInput, tag)
+		}
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ticket models.Ticket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, "id = ?", ticketID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ticket %w: %s", ErrNotFound, ticketID)
+			}
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+
+		var changes []string
+		if len(add) > 0 {
+			tags := make([]models.TicketTag, 0, len(add))
+			for _, tag := range add {
+				tags = append(tags, models.TicketTag{TicketID: ticket.ID, Tag: tag})
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags)
+			if result.Error != nil {
+				return fmt.Errorf("failed to add tags: %w", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				changes = append(changes, "added "+strings.Join(add, ", "))
+			}
+		}
+		if len(remove) > 0 {
+			result := tx.Where("ticket_id = ? AND tag IN ?", ticket.ID, remove).Delete(&models.TicketTag{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to remove tags: %w", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				changes = append(changes, "removed "+strings.Join(remove, ", "))
+			}
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return recordTicketHistory(tx, ticket.ID, "tagged", "Tags "+strings.Join(changes, "; "), actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindTicket(ctx, ticketID.String(), actor)
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/models"
+)
+
+// ticketTransitions are the statuses a ticket can move to from each status.
+// Resolved and closed tickets have to be reopened before work resumes.
+var ticketTransitions = map[string][]string{
+	models.TicketStatusOpen:            {models.TicketStatusInProgress, models.TicketStatusWaitingCustomer, models.TicketStatusResolved, models.TicketStatusClosed},
+	models.TicketStatusInProgress:      {models.TicketStatusOpen, models.TicketStatusWaitingCustomer, models.TicketStatusResolved, models.TicketStatusClosed},
+	models.TicketStatusWaitingCustomer: {models.TicketStatusOpen, models.TicketStatusInProgress, models.TicketStatusResolved, models.TicketStatusClosed},
+	models.TicketStatusResolved:        {models.TicketStatusOpen, models.TicketStatusClosed},
+	models.TicketStatusClosed:          {models.TicketStatusOpen},
+}
+
+// ticketStatusOrder is the order statuses are reported in
+var ticketStatusOrder = []string{
+	models.TicketStatusOpen,
+	models.TicketStatusInProgress,
+	models.TicketStatusWaitingCustomer,
+	models.TicketStatusResolved,
+	models.TicketStatusClosed,
+}
+
+// statusReasons are the reason codes a status change can be given
+var statusReasons = []string{
+	models.StatusReasonWorking,
+	models.StatusReasonAwaitingInfo,
+	models.StatusReasonCustomerReplied,
+	models.StatusReasonSolved,
+	models.StatusReasonNoResponse,
+	models.StatusReasonDuplicate,
+	models.StatusReasonReopened,
+	models.StatusReasonOther,
+}
+
+// checkTransition checks that a ticket may move between two statuses
+func checkTransition(from, to string) error {
+	if _, ok := ticketTransitions[to]; !ok {
+		return fmt.Errorf("%w: unknown ticket status %q", ErrInvalidInput, to)
+	}
+	if from == to {
+		return fmt.Errorf("%w: ticket is already %s", ErrConflict, to)
+	}
+	if !containsString(ticketTransitions[from], to) {
+		if from == models.TicketStatusResolved || from == models.TicketStatusClosed {
+			return fmt.Errorf("%w: a %s ticket has to be reopened before it can move to %s", ErrConflict, from, to)
+		}
+		return fmt.Errorf("%w: ticket cannot move from %s to %s", ErrConflict, from, to)
+	}
+	return nil
+}
+
+// statusReason returns the reason code of a status change, defaulting to the
+// usual reason for the transition when none is given
+func statusReason(from, to, reason string) (string, error) {
+	if reason != "" {
+		if !containsString(statusReasons, reason) {
+			return "", fmt.Errorf("%w: unknown status reason %q", ErrInvalidInput, reason)
+		}
+		return reason, nil
+	}
+
+	switch {
+	case from == models.TicketStatusResolved || from == models.TicketStatusClosed:
+		return models.StatusReasonReopened, nil
+	case from == models.TicketStatusWaitingCustomer && to != models.TicketStatusResolved && to != models.TicketStatusClosed:
+		return models.StatusReasonCustomerReplied, nil
+	}
+	switch to {
+	case models.TicketStatusInProgress:
+		return models.StatusReasonWorking, nil
+	case models.TicketStatusWaitingCustomer:
+		return models.StatusReasonAwaitingInfo, nil
+	case models.TicketStatusResolved, models.TicketStatusClosed:
+		return models.StatusReasonSolved, nil
+	default:
+		return models.StatusReasonOther, nil
+	}
+}
+
+// recordTicketStatus adds a change to the status timeline of a ticket
+func recordTicketStatus(tx *gorm.DB, ticketID uuid.UUID, from, to, reason, description string, actor Actor) error {
+	change := models.TicketStatus{
+		ID:          uuid.New(),
+		TicketID:    ticketID,
+		FromStatus:  from,
+		Status:      to,
+		Reason:      reason,
+		Description: description,
+	}
+	if actor.UserID != uuid.Nil {
+		change.ActorID = &actor.UserID
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to record ticket status: %w", err)
+	}
+	return nil
+}
+
+// TimelineEntry is a status a ticket was in, from the change that moved it
+// there until the next change
+type TimelineEntry struct {
+	FromStatus  string     `json:"from_status,omitempty"`
+	Status      string     `json:"status"`
+	Reason      string     `json:"reason"`
+	Description string     `json:"description,omitempty"`
+	ActorID     *uuid.UUID `json:"actor_id,omitempty"`
+	At          time.Time  `json:"at"`
+	Until       *time.Time `json:"until,omitempty"` // empty for the current status
+	Seconds     int64      `json:"seconds"`         // time in the status, until now for the current status; 0 while closed
+}
+
+// TicketTimeline is the status timeline of a ticket with the total time it
+// spent in each status. Time spent closed is not counted: a closed ticket
+// needs nothing more.
+type TicketTimeline struct {
+	TicketID     uuid.UUID        `json:"ticket_id"`
+	Number       string           `json:"number"`
+	Status       string           `json:"status"`
+	Entries      []TimelineEntry  `json:"entries"`
+	TimeInStatus map[string]int64 `json:"time_in_status"` // seconds by status
+}
+
+// Timeline returns the status timeline of a ticket, oldest change first
+func (s *TicketService) Timeline(ctx context.Context, ticket *models.Ticket, now time.Time) (*TicketTimeline, error) {
+	var changes []models.TicketStatus
+	if err := s.db.WithContext(ctx).
+		Where("ticket_id = ?", ticket.ID).
+		Order("created_at, id").
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ticket timeline: %w", err)
+	}
+	return buildTimeline(ticket, changes, now), nil
+}
+
+// buildTimeline builds the timeline of a ticket from its status changes,
+// oldest first, counting the time in the current status until now
+func buildTimeline(ticket *models.Ticket, changes []models.TicketStatus, now time.Time) *TicketTimeline {
+	timeline := &TicketTimeline{
+		TicketID:     ticket.ID,
+		Number:       ticket.Number,
+		Status:       ticket.Status,
+		Entries:      make([]TimelineEntry, 0, len(changes)),
+		TimeInStatus: make(map[string]int64),
+	}
+	for i, change := range changes {
+		entry := TimelineEntry{
+			FromStatus:  change.FromStatus,
+			Status:      change.Status,
+			Reason:      change.Reason,
+			Description: change.Description,
+			ActorID:     change.ActorID,
+			At:          change.CreatedAt,
+		}
+		end := now
+		if i+1 < len(changes) {
+			end = changes[i+1].CreatedAt
+			entry.Until = &end
+		}
+		if entry.Status != models.TicketStatusClosed && end.After(entry.At) {
+			entry.Seconds = int64(end.Sub(entry.At) / time.Second)
+		}
+		if entry.Status != models.TicketStatusClosed {
+			timeline.TimeInStatus[entry.Status] += entry.Seconds
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+	return timeline
+}
+
+// StatusTimeRow sums up how long the tickets that were in a status spent in
+// it, in seconds
+type StatusTimeRow struct {
+	Status         string  `json:"status"`
+	Tickets        int64   `json:"tickets"`
+	AverageSeconds float64 `json:"average_seconds"`
+	MedianSeconds  float64 `json:"median_seconds"`
+	P90Seconds     float64 `json:"p90_seconds"`
+}
+
+// StatusTimeReport is the time tickets opened in a period spent in each status
+type StatusTimeReport struct {
+	From time.Time       `json:"from"`
+	To   time.Time       `json:"to"`
+	Rows []StatusTimeRow `json:"rows"`
+}
+
+// TimeInStatus reports how long the tickets opened from from until to, of one
+// queue or of all queues, spent in each status, counting the time in their
+// current status until now. Time spent closed is not counted.
+func (s *TicketService) TimeInStatus(ctx context.Context, from, to time.Time, queueID *uuid.UUID, now time.Time) (*StatusTimeReport, error) {
+	queueFilter := ""
+	args := map[string]interface{}{"from": from, "to": to, "now": now}
+	if queueID != nil {
+		queueFilter = "AND tickets.queue_id = @queue"
+		args["queue"] = *queueID
+	}
+
+	report := &StatusTimeReport{From: from, To: to, Rows: []StatusTimeRow{}}
+	if err := s.db.WithContext(ctx).Raw(`
+		WITH spans AS (
+			SELECT ticket_statuses.ticket_id, ticket_statuses.status,
+				extract(epoch FROM coalesce(lead(ticket_statuses.created_at) OVER (
+					PARTITION BY ticket_statuses.ticket_id ORDER BY ticket_statuses.created_at, ticket_statuses.id
+				), @now) - ticket_statuses.created_at) AS seconds
+			FROM ticket_statuses
+			JOIN tickets ON tickets.id = ticket_statuses.ticket_id AND tickets.deleted_at IS NULL
+			WHERE ticket_statuses.deleted_at IS NULL
+				AND tickets.created_at >= @from AND tickets.created_at < @to `+queueFilter+`
+		), totals AS (
+			SELECT ticket_id, status, sum(seconds) AS seconds FROM spans
+			WHERE status <> 'closed'
+			GROUP BY ticket_id, status
+		)
+		SELECT status, count(*) AS tickets,
+			round(avg(seconds)) AS average_seconds,
+			round(percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds)) AS median_seconds,
+			round(percentile_cont(0.9) WITHIN GROUP (ORDER BY seconds)) AS p90_seconds
+		FROM totals
+		GROUP BY status`, args).Scan(&report.Rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to report time in status: %w", err)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return statusRank(report.Rows[i].Status) < statusRank(report.Rows[j].Status)
+	})
+	return report, nil
+}
+
+// statusRank is the position of a status in ticketStatusOrder; unknown
+// statuses come last
+func statusRank(status string) int {
+	for i, s := range ticketStatusOrder {
+		if s == status {
+			return i
+		}
+	}
+	return len(ticketStatusOrder)
+}
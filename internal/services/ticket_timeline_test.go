@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"callcenter/internal/models"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  error
+	}{
+		{models.TicketStatusOpen, models.TicketStatusInProgress, nil},
+		{models.TicketStatusInProgress, models.TicketStatusWaitingCustomer, nil},
+		{models.TicketStatusWaitingCustomer, models.TicketStatusOpen, nil},
+		{models.TicketStatusInProgress, models.TicketStatusResolved, nil},
+		{models.TicketStatusResolved, models.TicketStatusClosed, nil},
+		{models.TicketStatusResolved, models.TicketStatusOpen, nil},
+		{models.TicketStatusClosed, models.TicketStatusOpen, nil},
+		{models.TicketStatusClosed, models.TicketStatusInProgress, ErrConflict},
+		{models.TicketStatusClosed, models.TicketStatusResolved, ErrConflict},
+		{models.TicketStatusResolved, models.TicketStatusWaitingCustomer, ErrConflict},
+		{models.TicketStatusOpen, models.TicketStatusOpen, ErrConflict},
+		{models.TicketStatusOpen, "archived", ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			err := checkTransition(tt.from, tt.to)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("checkTransition() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkTransition() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStatusReason(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to       string
+		reason         string
+		want           string
+		wantInvalidErr bool
+	}{
+		{name: "given", from: models.TicketStatusOpen, to: models.TicketStatusClosed, reason: models.StatusReasonDuplicate, want: models.StatusReasonDuplicate},
+		{name: "unknown", from: models.TicketStatusOpen, to: models.TicketStatusClosed, reason: "bored", wantInvalidErr: true},
+		{name: "start work", from: models.TicketStatusOpen, to: models.TicketStatusInProgress, want: models.StatusReasonWorking},
+		{name: "ask customer", from: models.TicketStatusInProgress, to: models.TicketStatusWaitingCustomer, want: models.StatusReasonAwaitingInfo},
+		{name: "customer replied", from: models.TicketStatusWaitingCustomer, to: models.TicketStatusInProgress, want: models.StatusReasonCustomerReplied},
+		{name: "resolved while waiting", from: models.TicketStatusWaitingCustomer, to: models.TicketStatusResolved, want: models.StatusReasonSolved},
+		{name: "close", from: models.TicketStatusInProgress, to: models.TicketStatusClosed, want: models.StatusReasonSolved},
+		{name: "reopen resolved", from: models.TicketStatusResolved, to: models.TicketStatusOpen, want: models.StatusReasonReopened},
+		{name: "reopen closed", from: models.TicketStatusClosed, to: models.TicketStatusOpen, want: models.StatusReasonReopened},
+		{name: "back to open", from: models.TicketStatusInProgress, to: models.TicketStatusOpen, want: models.StatusReasonOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := statusReason(tt.from, tt.to, tt.reason)
+			if tt.wantInvalidErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("statusReason() error = %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statusReason() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("statusReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTimeline(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	change := func(minutes int, from, to string) models.TicketStatus {
+		return models.TicketStatus{
+			Model:      gorm.Model{CreatedAt: start.Add(time.Duration(minutes) * time.Minute)},
+			ID:         uuid.New(),
+			FromStatus: from,
+			Status:     to,
+		}
+	}
+	ticket := &models.Ticket{ID: uuid.New(), Number: "TK-1", Status: models.TicketStatusInProgress}
+
+	tests := []struct {
+		name        string
+		changes     []models.TicketStatus
+		now         time.Time
+		wantSeconds []int64
+		want        map[string]int64
+	}{
+		{
+			name:        "no changes",
+			now:         start,
+			wantSeconds: []int64{},
+			want:        map[string]int64{},
+		},
+		{
+			name:        "current status until now",
+			changes:     []models.TicketStatus{change(0, "", models.TicketStatusOpen)},
+			now:         start.Add(90 * time.Second),
+			wantSeconds: []int64{90},
+			want:        map[string]int64{models.TicketStatusOpen: 90},
+		},
+		{
+			name: "time in a status adds up",
+			changes: []models.TicketStatus{
+				change(0, "", models.TicketStatusOpen),
+				change(10, models.TicketStatusOpen, models.TicketStatusInProgress),
+				change(30, models.TicketStatusInProgress, models.TicketStatusWaitingCustomer),
+				change(90, models.TicketStatusWaitingCustomer, models.TicketStatusInProgress),
+			},
+			now:         start.Add(100 * time.Minute),
+			wantSeconds: []int64{600, 1200, 3600, 600},
+			want: map[string]int64{
+				models.TicketStatusOpen:            600,
+				models.TicketStatusInProgress:      1800,
+				models.TicketStatusWaitingCustomer: 3600,
+			},
+		},
+		{
+			name: "time closed is not counted",
+			changes: []models.TicketStatus{
+				change(0, "", models.TicketStatusOpen),
+				change(5, models.TicketStatusOpen, models.TicketStatusClosed),
+				change(65, models.TicketStatusClosed, models.TicketStatusOpen),
+			},
+			now:         start.Add(70 * time.Minute),
+			wantSeconds: []int64{300, 0, 300},
+			want:        map[string]int64{models.TicketStatusOpen: 600},
+		},
+		{
+			name:        "change after now",
+			changes:     []models.TicketStatus{change(10, "", models.TicketStatusOpen)},
+			now:         start,
+			wantSeconds: []int64{0},
+			want:        map[string]int64{models.TicketStatusOpen: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeline := buildTimeline(ticket, tt.changes, tt.now)
+			if timeline.Number != ticket.Number || timeline.Status != ticket.Status {
+				t.Errorf("timeline of %s %s, want %s %s", timeline.Number, timeline.Status, ticket.Number, ticket.Status)
+			}
+			if len(timeline.Entries) != len(tt.wantSeconds) {
+				t.Fatalf("got %d entries, want %d", len(timeline.Entries), len(tt.wantSeconds))
+			}
+			for i, entry := range timeline.Entries {
+				if entry.Seconds != tt.wantSeconds[i] {
+					t.Errorf("entry %d (%s) seconds = %d, want %d", i, entry.Status, entry.Seconds, tt.wantSeconds[i])
+				}
+				last := i == len(timeline.Entries)-1
+				if last != (entry.Until == nil) {
+					t.Errorf("entry %d until = %v, want it set on all but the current status", i, entry.Until)
+				}
+			}
+			if len(timeline.TimeInStatus) != len(tt.want) {
+				t.Errorf("time in status = %v, want %v", timeline.TimeInStatus, tt.want)
+			}
+			for status, seconds := range tt.want {
+				if timeline.TimeInStatus[status] != seconds {
+					t.Errorf("time in %s = %d, want %d", status, timeline.TimeInStatus[status], seconds)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package ticketnumber formats and validates the support ticket numbers that
+// customers read out over the phone, such as TKT-2026-0001234: a prefix, the
+// year the ticket was opened, a zero-padded sequence number and a check digit.
+//
+// The check digit uses the Damm algorithm, which catches every single mistyped
+// digit and every swap of two adjacent digits.
+package ticketnumber
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format describes how ticket numbers are written
+type Format struct {
+	Prefix     string // letters before the first dash, e.g. TKT
+	Year       bool   // include the year the ticket was opened
+	Digits     int    // minimum digits of the sequence number, zero-padded
+	CheckDigit bool   // append a check digit to the sequence number
+}
+
+// DefaultFormat writes numbers such as TKT-2026-0001234
+var DefaultFormat = Format{
+	Prefix:     "TKT",
+	Year:       true,
+	Digits:     6,
+	CheckDigit: true,
+}
+
+var prefixPattern = regexp.MustCompile(`^[A-Za-z]{1,8}$`)
+
+// Validate checks that numbers written in the format can be read back
+func (f Format) Validate() error {
+	if !prefixPattern.MatchString(f.Prefix) {
+		return fmt.Errorf("ticket number prefix must be 1 to 8 letters: %q", f.Prefix)
+	}
+	if f.Digits < 1 || f.Digits > 12 {
+		return fmt.Errorf("ticket number digits must be between 1 and 12: %d", f.Digits)
+	}
+	return nil
+}
+
+// Number writes the ticket number for a sequence number, opened at the given time
+func (f Format) Number(sequence int64, at time.Time) string {
+	digits := fmt.Sprintf("%0*d", f.Digits, sequence)
+	year := ""
+	if f.Year {
+		year = fmt.Sprintf("%04d", at.UTC().Year())
+	}
+	if f.CheckDigit {
+		digits += string(rune('0' + checkDigit(year+digits)))
+	}
+
+	parts := []string{strings.ToUpper(f.Prefix)}
+	if f.Year {
+		parts = append(parts, year)
+	}
+	return strings.Join(append(parts, digits), "-")
+}
+
+// Parser finds and validates the numbers of one format
+type Parser struct {
+	format  Format
+	pattern *regexp.Regexp // captures the year and the digits
+}
+
+// Parser compiles a parser for numbers of the format
+func (f Format) Parser() *Parser {
+	minDigits := f.Digits
+	if f.CheckDigit {
+		minDigits++
+	}
+	year := ""
+	if f.Year {
+		year = `(\d{4})-`
+	}
+	return &Parser{
+		format:  f,
+		pattern: regexp.MustCompile(fmt.Sprintf(`(?i)\b%s-%s(\d{%d,})\b`, regexp.QuoteMeta(f.Prefix), year, minDigits)),
+	}
+}
+
+// Valid reports whether number is written in the format with a correct check digit
+func (p *Parser) Valid(number string) bool {
+	match := p.pattern.FindStringSubmatch(number)
+	return match != nil && match[0] == number && p.checks(match)
+}
+
+// Find returns the first number of the format in text whose check digit is
+// correct, with the prefix in upper case as it is stored. Numbers with a
+// wrong check digit are most likely misheard or mistyped and are skipped.
+func (p *Parser) Find(text string) string {
+	for _, match := range p.pattern.FindAllStringSubmatch(text, -1) {
+		if p.checks(match) {
+			return strings.ToUpper(match[0])
+		}
+	}
+	return ""
+}
+
+// checks verifies the check digit of a pattern match
+func (p *Parser) checks(match []string) bool {
+	if !p.format.CheckDigit {
+		return true
+	}
+	return checkDigit(strings.Join(match[1:], "")) == 0
+}
+
+// dammTable is the quasigroup of the Damm algorithm
+var dammTable = [10][10]int{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// checkDigit returns the Damm check digit of a string of ASCII digits. Digits
+// followed by their check digit give zero.
+func checkDigit(digits string) int {
+	interim := 0
+	for _, d := range digits {
+		interim = dammTable[interim][d-'0']
+	}
+	return interim
+}
@@ -0,0 +1,148 @@
+package ticketnumber
+
+import (
+	"testing"
+	"time"
+)
+
+var opened = time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		sequence int64
+		want     string
+	}{
+		{"default", DefaultFormat, 1234, "TKT-2026-0012348"},
+		{"first ticket", DefaultFormat, 1, "TKT-2026-0000015"},
+		{"more digits than padded", DefaultFormat, 12345678, "TKT-2026-123456788"},
+		{"no year or check digit", Format{Prefix: "cs", Digits: 4}, 42, "CS-0042"},
+		{"no year", Format{Prefix: "CS", Digits: 4, CheckDigit: true}, 42, "CS-00427"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.format.Number(tt.sequence, opened)
+			if got != tt.want {
+				t.Fatalf("Number(%d) = %q, want %q", tt.sequence, got, tt.want)
+			}
+			if !tt.format.Parser().Valid(got) {
+				t.Errorf("Valid(%q) = false, want true", got)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	if got := checkDigit("572"); got != 4 {
+		t.Errorf("checkDigit(572) = %d, want 4", got)
+	}
+	if got := checkDigit("5724"); got != 0 {
+		t.Errorf("checkDigit(5724) = %d, want 0", got)
+	}
+
+	parser := DefaultFormat.Parser()
+	number := []byte("TKT-2026-0012348")
+	digits := []int{}
+	for i, c := range number {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, i)
+		}
+	}
+
+	for _, i := range digits {
+		for d := byte('0'); d <= '9'; d++ {
+			if d == number[i] {
+				continue
+			}
+			mistyped := append([]byte(nil), number...)
+			mistyped[i] = d
+			if parser.Valid(string(mistyped)) {
+				t.Errorf("Valid(%q) = true for a mistyped digit", mistyped)
+			}
+		}
+	}
+	for k := 0; k+1 < len(digits); k++ {
+		i, j := digits[k], digits[k+1]
+		if j != i+1 || number[i] == number[j] {
+			continue
+		}
+		swapped := append([]byte(nil), number...)
+		swapped[i], swapped[j] = swapped[j], swapped[i]
+		if parser.Valid(string(swapped)) {
+			t.Errorf("Valid(%q) = true for swapped digits", swapped)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	parser := DefaultFormat.Parser()
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"TKT-2026-0012348", true},
+		{"tkt-2026-0012348", true},
+		{"TKT-2026-0012347", false},
+		{"TKT-2026-012348", false},
+		{"TKT-0012348", false},
+		{"ABC-2026-0012348", false},
+		{"TKT-2026-0012348 ", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := parser.Valid(tt.number); got != tt.want {
+			t.Errorf("Valid(%q) = %t, want %t", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	parser := DefaultFormat.Parser()
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"in a sentence", "my ticket is TKT-2026-0012348, thanks", "TKT-2026-0012348"},
+		{"lower case", "status of tkt-2026-0012348?", "TKT-2026-0012348"},
+		{"in Persian", "شماره تیکت من TKT-2026-0012348 است", "TKT-2026-0012348"},
+		{"wrong check digit skipped", "TKT-2026-0012347 or TKT-2026-0000015", "TKT-2026-0000015"},
+		{"wrong check digit only", "TKT-2026-0012347", ""},
+		{"part of a longer word", "XTKT-2026-0012348", ""},
+		{"none", "I want a refund", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.Find(tt.text); got != tt.want {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		wantErr bool
+	}{
+		{"default", DefaultFormat, false},
+		{"no prefix", Format{Digits: 6}, true},
+		{"prefix with digits", Format{Prefix: "T1", Digits: 6}, true},
+		{"prefix too long", Format{Prefix: "TICKETNUM", Digits: 6}, true},
+		{"no digits", Format{Prefix: "TKT"}, true},
+		{"too many digits", Format{Prefix: "TKT", Digits: 13}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.format.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
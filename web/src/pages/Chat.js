@@ -39,7 +39,6 @@ const Chat = () => {
     const message = {
       content: inputMessage,
       platform: 'web',
-      user_id: 'user123', // TODO: Replace with actual user ID
     };
 
     await dispatch(sendMessage(message));
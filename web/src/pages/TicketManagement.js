@@ -19,11 +19,17 @@ import {
   TableRow,
   Alert,
 } from '@mui/material';
-import { getTicketDetails, cancelTicket, getRefundStatus } from '../store/slices/ticketSlice';
+import {
+  getTicketDetails,
+  quoteCancellation,
+  cancelTicket,
+  getRefundStatus,
+  clearQuote,
+} from '../store/slices/ticketSlice';
 
 const TicketManagement = () => {
   const dispatch = useDispatch();
-  const { currentTicket, refundStatus, loading, error } = useSelector((state) => state.tickets);
+  const { currentTicket, refundStatus, quote, loading, error } = useSelector((state) => state.tickets);
   const [ticketNumber, setTicketNumber] = useState('');
   const [cancelDialogOpen, setCancelDialogOpen] = useState(false);
   const [cancelReason, setCancelReason] = useState('');
@@ -36,14 +42,24 @@ const TicketManagement = () => {
     await dispatch(getRefundStatus(ticketNumber));
   };
 
-  const handleCancelClick = () => {
-    setCancelDialogOpen(true);
+  // The refund is quoted first; cancelling accepts exactly the quoted amount
+  const handleCancelClick = async () => {
+    const result = await dispatch(quoteCancellation(ticketNumber));
+    if (quoteCancellation.fulfilled.match(result)) {
+      setCancelDialogOpen(true);
+    }
+  };
+
+  const handleCancelClose = () => {
+    setCancelDialogOpen(false);
+    dispatch(clearQuote());
   };
 
   const handleCancelConfirm = async () => {
-    if (!cancelReason.trim()) return;
+    if (!cancelReason.trim() || !quote) return;
 
-    await dispatch(cancelTicket({ ticketNumber, reason: cancelReason }));
+    await dispatch(cancelTicket({ ticketNumber, quoteToken: quote.quote_token, reason: cancelReason }));
+    await dispatch(getRefundStatus(ticketNumber));
     setCancelDialogOpen(false);
     setCancelReason('');
   };
@@ -178,9 +194,50 @@ const TicketManagement = () => {
       </Paper>
 
       {/* Cancel Dialog */}
-      <Dialog open={cancelDialogOpen} onClose={() => setCancelDialogOpen(false)}>
+      <Dialog open={cancelDialogOpen} onClose={handleCancelClose}>
         <DialogTitle>Cancel Ticket</DialogTitle>
         <DialogContent>
+          {quote && (
+            <TableContainer sx={{ mb: 2 }}>
+              <Table size="small">
+                <TableBody>
+                  <TableRow>
+                    <TableCell component="th">Base Fare</TableCell>
+                    <TableCell>
+                      {quote.breakdown.base_fare} {quote.breakdown.currency}
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell component="th">
+                      Penalty ({quote.breakdown.penalty_percent}%)
+                    </TableCell>
+                    <TableCell>
+                      {quote.breakdown.penalty} {quote.breakdown.currency}
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell component="th">Fee</TableCell>
+                    <TableCell>
+                      {quote.breakdown.fee} {quote.breakdown.currency}
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell component="th">Taxes Returned</TableCell>
+                    <TableCell>
+                      {quote.breakdown.taxes_refunded} {quote.breakdown.currency}
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell component="th">Refund</TableCell>
+                    <TableCell>{quote.breakdown.amount_display}</TableCell>
+                  </TableRow>
+                </TableBody>
+              </Table>
+              <Typography variant="body2" color="text.secondary" sx={{ mt: 1 }}>
+                This quote is valid until {new Date(quote.expires_at).toLocaleString()}.
+              </Typography>
+            </TableContainer>
+          )}
           <TextField
             autoFocus
             margin="dense"
@@ -193,8 +250,8 @@ const TicketManagement = () => {
           />
         </DialogContent>
         <DialogActions>
-          <Button onClick={() => setCancelDialogOpen(false)}>Cancel</Button>
-          <Button onClick={handleCancelConfirm} color="error">
+          <Button onClick={handleCancelClose}>Cancel</Button>
+          <Button onClick={handleCancelConfirm} color="error" disabled={loading || !quote}>
             Confirm Cancellation
           </Button>
         </DialogActions>
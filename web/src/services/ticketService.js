@@ -5,8 +5,15 @@ export const ticketService = {
     return api.get(`/tickets/${ticketNumber}`);
   },
 
-  cancelTicket: async (ticketNumber, reason) => {
-    return api.post(`/tickets/${ticketNumber}/cancel`, { reason });
+  quoteCancellation: async (ticketNumber) => {
+    return api.post(`/tickets/${ticketNumber}/refund-quote`);
+  },
+
+  cancelTicket: async (ticketNumber, quoteToken, reason) => {
+    return api.post(`/tickets/${ticketNumber}/cancel`, {
+      quote_token: quoteToken,
+      reason,
+    });
   },
 
   getRefundStatus: async (ticketNumber) => {
@@ -13,11 +13,23 @@ export const getTicketDetails = createAsyncThunk(
   }
 );
 
+export const quoteCancellation = createAsyncThunk(
+  'tickets/quoteCancellation',
+  async (ticketNumber, { rejectWithValue }) => {
+    try {
+      const response = await ticketService.quoteCancellation(ticketNumber);
+      return response.data;
+    } catch (error) {
+      return rejectWithValue(error.response.data);
+    }
+  }
+);
+
 export const cancelTicket = createAsyncThunk(
   'tickets/cancel',
-  async ({ ticketNumber, reason }, { rejectWithValue }) => {
+  async ({ ticketNumber, quoteToken, reason }, { rejectWithValue }) => {
     try {
-      const response = await ticketService.cancelTicket(ticketNumber, reason);
+      const response = await ticketService.cancelTicket(ticketNumber, quoteToken, reason);
       return response.data;
     } catch (error) {
       return rejectWithValue(error.response.data);
@@ -40,6 +52,7 @@ export const getRefundStatus = createAsyncThunk(
 const initialState = {
   currentTicket: null,
   refundStatus: null,
+  quote: null,
   loading: false,
   error: null,
 };
@@ -51,8 +64,12 @@ const ticketSlice = createSlice({
     clearTicket: (state) => {
       state.currentTicket = null;
       state.refundStatus = null;
+      state.quote = null;
       state.error = null;
     },
+    clearQuote: (state) => {
+      state.quote = null;
+    },
   },
   extraReducers: (builder) => {
     builder
@@ -68,12 +85,26 @@ const ticketSlice = createSlice({
         state.loading = false;
         state.error = action.payload?.error || 'Failed to get ticket details';
       })
+      .addCase(quoteCancellation.pending, (state) => {
+        state.loading = true;
+        state.error = null;
+        state.quote = null;
+      })
+      .addCase(quoteCancellation.fulfilled, (state, action) => {
+        state.loading = false;
+        state.quote = action.payload;
+      })
+      .addCase(quoteCancellation.rejected, (state, action) => {
+        state.loading = false;
+        state.error = action.payload?.error || 'Failed to quote the refund';
+      })
       .addCase(cancelTicket.pending, (state) => {
         state.loading = true;
         state.error = null;
       })
       .addCase(cancelTicket.fulfilled, (state, action) => {
         state.loading = false;
+        state.quote = null;
         if (state.currentTicket) {
           state.currentTicket.status = 'cancelled';
         }
@@ -88,7 +119,7 @@ const ticketSlice = createSlice({
       })
       .addCase(getRefundStatus.fulfilled, (state, action) => {
         state.loading = false;
-        state.refundStatus = action.payload.refund_request;
+        state.refundStatus = action.payload.refund_requests?.[0] || null;
       })
       .addCase(getRefundStatus.rejected, (state, action) => {
         state.loading = false;
@@ -97,5 +128,5 @@ const ticketSlice = createSlice({
   },
 });
 
-export const { clearTicket } = ticketSlice.actions;
+export const { clearTicket, clearQuote } = ticketSlice.actions;
 export default ticketSlice.reducer; 
\ No newline at end of file